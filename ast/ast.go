@@ -46,6 +46,9 @@ func (p *Program) String() string {
 type LetStatement struct {
 	Token token.Token
 	Name  *Identifier
+	// Rest holds the names after Name in "let x, y = f()", which unpacks a
+	// tuple or array. It is nil when the statement binds one name.
+	Rest  []*Identifier
 	Value Expression
 }
 
@@ -60,6 +63,9 @@ func (ls *LetStatement) String() string {
 
 	out.WriteString(ls.TokenLiteral() + " ")
 	out.WriteString(ls.Name.String())
+	for _, name := range ls.Rest {
+		out.WriteString(", " + name.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -73,6 +79,14 @@ func (ls *LetStatement) String() string {
 type Identifier struct {
 	Token token.Token
 	Value string
+
+	// Resolved is set by the resolver for variables local to an enclosing
+	// function. Such a variable lives Depth function scopes up from this
+	// identifier, in slot Index of that scope's environment. Globals and
+	// builtins are left unresolved and looked up by name.
+	Resolved bool
+	Depth    int
+	Index    int
 }
 
 func (i *Identifier) expressionNode() {}
@@ -108,6 +122,26 @@ func (r *ReturnStatement) TokenLiteral() string {
 
 func (r *ReturnStatement) statementNode() {}
 
+// TupleLiteral is the list of values in "return a, b".
+type TupleLiteral struct {
+	Token    token.Token
+	Elements []Expression
+}
+
+func (tl *TupleLiteral) TokenLiteral() string {
+	return tl.Token.Literal
+}
+
+func (tl *TupleLiteral) String() string {
+	elements := make([]string, 0, len(tl.Elements))
+	for _, el := range tl.Elements {
+		elements = append(elements, el.String())
+	}
+	return strings.Join(elements, ", ")
+}
+
+func (tl *TupleLiteral) expressionNode() {}
+
 type ExpressionStatement struct {
 	Token      token.Token
 	Expression Expression
@@ -142,6 +176,21 @@ func (il *IntegerLiteral) String() string {
 
 func (il *IntegerLiteral) expressionNode() {}
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -201,6 +250,24 @@ func (ie *InfixExpression) String() string {
 
 func (ie *InfixExpression) expressionNode() {}
 
+// IsExpression tests the type of a value, as in "x is INTEGER". Type names
+// the type rather than a variable.
+type IsExpression struct {
+	Token token.Token
+	Left  Expression
+	Type  *Identifier
+}
+
+func (ie *IsExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *IsExpression) String() string {
+	return "(" + ie.Left.String() + " is " + ie.Type.String() + ")"
+}
+
+func (ie *IsExpression) expressionNode() {}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -212,6 +279,25 @@ func (b *Boolean) String() string { return b.Token.Literal }
 
 func (b *Boolean) expressionNode() {}
 
+// AssignExpression rebinds an existing variable. Operator is "=" or a
+// compound assignment such as "+=".
+type AssignExpression struct {
+	Token    token.Token
+	Name     *Identifier
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+func (ae *AssignExpression) String() string {
+	return "(" + ae.Name.String() + " " + ae.Operator + " " + ae.Value.String() + ")"
+}
+
 type IfExpression struct {
 	Token       token.Token
 	Condition   Expression
@@ -241,6 +327,78 @@ func (ie *IfExpression) String() string {
 
 func (ie *IfExpression) expressionNode() {}
 
+// WhileExpression runs Body for as long as Condition is truthy. A do-while
+// loop tests Condition after each run, so Body runs at least once.
+type WhileExpression struct {
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+	DoWhile   bool
+	// Label names the loop for break and continue statements, or is empty.
+	Label string
+}
+
+func (we *WhileExpression) TokenLiteral() string {
+	return we.Token.Literal
+}
+
+func (we *WhileExpression) String() string {
+	if we.DoWhile {
+		return labelPrefix(we.Label) + "do " + we.Body.String() + " while" + we.Condition.String()
+	}
+	return labelPrefix(we.Label) + "while" + we.Condition.String() + " " + we.Body.String()
+}
+
+func (we *WhileExpression) expressionNode() {}
+
+// ForExpression runs Body once for each element of Iterable, with Variable
+// bound to the element.
+type ForExpression struct {
+	Token    token.Token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+	// Label names the loop for break and continue statements, or is empty.
+	Label string
+}
+
+func (fe *ForExpression) TokenLiteral() string {
+	return fe.Token.Literal
+}
+
+func (fe *ForExpression) String() string {
+	return labelPrefix(fe.Label) + "for(" + fe.Variable.String() + " in " + fe.Iterable.String() + ") " + fe.Body.String()
+}
+
+func (fe *ForExpression) expressionNode() {}
+
+func labelPrefix(label string) string {
+	if label == "" {
+		return ""
+	}
+	return label + ": "
+}
+
+// BranchStatement is a break or continue statement. Without a Label it
+// applies to the innermost enclosing loop.
+type BranchStatement struct {
+	Token token.Token
+	Label *Identifier
+}
+
+func (bs *BranchStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BranchStatement) String() string {
+	if bs.Label == nil {
+		return bs.TokenLiteral() + ";"
+	}
+	return bs.TokenLiteral() + " " + bs.Label.String() + ";"
+}
+
+func (bs *BranchStatement) statementNode() {}
+
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
@@ -268,6 +426,17 @@ type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
 	Body       *BlockStatement
+	// Name is the name the function is bound to when it is the value of a
+	// let statement, or empty.
+	Name string
+
+	// Locals names the function's environment slots, parameters first, as
+	// assigned by the resolver. It is nil if the function was not resolved.
+	Locals []string
+	// Captured reports for each of Locals whether a nested function literal
+	// refers to it. The others are cleared when a call returns, so closures
+	// the call created keep only the variables they use alive.
+	Captured []bool
 }
 
 func (fl *FunctionLiteral) TokenLiteral() string {
@@ -323,6 +492,10 @@ func (ce *CallExpression) expressionNode() {}
 type ArrayLiteral struct {
 	Token    token.Token
 	Elements []Expression
+
+	// Constant is set by the optimizer when every element is a literal, so
+	// the evaluator may build the array once and reuse it.
+	Constant bool
 }
 
 func (al *ArrayLiteral) TokenLiteral() string {
@@ -375,6 +548,12 @@ func (ie *IndexExpression) expressionNode() {}
 type HashLiteral struct {
 	Token token.Token
 	Pairs map[Expression]Expression
+	// Keys lists the keys of Pairs in source order.
+	Keys []Expression
+
+	// Constant is set by the optimizer when every key and value is a
+	// literal, so the evaluator may build the hash once and reuse it.
+	Constant bool
 }
 
 func (hl *HashLiteral) TokenLiteral() string {
@@ -386,8 +565,8 @@ func (hl *HashLiteral) String() string {
 
 	var pairs []string
 
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+" : "+value.String())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.String()+" : "+hl.Pairs[key].String())
 	}
 
 	out.WriteString("{")
@@ -398,3 +577,19 @@ func (hl *HashLiteral) String() string {
 }
 
 func (hl *HashLiteral) expressionNode() {}
+
+// ImportExpression loads the module named by Path, as in import "go:mylib".
+type ImportExpression struct {
+	Token token.Token
+	Path  string
+}
+
+func (ie *ImportExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *ImportExpression) String() string {
+	return `import "` + ie.Path + `"`
+}
+
+func (ie *ImportExpression) expressionNode() {}
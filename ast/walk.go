@@ -0,0 +1,104 @@
+package ast
+
+// Inspect traverses the tree rooted at node in depth-first order, calling f
+// for each node. If f returns false, the children of that node are skipped.
+// Nil children are not visited.
+func Inspect(node Node, f func(Node) bool) {
+	if isNilNode(node) || !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, stmt := range n.Statements {
+			Inspect(stmt, f)
+		}
+	case *BlockStatement:
+		for _, stmt := range n.Statements {
+			Inspect(stmt, f)
+		}
+	case *LetStatement:
+		Inspect(n.Name, f)
+		for _, name := range n.Rest {
+			Inspect(name, f)
+		}
+		Inspect(n.Value, f)
+	case *ReturnStatement:
+		Inspect(n.ReturnValue, f)
+	case *ExpressionStatement:
+		Inspect(n.Expression, f)
+	case *PrefixExpression:
+		Inspect(n.Right, f)
+	case *InfixExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Right, f)
+	case *IsExpression:
+		Inspect(n.Left, f)
+	case *AssignExpression:
+		Inspect(n.Name, f)
+		Inspect(n.Value, f)
+	case *IfExpression:
+		Inspect(n.Condition, f)
+		Inspect(n.Consequence, f)
+		Inspect(n.Alternative, f)
+	case *WhileExpression:
+		if n.DoWhile {
+			Inspect(n.Body, f)
+			Inspect(n.Condition, f)
+		} else {
+			Inspect(n.Condition, f)
+			Inspect(n.Body, f)
+		}
+	case *ForExpression:
+		Inspect(n.Variable, f)
+		Inspect(n.Iterable, f)
+		Inspect(n.Body, f)
+	case *FunctionLiteral:
+		for _, param := range n.Parameters {
+			Inspect(param, f)
+		}
+		Inspect(n.Body, f)
+	case *CallExpression:
+		Inspect(n.Function, f)
+		for _, arg := range n.Arguments {
+			Inspect(arg, f)
+		}
+	case *ArrayLiteral:
+		for _, element := range n.Elements {
+			Inspect(element, f)
+		}
+	case *TupleLiteral:
+		for _, element := range n.Elements {
+			Inspect(element, f)
+		}
+	case *IndexExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Index, f)
+	case *HashLiteral:
+		for _, key := range n.Keys {
+			Inspect(key, f)
+			Inspect(n.Pairs[key], f)
+		}
+	}
+}
+
+// isNilNode reports whether node is nil or a typed nil pointer, which the
+// parser leaves behind for constructs it could not parse.
+func isNilNode(node Node) bool {
+	switch n := node.(type) {
+	case nil:
+		return true
+	case *BlockStatement:
+		return n == nil
+	case *Identifier:
+		return n == nil
+	case *LetStatement:
+		return n == nil
+	case *ReturnStatement:
+		return n == nil
+	case *ExpressionStatement:
+		return n == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"monkey/bench"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+func runBench(args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	count := flags.Int("count", 5, "run each workload `n` times")
+	benchtime := flags.Duration("benchtime", time.Second, "run each measurement for at least `d`")
+	run := flags.String("run", "", "only run workloads whose name matches `regexp`")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey bench [-count n] [-benchtime d] [-run regexp] [script.mky ...]")
+		fmt.Fprintln(flags.Output(), "Runs the standard workloads, or the given scripts instead.")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *count < 1 {
+		flags.Usage()
+		return 2
+	}
+
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey bench: -run: %s\n", err)
+		return 2
+	}
+
+	workloads := bench.Workloads()
+	if flags.NArg() > 0 {
+		workloads = workloads[:0]
+		for _, path := range flags.Args() {
+			source, err := os.ReadFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "monkey bench: %s\n", err)
+				return 1
+			}
+			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			workloads = append(workloads, bench.Workload{Name: name, Source: string(source)})
+		}
+	}
+
+	width := 0
+	for _, w := range workloads {
+		width = max(width, len(w.Name))
+	}
+
+	for _, w := range workloads {
+		if !filter.MatchString(w.Name) {
+			continue
+		}
+
+		results := make([]bench.Result, 0, *count)
+		for i := 0; i < *count; i++ {
+			result, err := bench.Run(w, *benchtime)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "monkey bench: %s\n", err)
+				return 1
+			}
+			results = append(results, result)
+		}
+
+		s := bench.Summarize(results)
+		fmt.Printf("%-*s  %12.0f ns/op ±%4.1f%%  %10d allocs/op  %12d B/op\n",
+			width, s.Name, s.NsPerOp, s.NsPerOpDev*100, s.AllocsPerOp, s.BytesPerOp)
+	}
+
+	return 0
+}
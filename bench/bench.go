@@ -0,0 +1,161 @@
+// Package bench measures how fast the interpreter runs Monkey programs. It
+// ships a standard suite of workloads so performance changes between
+// interpreter versions can be compared on equal terms.
+package bench
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"math"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/optimizer"
+	"monkey/parser"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed workloads/*.mky
+var workloadFiles embed.FS
+
+// Workload is a Monkey program to benchmark.
+type Workload struct {
+	Name   string
+	Source string
+}
+
+// Workloads returns the standard suite, sorted by name:
+//
+//	fib      naive recursion: function calls and integer arithmetic
+//	hashes   building hashes with computed keys and looking them up
+//	sort     quicksort of pseudo-random integers: closures and arrays
+//	strings  repeated string concatenation
+func Workloads() []Workload {
+	entries, err := workloadFiles.ReadDir("workloads")
+	if err != nil {
+		panic(err)
+	}
+
+	workloads := make([]Workload, 0, len(entries))
+	for _, entry := range entries {
+		source, err := workloadFiles.ReadFile(path.Join("workloads", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		workloads = append(workloads, Workload{
+			Name:   strings.TrimSuffix(entry.Name(), ".mky"),
+			Source: string(source),
+		})
+	}
+
+	sort.Slice(workloads, func(i, j int) bool { return workloads[i].Name < workloads[j].Name })
+	return workloads
+}
+
+// Result is one measurement of a workload.
+type Result struct {
+	Name        string
+	Iterations  int
+	NsPerOp     float64
+	AllocsPerOp uint64
+	BytesPerOp  uint64
+}
+
+// Run evaluates w repeatedly for at least benchtime, in a fresh environment
+// each time, and reports the cost of one evaluation. Parsing is not
+// measured. Output written by the workload is discarded.
+func Run(w Workload, benchtime time.Duration) (Result, error) {
+	p := parser.New(lexer.New(w.Source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return Result{}, fmt.Errorf("%s: %s", w.Name, strings.Join(p.Errors(), "; "))
+	}
+	optimizer.Fold(program)
+
+	in := evaluator.New(evaluator.WithStdout(io.Discard))
+	run := func(n int) (time.Duration, runtime.MemStats, runtime.MemStats) {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			in.Eval(program, object.NewEnvironment())
+		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+		return elapsed, before, after
+	}
+
+	if result, ok := in.Eval(program, object.NewEnvironment()).(*object.Error); ok {
+		return Result{}, fmt.Errorf("%s: %w", w.Name, result)
+	}
+
+	// Grow the iteration count until a round lasts benchtime, the same way
+	// the testing package does.
+	n := 1
+	for {
+		elapsed, before, after := run(n)
+		if elapsed >= benchtime || n >= 1e9 {
+			return Result{
+				Name:        w.Name,
+				Iterations:  n,
+				NsPerOp:     float64(elapsed.Nanoseconds()) / float64(n),
+				AllocsPerOp: (after.Mallocs - before.Mallocs) / uint64(n),
+				BytesPerOp:  (after.TotalAlloc - before.TotalAlloc) / uint64(n),
+			}, nil
+		}
+
+		perOp := max(elapsed.Nanoseconds()/int64(n), 1)
+		next := int(benchtime.Nanoseconds() * 6 / 5 / perOp)
+		n = min(max(next, n+1), 100*n, 1e9)
+	}
+}
+
+// Summary aggregates repeated measurements of one workload.
+type Summary struct {
+	Name        string
+	Runs        int
+	NsPerOp     float64 // mean
+	NsPerOpDev  float64 // standard deviation as a fraction of the mean
+	AllocsPerOp uint64
+	BytesPerOp  uint64
+}
+
+// Summarize combines results of the same workload. Allocation counts are
+// taken from the smallest measurement, since the garbage collector's own
+// bookkeeping occasionally inflates one.
+func Summarize(results []Result) Summary {
+	if len(results) == 0 {
+		return Summary{}
+	}
+
+	s := Summary{
+		Name:        results[0].Name,
+		Runs:        len(results),
+		AllocsPerOp: results[0].AllocsPerOp,
+		BytesPerOp:  results[0].BytesPerOp,
+	}
+
+	for _, r := range results {
+		s.NsPerOp += r.NsPerOp
+		s.AllocsPerOp = min(s.AllocsPerOp, r.AllocsPerOp)
+		s.BytesPerOp = min(s.BytesPerOp, r.BytesPerOp)
+	}
+	s.NsPerOp /= float64(len(results))
+
+	if len(results) > 1 && s.NsPerOp > 0 {
+		var variance float64
+		for _, r := range results {
+			variance += (r.NsPerOp - s.NsPerOp) * (r.NsPerOp - s.NsPerOp)
+		}
+		variance /= float64(len(results) - 1)
+		s.NsPerOpDev = math.Sqrt(variance) / s.NsPerOp
+	}
+
+	return s
+}
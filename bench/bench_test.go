@@ -0,0 +1,62 @@
+package bench
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWorkloads(t *testing.T) {
+	expected := []string{"fib", "hashes", "sort", "strings"}
+
+	workloads := Workloads()
+	if len(workloads) != len(expected) {
+		t.Fatalf("wrong number of workloads. want=%d, got=%d", len(expected), len(workloads))
+	}
+
+	for i, w := range workloads {
+		if w.Name != expected[i] {
+			t.Errorf("workload %d has wrong name. want=%q, got=%q", i, expected[i], w.Name)
+		}
+
+		result, err := Run(w, 0)
+		if err != nil {
+			t.Errorf("%s: %s", w.Name, err)
+			continue
+		}
+		if result.Iterations != 1 || result.NsPerOp <= 0 || result.AllocsPerOp == 0 {
+			t.Errorf("%s: implausible result %+v", w.Name, result)
+		}
+	}
+}
+
+func TestRunReportsErrors(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{"let = 1", "broken: expected next token to be IDENT, got = instead; no prefix parse function for = found"},
+		{"missing", "broken: NameError: identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		_, err := Run(Workload{Name: "broken", Source: tt.source}, 0)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%q: wrong error. want=%q, got=%v", tt.source, tt.expected, err)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]Result{
+		{Name: "w", NsPerOp: 90, AllocsPerOp: 12, BytesPerOp: 100},
+		{Name: "w", NsPerOp: 110, AllocsPerOp: 10, BytesPerOp: 120},
+	})
+
+	if s.Name != "w" || s.Runs != 2 || s.NsPerOp != 100 || s.AllocsPerOp != 10 || s.BytesPerOp != 100 {
+		t.Errorf("wrong summary %+v", s)
+	}
+
+	if math.Abs(s.NsPerOpDev-math.Sqrt(200)/100) > 1e-9 {
+		t.Errorf("wrong deviation. got=%f", s.NsPerOpDev)
+	}
+}
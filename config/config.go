@@ -0,0 +1,72 @@
+// Package config uses Monkey as a programmable configuration language. A
+// configuration script may compute whatever it likes; the value of its final
+// expression, which must be a hash or an array, is the configuration.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/optimizer"
+	"monkey/parser"
+	"strings"
+)
+
+// Evaluate runs the configuration script source and returns its result as
+// Go data: a map[string]any for a hash or a []any for an array. name is used
+// in error messages.
+func Evaluate(name, source string, opts ...evaluator.Option) (any, error) {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, fmt.Errorf("%s: %s", name, strings.Join(p.Errors(), "; "))
+	}
+	optimizer.Fold(program)
+
+	result := evaluator.New(opts...).Eval(program, object.NewEnvironment())
+	if errObj, ok := result.(*object.Error); ok {
+		return nil, fmt.Errorf("%s: %w", name, errObj)
+	}
+
+	switch result.(type) {
+	case *object.Hash, *object.Array:
+	default:
+		resultType := object.ObjectType(object.NULL_OBJ)
+		if result != nil {
+			resultType = result.Type()
+		}
+		return nil, fmt.Errorf("%s: configuration must end with a HASH or ARRAY, got %s", name, resultType)
+	}
+
+	value, err := object.ToGo(result)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return value, nil
+}
+
+// EvaluateMap is Evaluate for scripts that must produce a hash.
+func EvaluateMap(name, source string, opts ...evaluator.Option) (map[string]any, error) {
+	value, err := Evaluate(name, source, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: configuration must end with a HASH, got ARRAY", name)
+	}
+
+	return m, nil
+}
+
+// EncodeJSON writes value to w as indented JSON.
+func EncodeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const source = `
+let port = 8000 + 80;
+let hosts = ["web-1", "web-2"];
+{
+	"name": "frontend",
+	"port": port,
+	"hosts": hosts,
+	"tls": {"enabled": true, "cert": null_value},
+	"empty": [],
+	"version": "1.0"
+}
+`
+
+func TestEvaluateMap(t *testing.T) {
+	config, err := EvaluateMap("test.mky", strings.Replace(source, "null_value", `{}["x"]`, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]any{
+		"name":    "frontend",
+		"port":    int64(8080),
+		"hosts":   []any{"web-1", "web-2"},
+		"tls":     map[string]any{"enabled": true, "cert": nil},
+		"empty":   []any{},
+		"version": "1.0",
+	}
+
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("wrong config.\nwant=%#v\ngot=%#v", expected, config)
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let = 1;", "test.mky: expected next token to be IDENT, got = instead; no prefix parse function for = found"},
+		{"5", "test.mky: configuration must end with a HASH or ARRAY, got INTEGER"},
+		{"let x = 1;", "test.mky: configuration must end with a HASH or ARRAY, got NULL"},
+		{"missing", "test.mky: NameError: identifier not found: missing"},
+		{`{"f": fn() {}}`, "test.mky: f: cannot convert FUNCTION to a Go value"},
+	}
+
+	for _, tt := range tests {
+		_, err := Evaluate("test.mky", tt.input)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%q: wrong error. want=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestEncodeYAML(t *testing.T) {
+	config, err := Evaluate("test.mky", strings.Replace(source, "null_value", `{}["x"]`, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := EncodeYAML(&out, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `empty: []
+hosts:
+  - web-1
+  - web-2
+name: frontend
+port: 8080
+tls:
+  cert: null
+  enabled: true
+version: "1.0"
+`
+	if out.String() != expected {
+		t.Errorf("wrong YAML.\nwant=%s\ngot=%s", expected, out.String())
+	}
+}
+
+func TestEncodeYAMLBinary(t *testing.T) {
+	config, err := Evaluate("test.mky", `{"key": bytes([0, 1, 2])}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := EncodeYAML(&out, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if out.String() != "key: !!binary AAEC\n" {
+		t.Errorf("wrong YAML. got=%q", out.String())
+	}
+}
+
+func TestEncodeYAMLFloats(t *testing.T) {
+	config, err := Evaluate("test.mky", `{"ratio": 0.25, "whole": 2.0}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := EncodeYAML(&out, config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if out.String() != "ratio: 0.25\nwhole: 2.0\n" {
+		t.Errorf("wrong YAML. got=%q", out.String())
+	}
+}
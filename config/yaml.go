@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EncodeYAML writes value, as returned by Evaluate, to w as block-style
+// YAML with hash keys in sorted order.
+func EncodeYAML(w io.Writer, value any) error {
+	out := bufio.NewWriter(w)
+
+	switch value.(type) {
+	case map[string]any, []any:
+		writeYAMLBlock(out, value, 0)
+	default:
+		out.WriteString(yamlScalar(value) + "\n")
+	}
+
+	return out.Flush()
+}
+
+func writeYAMLBlock(out *bufio.Writer, value any, indent int) {
+	prefix := strings.Repeat("  ", indent)
+
+	switch value := value.(type) {
+	case map[string]any:
+		if len(value) == 0 {
+			out.WriteString(prefix + "{}\n")
+			return
+		}
+
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			out.WriteString(prefix + yamlString(key) + ":")
+			writeYAMLValue(out, value[key], indent)
+		}
+	case []any:
+		if len(value) == 0 {
+			out.WriteString(prefix + "[]\n")
+			return
+		}
+
+		for _, element := range value {
+			out.WriteString(prefix + "-")
+			writeYAMLValue(out, element, indent)
+		}
+	}
+}
+
+// writeYAMLValue writes the value following a "key:" or "-" marker, either
+// on the same line or as a nested block.
+func writeYAMLValue(out *bufio.Writer, value any, indent int) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			out.WriteString(" {}\n")
+			return
+		}
+		out.WriteString("\n")
+		writeYAMLBlock(out, v, indent+1)
+	case []any:
+		if len(v) == 0 {
+			out.WriteString(" []\n")
+			return
+		}
+		out.WriteString("\n")
+		writeYAMLBlock(out, v, indent+1)
+	default:
+		out.WriteString(" " + yamlScalar(value) + "\n")
+	}
+}
+
+func yamlScalar(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return yamlFloat(value)
+	case string:
+		return yamlString(value)
+	case []byte:
+		return "!!binary " + base64.StdEncoding.EncodeToString(value)
+	default:
+		return yamlString(fmt.Sprint(value))
+	}
+}
+
+// yamlString writes s bare when YAML would read it back as the same string,
+// and double-quoted otherwise.
+func yamlString(s string) string {
+	if s == "" || !isPlainYAML(s) {
+		return strconv.Quote(s)
+	}
+
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return strconv.Quote(s)
+	}
+
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+func isPlainYAML(s string) bool {
+	for i, ch := range s {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9', ch == '_':
+		case i > 0 && (ch == '-' || ch == '.' || ch == '/'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func yamlFloat(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return ".nan"
+	case math.IsInf(value, 1):
+		return ".inf"
+	case math.IsInf(value, -1):
+		return "-.inf"
+	}
+
+	s := strconv.FormatFloat(value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
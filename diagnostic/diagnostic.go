@@ -0,0 +1,82 @@
+// Package diagnostic describes non-fatal problems found in Monkey programs.
+// Unlike errors, diagnostics never stop parsing or evaluation; hosts decide
+// whether to collect, print or ignore them.
+package diagnostic
+
+import "fmt"
+
+// Severity ranks how likely a diagnostic is to point at a real mistake.
+type Severity int
+
+const (
+	// Info marks code that is legal and often intended, but worth knowing
+	// about.
+	Info Severity = iota
+	// Warning marks code that is very likely a mistake.
+	Warning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Codes identify the kind of a diagnostic, so hosts can filter them without
+// matching on messages.
+const (
+	Shadowing          = "shadowing"
+	UnusedResult       = "unused-result"
+	TruncatingDivision = "truncating-division"
+	ImpureCallback     = "impure-callback"
+)
+
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s [%s]", d.Severity, d.Message, d.Code)
+}
+
+// List collects diagnostics. Its Add method can be handed to anything that
+// reports diagnostics through a callback.
+type List []Diagnostic
+
+func (l *List) Add(d Diagnostic) {
+	*l = append(*l, d)
+}
+
+// Catalog supplies the wording of messages, so hosts can reword or translate
+// them. A message is identified by its English format string, as passed to
+// fmt.Sprintf; its replacement receives the same arguments and may reorder
+// them with explicit indexes such as %[2]s.
+type Catalog interface {
+	Lookup(format string) (string, bool)
+}
+
+// Messages is a Catalog mapping English formats to their replacements.
+type Messages map[string]string
+
+func (m Messages) Lookup(format string) (string, bool) {
+	replacement, ok := m[format]
+	return replacement, ok
+}
+
+// Sprintf formats a message with c's wording of format, or with format
+// itself if c is nil or has no replacement for it.
+func Sprintf(c Catalog, format string, args ...any) string {
+	if c != nil {
+		if replacement, ok := c.Lookup(format); ok {
+			format = replacement
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,72 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"monkey/object"
+	"time"
+)
+
+// defaultBenchTime is how long bench keeps calling a function unless told
+// otherwise.
+const defaultBenchTime = time.Second
+
+// builtinBench implements bench(name, fn) and bench(name, fn, time). It
+// calls fn with no arguments in rounds of growing size, the way the
+// testing package calibrates, until a round lasts time, given in
+// milliseconds or as a duration. The last round is printed and returned as
+// a hash of name, iterations and ns_per_op.
+func (in *Interpreter) builtinBench(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2 or 3", len(args))
+	}
+
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "name passed to `bench` must be STRING, got %s", args[0].Type())
+	}
+	fn := args[1]
+	if !isCallable(fn) {
+		return newError(object.TypeError, "function passed to `bench` must be FUNCTION, got %s", fn.Type())
+	}
+
+	benchtime := defaultBenchTime
+	if len(args) == 3 {
+		switch arg := args[2].(type) {
+		case *object.Integer:
+			benchtime = time.Duration(arg.Value) * time.Millisecond
+		case *object.Duration:
+			benchtime = arg.Value
+		default:
+			return newError(object.TypeError, "time passed to `bench` must be INTEGER or DURATION, got %s", args[2].Type())
+		}
+	}
+
+	n := 1
+	for {
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if err := ctx.Err(); err != nil {
+				return newCancelledError("bench", err)
+			}
+			if result := in.applyFunction(ctx, fn, nil); isError(result) {
+				return result
+			}
+		}
+		elapsed := time.Since(start)
+
+		if elapsed >= benchtime || n >= 1e9 {
+			nsPerOp := float64(elapsed.Nanoseconds()) / float64(n)
+			fmt.Fprintf(in.stdout, "%s  %d iterations  %.0f ns/op\n", name.Value, n, nsPerOp)
+			return stringHash(
+				"name", name,
+				"iterations", object.NewInteger(int64(n)),
+				"ns_per_op", &object.Float{Value: nsPerOp},
+			)
+		}
+
+		perOp := max(elapsed.Nanoseconds()/int64(n), 1)
+		next := int(benchtime.Nanoseconds() * 6 / 5 / perOp)
+		n = min(max(next, n+1), 100*n, 1e9)
+	}
+}
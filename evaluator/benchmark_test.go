@@ -0,0 +1,98 @@
+package evaluator
+
+import (
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"runtime"
+	"testing"
+)
+
+func benchmarkProgram(b *testing.B, input string) {
+	program := parser.New(lexer.New(input)).ParseProgram()
+	in := New()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		result := in.Eval(program, object.NewEnvironment())
+		if isError(result) {
+			b.Fatalf("benchmark program failed: %s", result.Inspect())
+		}
+	}
+}
+
+func BenchmarkArithmeticLoop(b *testing.B) {
+	benchmarkProgram(b, `
+	let loop = fn(i, acc) {
+		if (i == 0) { acc } else { loop(i - 1, acc + (i * 2 - i) / 1) }
+	};
+	loop(200, 0);
+	`)
+}
+
+func BenchmarkFibonacci(b *testing.B) {
+	benchmarkProgram(b, `
+	let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };
+	fib(15);
+	`)
+}
+
+func BenchmarkStringBuilding(b *testing.B) {
+	benchmarkProgram(b, `
+	let build = fn(i, s) { if (i == 0) { s } else { build(i - 1, s + "a" + "") } };
+	build(100, "");
+	`)
+}
+
+func BenchmarkExplicitReturns(b *testing.B) {
+	benchmarkProgram(b, `
+	let count = fn(n) { if (n == 0) { return 0; } return 1 + count(n - 1); };
+	count(300);
+	`)
+}
+
+func BenchmarkFibonacciReturns(b *testing.B) {
+	benchmarkProgram(b, `
+	let fib = fn(n) { if (n < 2) { return n; } return fib(n - 1) + fib(n - 2); };
+	fib(15);
+	`)
+}
+
+func BenchmarkLargeIntegers(b *testing.B) {
+	benchmarkProgram(b, `
+	let total = 0;
+	let i = 0;
+	while (i < 500) { total += i * 1000; i += 1 };
+	total
+	`)
+}
+
+// BenchmarkRetainedIntegers keeps one result of many in an array, the way a
+// long-running script accumulates values, and reports the heap the kept
+// values hold on to, including the arena slabs they share.
+func BenchmarkRetainedIntegers(b *testing.B) {
+	program := parser.New(lexer.New(`
+	let kept = [];
+	let i = 0;
+	while (i < 2000) { let t = i * 1000 + i * 7 - i; if (i // 10 * 10 == i) { kept = push(kept, t) }; i += 1 };
+	kept
+	`)).ParseProgram()
+
+	b.ReportAllocs()
+	var stats runtime.MemStats
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		before := stats.HeapAlloc
+
+		result := New().Eval(program, object.NewEnvironment())
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		retained += stats.HeapAlloc - before
+		runtime.KeepAlive(result)
+	}
+	b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+}
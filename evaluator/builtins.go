@@ -1,115 +1,423 @@
 package evaluator
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"monkey/object"
+	"time"
 )
 
-var builtins = map[string]*object.Builtin{
-	"len": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.String:
-				return &object.Integer{
-					Value: int64(len(arg.Value)),
+// newBuiltins builds the builtin table for in. Every interpreter gets its own
+// table so builtins can use its configuration, such as where puts writes.
+func newBuiltins(in *Interpreter) map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"len": {
+			Fn: func(ctx context.Context, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
 				}
-			case *object.Array:
-				return &object.Integer{
-					Value: int64(len(arg.Elements)),
+
+				switch arg := args[0].(type) {
+				case *object.String:
+					return object.NewInteger(int64(len(arg.Value)))
+				case *object.Array:
+					return object.NewInteger(int64(len(arg.Elements)))
+				case *object.Tuple:
+					return object.NewInteger(int64(len(arg.Elements)))
+				case *object.Bytes:
+					return object.NewInteger(int64(len(arg.Value)))
+				default:
+					return newError(object.TypeError, "argument to `len` not supported, got %s", arg.Type())
+
 				}
-			default:
-				return newError("argument to `len` not supported, got %s", arg.Type())
-
-			}
-		},
-	},
-	"first": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.Array:
-				if len(arg.Elements) > 0 {
-					return arg.Elements[0]
+			},
+			Pure: true,
+		},
+		"first": {
+			Fn: func(ctx context.Context, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
 				}
-				return NULL
-			default:
-				return newError("argument to `first` must be ARRAY, got %s", arg.Type())
-
-			}
-		},
-	},
-	"last": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.Array:
-				if len(arg.Elements) > 0 {
-					return arg.Elements[len(arg.Elements)-1]
+
+				switch arg := args[0].(type) {
+				case *object.Array:
+					if len(arg.Elements) > 0 {
+						return arg.Elements[0]
+					}
+					return NULL
+				default:
+					return newError(object.TypeError, "argument to `first` must be ARRAY, got %s", arg.Type())
+
 				}
-				return NULL
-			default:
-				return newError("argument to `last` must be ARRAY, got %s", arg.Type())
-
-			}
-		},
-	},
-	"rest": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.Array:
-				length := len(arg.Elements)
-				if length > 0 {
-					arr := make([]object.Object, length-1)
-					copy(arr, arg.Elements[1:length])
+			},
+			Pure: true,
+		},
+		"last": {
+			Fn: func(ctx context.Context, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				switch arg := args[0].(type) {
+				case *object.Array:
+					if len(arg.Elements) > 0 {
+						return arg.Elements[len(arg.Elements)-1]
+					}
+					return NULL
+				default:
+					return newError(object.TypeError, "argument to `last` must be ARRAY, got %s", arg.Type())
+
+				}
+			},
+			Pure: true,
+		},
+		"rest": {
+			Fn: func(ctx context.Context, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				switch arg := args[0].(type) {
+				case *object.Array:
+					length := len(arg.Elements)
+					if length > 0 {
+						arr := make([]object.Object, length-1)
+						copy(arr, arg.Elements[1:length])
+						return &object.Array{Elements: arr}
+					}
+					return NULL
+				default:
+					return newError(object.TypeError, "argument to `rest` must be ARRAY, got %s", arg.Type())
+
+				}
+			},
+			Pure: true,
+		},
+		"push": {
+			Fn: func(ctx context.Context, args ...object.Object) object.Object {
+				if len(args) != 2 {
+					return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+				}
+
+				switch arg := args[0].(type) {
+				case *object.Array:
+					length := len(arg.Elements)
+
+					arr := make([]object.Object, length, length+1)
+					copy(arr, arg.Elements[:])
+					arr = append(arr, args[1])
 					return &object.Array{Elements: arr}
+				default:
+					return newError(object.TypeError, "argument to `push` must be ARRAY, got %s", arg.Type())
+				}
+			},
+			Pure: true,
+		},
+		"type": {
+			Fn:   builtinType,
+			Pure: true,
+		},
+		"keys": {
+			Fn:   builtinKeys,
+			Pure: true,
+		},
+		"values": {
+			Fn:   builtinValues,
+			Pure: true,
+		},
+		"put": {
+			Fn:   builtinPut,
+			Pure: true,
+		},
+		"hash_with_default": {
+			Fn:   builtinHashWithDefault,
+			Pure: true,
+		},
+		"any": {
+			Fn: in.builtinAny,
+		},
+		"all": {
+			Fn: in.builtinAll,
+		},
+		"find": {
+			Fn: in.builtinFind,
+		},
+		"find_index": {
+			Fn: in.builtinFindIndex,
+		},
+		"min": {
+			Fn:   builtinMin,
+			Pure: true,
+		},
+		"max": {
+			Fn:   builtinMax,
+			Pure: true,
+		},
+		"sum": {
+			Fn:   builtinSum,
+			Pure: true,
+		},
+		"product": {
+			Fn:   builtinProduct,
+			Pure: true,
+		},
+		"parse_int": {
+			Fn:   builtinParseInt,
+			Pure: true,
+		},
+		"parse_float": {
+			Fn:   builtinParseFloat,
+			Pure: true,
+		},
+		"to_base": {
+			Fn:   builtinToBase,
+			Pure: true,
+		},
+		"to_bin": radixBuiltin("to_bin", 2),
+		"to_hex": radixBuiltin("to_hex", 16),
+		"bit_count": {
+			Fn:   builtinBitCount,
+			Pure: true,
+		},
+		"num_format": {
+			Fn:   builtinNumFormat,
+			Pure: true,
+		},
+		"chars": {
+			Fn:   builtinChars,
+			Pure: true,
+		},
+		"from_chars": {
+			Fn:   builtinFromChars,
+			Pure: true,
+		},
+		"byte_values": {
+			Fn:   builtinByteValues,
+			Pure: true,
+		},
+		"bytes": {
+			Fn:   builtinBytes,
+			Pure: true,
+		},
+		"string": {
+			Fn:   builtinString,
+			Pure: true,
+		},
+		"pack": {
+			Fn:   builtinPack,
+			Pure: true,
+		},
+		"unpack": {
+			Fn:   builtinUnpack,
+			Pure: true,
+		},
+		"diff": {
+			Fn:   builtinDiff,
+			Pure: true,
+		},
+		"json_encode": {
+			Fn:   builtinJSONEncode,
+			Pure: true,
+		},
+		"gofmt_sprintf": {
+			Fn:   builtinGoSprintf,
+			Pure: true,
+		},
+		"gzip_compress": {
+			Fn:   builtinGzipCompress,
+			Pure: true,
+		},
+		"gzip_decompress": {
+			Fn:   builtinGzipDecompress,
+			Pure: true,
+		},
+		"zip_list": {
+			Fn:   builtinZipList,
+			Pure: true,
+		},
+		"zip_read": {
+			Fn:   builtinZipRead,
+			Pure: true,
+		},
+		"db_open": {
+			Fn: in.builtinDBOpen,
+		},
+		"db_query": {
+			Fn: builtinDBQuery,
+		},
+		"db_exec": {
+			Fn: builtinDBExec,
+		},
+		"db_close": {
+			Fn: in.builtinDBClose,
+		},
+		"call_stack": {
+			Fn: in.builtinCallStack,
+		},
+		"locals": {
+			Fn: in.builtinLocals,
+		},
+		"open_handles": {
+			Fn: in.builtinOpenHandles,
+		},
+		"duration": {
+			Fn:   builtinDuration,
+			Pure: true,
+		},
+		"log_info":  in.logBuiltin("log_info", slog.LevelInfo),
+		"log_warn":  in.logBuiltin("log_warn", slog.LevelWarn),
+		"log_error": in.logBuiltin("log_error", slog.LevelError),
+		"on_signal": {
+			Fn: in.onSignal,
+		},
+		"stub_builtin": {
+			Fn: in.builtinStubBuiltin,
+		},
+		"memoize": {
+			Fn: in.memoize,
+		},
+		"arity": {
+			Fn:   builtinArity,
+			Pure: true,
+		},
+		"params": {
+			Fn:   builtinParams,
+			Pure: true,
+		},
+		"name": {
+			Fn:   in.builtinName,
+			Pure: true,
+		},
+		"partial": {
+			Fn: in.partial,
+		},
+		"curry": {
+			Fn: in.curry,
+		},
+		"compose": {
+			Fn: in.compose,
+		},
+		"now": {
+			Fn: in.builtinNow,
+		},
+		"every": in.scheduleBuiltin("every", true),
+		"after": in.scheduleBuiltin("after", false),
+		"cancel": {
+			Fn: in.builtinCancel,
+		},
+		"bench": {
+			Fn: in.builtinBench,
+		},
+		"forall": {
+			Fn: in.builtinForall,
+		},
+		"gen_int": {
+			Fn:   builtinGenInt,
+			Pure: true,
+		},
+		"gen_bool": {
+			Fn:   builtinGenBool,
+			Pure: true,
+		},
+		"gen_string": {
+			Fn:   builtinGenString,
+			Pure: true,
+		},
+		"gen_array": {
+			Fn:   builtinGenArray,
+			Pure: true,
+		},
+		"gen_one_of": {
+			Fn:   builtinGenOneOf,
+			Pure: true,
+		},
+		"events": {
+			Fn: in.builtinEvents,
+		},
+		"retry": {
+			Fn: in.builtinRetry,
+		},
+		"sleep": {
+			Fn: func(ctx context.Context, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+				}
+
+				var d time.Duration
+				switch arg := args[0].(type) {
+				case *object.Integer:
+					d = time.Duration(arg.Value) * time.Millisecond
+				case *object.Duration:
+					d = arg.Value
+				default:
+					return newError(object.TypeError, "argument to `sleep` must be INTEGER or DURATION, got %s", args[0].Type())
+				}
+
+				timer := time.NewTimer(d)
+				defer timer.Stop()
+
+				select {
+				case <-timer.C:
+					return NULL
+				case <-ctx.Done():
+					return newCancelledError("sleep interrupted", ctx.Err())
+				}
+			},
+		},
+		"time": {
+			Fn:   builtinTime,
+			Pure: true,
+		},
+		"with_timeout": {
+			Fn: in.builtinWithTimeout,
+		},
+		"ws_connect": {
+			Fn: in.builtinWSConnect,
+		},
+		"ws_send": {
+			Fn: builtinWSSend,
+		},
+		"ws_recv": {
+			Fn: builtinWSRecv,
+		},
+		"ws_close": {
+			Fn: in.builtinWSClose,
+		},
+		"style": {
+			Fn:   in.builtinStyle,
+			Pure: true,
+		},
+		"is_tty": {
+			Fn: in.builtinIsTTY,
+		},
+		"prompt": {
+			Fn: in.builtinPrompt,
+		},
+		"confirm": {
+			Fn: in.builtinConfirm,
+		},
+		"print_table": {
+			Fn: in.builtinPrintTable,
+		},
+		"help": {
+			Fn: in.builtinHelp,
+		},
+		"version": {
+			Fn:   builtinVersion,
+			Pure: true,
+		},
+		"features": {
+			Fn: in.builtinFeatures,
+		},
+		"puts": {
+			Fn: func(ctx context.Context, args ...object.Object) object.Object {
+				for _, arg := range args {
+					fmt.Fprintln(in.stdout, object.Format(arg, in.format))
 				}
+
 				return NULL
-			default:
-				return newError("argument to `rest` must be ARRAY, got %s", arg.Type())
-
-			}
-		},
-	},
-	"push": {
-		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=2", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.Array:
-				length := len(arg.Elements)
-
-				arr := make([]object.Object, length, length+1)
-				copy(arr, arg.Elements[:])
-				arr = append(arr, args[1])
-				return &object.Array{Elements: arr}
-			default:
-				return newError("argument to `push` must be ARRAY, got %s", arg.Type())
-			}
-		},
-	},
-	"puts": {
-		Fn: func(args ...object.Object) object.Object {
-			for _, arg := range args {
-				fmt.Println(arg.Inspect())
-			}
-
-			return NULL
-		},
-	},
+			},
+		},
+	}
 }
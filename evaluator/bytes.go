@@ -0,0 +1,186 @@
+package evaluator
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"monkey/object"
+)
+
+// maxDecompressedSize bounds what gzip_decompress and zip_read produce, so
+// a small malicious archive cannot exhaust memory.
+const maxDecompressedSize = 256 << 20
+
+var errTooLarge = errors.New("decompressed data exceeds 256 MiB")
+
+// builtinBytes implements bytes(string) and bytes(array of integers 0-255).
+func builtinBytes(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Bytes:
+		return arg
+	case *object.String:
+		return &object.Bytes{Value: []byte(arg.Value)}
+	case *object.Array:
+		value := make([]byte, len(arg.Elements))
+		for i, element := range arg.Elements {
+			n, ok := element.(*object.Integer)
+			if !ok || n.Value < 0 || n.Value > 255 {
+				return newError(object.ArgumentError, "element %d passed to `bytes` is not an integer from 0 to 255: %s", i, element.Inspect())
+			}
+			value[i] = byte(n.Value)
+		}
+		return &object.Bytes{Value: value}
+	default:
+		return newError(object.TypeError, "argument to `bytes` must be STRING or ARRAY, got %s", arg.Type())
+	}
+}
+
+// builtinString implements string(bytes), decoding bytes as text.
+func builtinString(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.String:
+		return arg
+	case *object.Bytes:
+		return object.NewString(string(arg.Value))
+	default:
+		return newError(object.TypeError, "argument to `string` must be BYTES, got %s", arg.Type())
+	}
+}
+
+// builtinGzipCompress implements gzip_compress(data) for bytes or strings.
+func builtinGzipCompress(ctx context.Context, args ...object.Object) object.Object {
+	data, errObj := binaryArgument("gzip_compress", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+
+	var out bytes.Buffer
+	w := gzip.NewWriter(&out)
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		return newError(object.RuntimeError, "gzip_compress: %s", err)
+	}
+
+	return &object.Bytes{Value: out.Bytes()}
+}
+
+// builtinGzipDecompress implements gzip_decompress(bytes).
+func builtinGzipDecompress(ctx context.Context, args ...object.Object) object.Object {
+	data, errObj := binaryArgument("gzip_decompress", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return newError(object.ArgumentError, "gzip_decompress: %s", err)
+	}
+
+	value, err := readLimited(r)
+	if err != nil {
+		return newError(object.ArgumentError, "gzip_decompress: %s", err)
+	}
+
+	return &object.Bytes{Value: value}
+}
+
+// builtinZipList implements zip_list(archive), returning the names of the
+// files in a zip archive held in memory.
+func builtinZipList(ctx context.Context, args ...object.Object) object.Object {
+	data, errObj := binaryArgument("zip_list", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return newError(object.ArgumentError, "zip_list: %s", err)
+	}
+
+	names := make([]object.Object, 0, len(archive.File))
+	for _, file := range archive.File {
+		names = append(names, object.NewString(file.Name))
+	}
+
+	return &object.Array{Elements: names}
+}
+
+// builtinZipRead implements zip_read(archive, name), returning the contents
+// of the named file, or null if the archive has no such file.
+func builtinZipRead(ctx context.Context, args ...object.Object) object.Object {
+	data, errObj := binaryArgument("zip_read", args, 2)
+	if errObj != nil {
+		return errObj
+	}
+
+	name, ok := args[1].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "name passed to `zip_read` must be STRING, got %s", args[1].Type())
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return newError(object.ArgumentError, "zip_read: %s", err)
+	}
+
+	for _, file := range archive.File {
+		if file.Name != name.Value {
+			continue
+		}
+
+		r, err := file.Open()
+		if err != nil {
+			return newError(object.ArgumentError, "zip_read: %s", err)
+		}
+		defer r.Close()
+
+		value, err := readLimited(r)
+		if err != nil {
+			return newError(object.ArgumentError, "zip_read: %s", err)
+		}
+
+		return &object.Bytes{Value: value}
+	}
+
+	return NULL
+}
+
+// binaryArgument checks that args has want elements and returns the
+// contents of the first, which may be bytes or a string.
+func binaryArgument(builtin string, args []object.Object, want int) ([]byte, *object.Error) {
+	if len(args) != want {
+		return nil, newError(object.ArgumentError, "wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Bytes:
+		return arg.Value, nil
+	case *object.String:
+		return []byte(arg.Value), nil
+	default:
+		return nil, newError(object.TypeError, "argument to `%s` must be BYTES or STRING, got %s", builtin, arg.Type())
+	}
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	value, err := io.ReadAll(io.LimitReader(r, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(value) > maxDecompressedSize {
+		return nil, errTooLarge
+	}
+
+	return value, nil
+}
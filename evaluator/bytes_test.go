@@ -0,0 +1,81 @@
+package evaluator
+
+import (
+	"archive/zip"
+	"bytes"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`bytes("hi")`, `b"hi"`},
+		{`bytes([0, 104, 255])`, `b"\x00h\xff"`},
+		{`len(bytes("héllo"))`, "6"},
+		{`bytes("abc")[1]`, "98"},
+		{`bytes("abc")[3]`, "null"},
+		{`bytes("ab") + bytes("cd")`, `b"abcd"`},
+		{`bytes("ab") == bytes("ab")`, "true"},
+		{`bytes("ab") != bytes("ba")`, "true"},
+		{`{bytes("k"): 1}[bytes("k")]`, "1"},
+		{`string(bytes("round trip"))`, "round trip"},
+		{`bytes([256])`, "ERROR: element 0 passed to `bytes` is not an integer from 0 to 255: 256"},
+		{`bytes(1)`, "ERROR: argument to `bytes` must be STRING or ARRAY, got INTEGER"},
+		{`bytes("a") + "b"`, "ERROR: type mismatch: BYTES + STRING"},
+		{`string(1)`, "ERROR: argument to `string` must be BYTES, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestCompressionBuiltins(t *testing.T) {
+	var archive bytes.Buffer
+	w := zip.NewWriter(&archive)
+	for _, file := range []struct{ name, body string }{
+		{"README", "hello from zip"},
+		{"data/config.mky", `{"port": 80}`},
+	} {
+		f, err := w.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(file.body))
+	}
+	w.Close()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`string(gzip_decompress(gzip_compress("payload")))`, "payload"},
+		{`let data = bytes([1, 2, 3]); gzip_decompress(gzip_compress(data)) == data`, "true"},
+		{`gzip_compress("x")[0]`, "31"},
+		{`gzip_decompress(bytes("this is not gzip data"))`, "ERROR: gzip_decompress: gzip: invalid header"},
+		{`zip_list(ARCHIVE)`, "[README, data/config.mky]"},
+		{`string(zip_read(ARCHIVE, "README"))`, "hello from zip"},
+		{`zip_read(ARCHIVE, "missing")`, "null"},
+		{`zip_list(bytes("nope"))`, "ERROR: zip_list: zip: not a valid zip file"},
+		{`zip_read(ARCHIVE, 1)`, "ERROR: name passed to `zip_read` must be STRING, got INTEGER"},
+		{`gzip_compress(1)`, "ERROR: argument to `gzip_compress` must be BYTES or STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Set("ARCHIVE", &object.Bytes{Value: archive.Bytes()})
+
+		evaluated := New().Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
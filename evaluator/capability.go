@@ -0,0 +1,88 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"net/url"
+	"time"
+)
+
+// Capability names a group of builtins that reach outside the interpreter.
+// Builtins in a group fail with a PermissionError unless the host grants
+// the group with WithCapabilities, so untrusted scripts are sandboxed by
+// default.
+type Capability string
+
+const (
+	// CapabilityDatabase allows opening database connections with db_open.
+	CapabilityDatabase Capability = "database"
+
+	// CapabilityNetwork allows opening network connections, such as with
+	// ws_connect.
+	CapabilityNetwork Capability = "network"
+)
+
+// WithCapabilities grants caps to scripts run by the interpreter.
+func WithCapabilities(caps ...Capability) Option {
+	return func(in *Interpreter) {
+		for _, c := range caps {
+			in.capabilities[c] = true
+		}
+	}
+}
+
+// require returns a PermissionError for builtin unless c has been granted.
+func (in *Interpreter) require(c Capability, builtin string) *object.Error {
+	if in.capabilities[c] {
+		return nil
+	}
+
+	return newError(object.PermissionError, "`%s` requires the %s capability", builtin, c)
+}
+
+// AuditLog records every use scripts make of granted capabilities, for hosts
+// that must account for what user-supplied scripts touched.
+type AuditLog struct {
+	Entries []AuditEntry `json:"entries"`
+}
+
+// AuditEntry is one use of a capability. Target is what the builtin
+// reached, such as a URL or data source, and is recorded before the
+// attempt, so failed attempts appear too.
+type AuditEntry struct {
+	Time       time.Time  `json:"time"`
+	Capability Capability `json:"capability"`
+	Builtin    string     `json:"builtin"`
+	Target     string     `json:"target"`
+}
+
+// WithAuditLog appends the capability uses of scripts run by the
+// interpreter to log.
+func WithAuditLog(log *AuditLog) Option {
+	return func(in *Interpreter) {
+		in.auditLog = log
+	}
+}
+
+// audit records that builtin is about to use c on target.
+func (in *Interpreter) audit(c Capability, builtin, target string) {
+	if in.auditLog == nil {
+		return
+	}
+
+	in.auditLog.Entries = append(in.auditLog.Entries, AuditEntry{
+		Time:       time.Now(),
+		Capability: c,
+		Builtin:    builtin,
+		Target:     target,
+	})
+}
+
+// redactURL hides the password in a URL, if it has one, so it does not end
+// up in an audit log.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Redacted()
+}
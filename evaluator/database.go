@@ -0,0 +1,238 @@
+package evaluator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"monkey/object"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DATABASE_OBJ is the type of the handles returned by db_open.
+const DATABASE_OBJ = "DATABASE"
+
+// Database is an open database/sql connection pool.
+type Database struct {
+	DB     *sql.DB
+	driver string
+}
+
+func (d *Database) Type() object.ObjectType { return DATABASE_OBJ }
+
+func (d *Database) Inspect() string {
+	return fmt.Sprintf("<database %s>", d.driver)
+}
+
+func (d *Database) Close() error { return d.DB.Close() }
+
+// builtinDBOpen implements db_open(dsn), where dsn is "driver:source", for
+// example "sqlite:data.db". The driver must have been registered with
+// database/sql by the host program.
+func (in *Interpreter) builtinDBOpen(ctx context.Context, args ...object.Object) object.Object {
+	if err := in.require(CapabilityDatabase, "db_open"); err != nil {
+		return err
+	}
+
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	dsn, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "argument to `db_open` must be STRING, got %s", args[0].Type())
+	}
+
+	driver, source, ok := strings.Cut(dsn.Value, ":")
+	if !ok {
+		return newError(object.ArgumentError, "data source %q is not of the form driver:source", dsn.Value)
+	}
+
+	in.audit(CapabilityDatabase, "db_open", driver+":"+redactDSN(source))
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return newError(object.RuntimeError, "db_open: %s", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return newError(object.RuntimeError, "db_open: %s", err)
+	}
+
+	return in.open(&Database{DB: db, driver: driver})
+}
+
+var (
+	// dsnPassword matches a password=... setting in a keyword/value DSN
+	// ("host=db password=secret"), an ODBC-style one ("Pwd=secret;") or a
+	// URL's query string.
+	dsnPassword = regexp.MustCompile(`(?i)\b(password|pwd)(\s*=\s*)('(?:[^'\\]|\\.)*'|[^\s;&]*)`)
+	// dsnUserinfo matches the user:password@ prefix of a MySQL-style DSN
+	// ("user:secret@tcp(db)/app"), up to the last @.
+	dsnUserinfo = regexp.MustCompile(`^([^:/@]*):(.*)@`)
+)
+
+// redactDSN hides the password in a driver's data source, whether it is a
+// URL, a MySQL-style DSN or a list of keyword settings, so it does not end
+// up in an audit log.
+func redactDSN(source string) string {
+	if strings.Contains(source, "://") {
+		source = redactURL(source)
+	} else {
+		source = dsnUserinfo.ReplaceAllString(source, "${1}:xxxxx@")
+	}
+	return dsnPassword.ReplaceAllString(source, "${1}${2}xxxxx")
+}
+
+// builtinDBQuery implements db_query(db, sql) and db_query(db, sql, params),
+// returning one hash per row keyed by column name.
+func builtinDBQuery(ctx context.Context, args ...object.Object) object.Object {
+	db, query, params, errObj := databaseArguments("db_query", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	rows, err := db.DB.QueryContext(ctx, query, params...)
+	if err != nil {
+		return newError(object.RuntimeError, "db_query: %s", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return newError(object.RuntimeError, "db_query: %s", err)
+	}
+
+	result := &object.Array{Elements: []object.Object{}}
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return newError(object.RuntimeError, "db_query: %s", err)
+		}
+
+		row := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair, len(columns))}
+		for i, column := range columns {
+			key := object.NewString(column)
+			row.Set(key.HashKey(), object.HashPair{Key: key, Value: databaseValue(values[i])})
+		}
+		result.Elements = append(result.Elements, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return newError(object.RuntimeError, "db_query: %s", err)
+	}
+
+	return result
+}
+
+// builtinDBExec implements db_exec(db, sql) and db_exec(db, sql, params),
+// returning a hash with rows_affected and, where the driver reports it,
+// last_insert_id.
+func builtinDBExec(ctx context.Context, args ...object.Object) object.Object {
+	db, query, params, errObj := databaseArguments("db_exec", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	res, err := db.DB.ExecContext(ctx, query, params...)
+	if err != nil {
+		return newError(object.RuntimeError, "db_exec: %s", err)
+	}
+
+	result := &object.Hash{}
+	set := func(name string, value int64) {
+		key := object.NewString(name)
+		result.Set(key.HashKey(), object.HashPair{Key: key, Value: object.NewInteger(value)})
+	}
+
+	if affected, err := res.RowsAffected(); err == nil {
+		set("rows_affected", affected)
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		set("last_insert_id", id)
+	}
+
+	return result
+}
+
+// builtinDBClose implements db_close(db).
+func (in *Interpreter) builtinDBClose(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	db, ok := args[0].(*Database)
+	if !ok {
+		return newError(object.TypeError, "argument to `db_close` must be DATABASE, got %s", args[0].Type())
+	}
+
+	return in.closeHandle("db_close", db)
+}
+
+func databaseArguments(builtin string, args []object.Object) (*Database, string, []any, *object.Error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, "", nil, newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2 or 3", len(args))
+	}
+
+	db, ok := args[0].(*Database)
+	if !ok {
+		return nil, "", nil, newError(object.TypeError, "first argument to `%s` must be DATABASE, got %s", builtin, args[0].Type())
+	}
+
+	query, ok := args[1].(*object.String)
+	if !ok {
+		return nil, "", nil, newError(object.TypeError, "query passed to `%s` must be STRING, got %s", builtin, args[1].Type())
+	}
+
+	var params []any
+	if len(args) == 3 {
+		array, ok := args[2].(*object.Array)
+		if !ok {
+			return nil, "", nil, newError(object.TypeError, "params passed to `%s` must be ARRAY, got %s", builtin, args[2].Type())
+		}
+
+		for i, element := range array.Elements {
+			var param any
+			switch element := element.(type) {
+			case *object.Time:
+				param = element.Value
+			default:
+				converted, err := object.ToGo(element)
+				if err != nil {
+					return nil, "", nil, newError(object.TypeError, "param %d passed to `%s`: %s", i, builtin, err)
+				}
+				param = converted
+			}
+			params = append(params, param)
+		}
+	}
+
+	return db, query.Value, params, nil
+}
+
+// databaseValue converts a scanned column value.
+func databaseValue(value any) object.Object {
+	switch value := value.(type) {
+	case nil:
+		return NULL
+	case int64:
+		return object.NewInteger(value)
+	case float64:
+		return &object.Float{Value: value}
+	case bool:
+		return nativeBoolToBooleanObject(value)
+	case []byte:
+		return object.NewString(string(value))
+	case string:
+		return object.NewString(value)
+	case time.Time:
+		return &object.Time{Value: value}
+	default:
+		return object.NewString(fmt.Sprint(value))
+	}
+}
@@ -0,0 +1,34 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/diagnostic"
+	"monkey/object"
+	"monkey/token"
+)
+
+func (in *Interpreter) checkInfix(node *ast.InfixExpression, left, right object.Object) {
+	if node.Operator != token.FLOOR_SLASH {
+		return
+	}
+
+	l, ok := left.(*object.Integer)
+	r, ok2 := right.(*object.Integer)
+	if !ok || !ok2 || r.Value == 0 || l.Value%r.Value == 0 {
+		return
+	}
+
+	exact := &object.Float{Value: float64(l.Value) / float64(r.Value)}
+	in.report(node, diagnostic.Info, diagnostic.TruncatingDivision,
+		diagnostic.Sprintf(in.catalog, "integer division %d // %d rounds down to %d; %d / %d is %s",
+			l.Value, r.Value, floorDivide(l.Value, r.Value), l.Value, r.Value, exact.Inspect()))
+}
+
+func (in *Interpreter) report(node ast.Node, severity diagnostic.Severity, code, message string) {
+	if in.diagnosed[node] {
+		return
+	}
+	in.diagnosed[node] = true
+
+	in.diagnose(diagnostic.Diagnostic{Severity: severity, Code: code, Message: message})
+}
@@ -0,0 +1,160 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+	"strings"
+)
+
+// maxDiffCells bounds the table a line diff fills in, the product of the
+// two line counts.
+const maxDiffCells = 1 << 22
+
+// builtinDiff implements diff(a, b), returning the differences between two
+// values as an array of hashes, empty when they are equal. Two strings are
+// compared line by line, giving {"op", "line", "text"} for each line
+// "removed" from a or "added" in b, numbered within its own string. Other
+// values are compared structurally, giving {"op", "path", "old", "new"} for
+// each element or pair "removed", "added" or "changed", where path is the
+// array of indexes and keys leading to it.
+func builtinDiff(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	if a, ok := args[0].(*object.String); ok {
+		if b, ok := args[1].(*object.String); ok {
+			return diffLines(a.Value, b.Value)
+		}
+	}
+
+	changes := &object.Array{}
+	diffValues(changes, nil, args[0], args[1])
+	return changes
+}
+
+func diffValues(changes *object.Array, path []object.Object, a, b object.Object) {
+	switch a := a.(type) {
+	case *object.Array:
+		if b, ok := b.(*object.Array); ok {
+			diffElements(changes, path, a.Elements, b.Elements)
+			return
+		}
+	case *object.Tuple:
+		if b, ok := b.(*object.Tuple); ok {
+			diffElements(changes, path, a.Elements, b.Elements)
+			return
+		}
+	case *object.Hash:
+		if b, ok := b.(*object.Hash); ok {
+			diffPairs(changes, path, a, b)
+			return
+		}
+	}
+
+	if a.Type() != b.Type() || !valuesEqual(a, b) {
+		changes.Elements = append(changes.Elements, diffChange("changed", path, "old", a, "new", b))
+	}
+}
+
+func diffElements(changes *object.Array, path []object.Object, a, b []object.Object) {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		at := appendPath(path, object.NewInteger(int64(i)))
+		switch {
+		case i >= len(b):
+			changes.Elements = append(changes.Elements, diffChange("removed", at, "old", a[i]))
+		case i >= len(a):
+			changes.Elements = append(changes.Elements, diffChange("added", at, "new", b[i]))
+		default:
+			diffValues(changes, at, a[i], b[i])
+		}
+	}
+}
+
+func diffPairs(changes *object.Array, path []object.Object, a, b *object.Hash) {
+	for _, pair := range a.Ordered() {
+		at := appendPath(path, pair.Key)
+		if other, ok := b.Pairs[pair.Key.(object.Hashable).HashKey()]; ok {
+			diffValues(changes, at, pair.Value, other.Value)
+		} else {
+			changes.Elements = append(changes.Elements, diffChange("removed", at, "old", pair.Value))
+		}
+	}
+	for _, pair := range b.Ordered() {
+		if _, ok := a.Pairs[pair.Key.(object.Hashable).HashKey()]; !ok {
+			changes.Elements = append(changes.Elements, diffChange("added", appendPath(path, pair.Key), "new", pair.Value))
+		}
+	}
+}
+
+// appendPath returns path extended by key without sharing its backing
+// array with sibling paths.
+func appendPath(path []object.Object, key object.Object) []object.Object {
+	return append(path[:len(path):len(path)], key)
+}
+
+// diffChange builds the hash describing one structural change from op,
+// path and alternating further names and values.
+func diffChange(op string, path []object.Object, fields ...any) *object.Hash {
+	return stringHash(append([]any{"op", object.NewString(op), "path", &object.Array{Elements: path}}, fields...)...)
+}
+
+// stringHash builds a hash with string keys from alternating names and
+// values.
+func stringHash(fields ...any) *object.Hash {
+	record := &object.Hash{}
+	for i := 0; i < len(fields); i += 2 {
+		key := object.NewString(fields[i].(string))
+		record.Set(key.HashKey(), object.HashPair{Key: key, Value: fields[i+1].(object.Object)})
+	}
+	return record
+}
+
+// diffLines compares a and b line by line through their longest common
+// subsequence of lines.
+func diffLines(a, b string) object.Object {
+	left, right := strings.Split(a, "\n"), strings.Split(b, "\n")
+	if len(left)*len(right) > maxDiffCells {
+		return newError(object.ArgumentError, "diff: strings of %d and %d lines are too long to compare", len(left), len(right))
+	}
+
+	// common[i][j] is the length of the longest common subsequence of
+	// left[i:] and right[j:].
+	common := make([][]int, len(left)+1)
+	for i := range common {
+		common[i] = make([]int, len(right)+1)
+	}
+	for i := len(left) - 1; i >= 0; i-- {
+		for j := len(right) - 1; j >= 0; j-- {
+			if left[i] == right[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	changes := &object.Array{}
+	line := func(op string, n int, text string) {
+		changes.Elements = append(changes.Elements, stringHash(
+			"op", object.NewString(op),
+			"line", object.NewInteger(int64(n+1)),
+			"text", object.NewString(text),
+		))
+	}
+
+	i, j := 0, 0
+	for i < len(left) || j < len(right) {
+		switch {
+		case i < len(left) && j < len(right) && left[i] == right[j]:
+			i, j = i+1, j+1
+		case j == len(right) || (i < len(left) && common[i+1][j] >= common[i][j+1]):
+			line("removed", i, left[i])
+			i++
+		default:
+			line("added", j, right[j])
+			j++
+		}
+	}
+	return changes
+}
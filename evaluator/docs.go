@@ -0,0 +1,533 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"monkey/object"
+)
+
+// Doc describes a builtin or prelude function for help, the REPL's :doc
+// command and generated documentation.
+type Doc struct {
+	// Signature shows how the function is called, with optional
+	// arguments as alternative forms separated by " or ".
+	Signature string
+	// Description says what the function does, in a sentence or two.
+	Description string
+	// Examples are calls followed by " => " and the Inspect text of
+	// their result.
+	Examples []string
+}
+
+// String lays a doc out the way help prints it.
+func (d Doc) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n    %s\n", d.Signature, d.Description)
+	if len(d.Examples) > 0 {
+		b.WriteString("examples:\n")
+		for _, ex := range d.Examples {
+			fmt.Fprintf(&b, "    %s\n", ex)
+		}
+	}
+	return b.String()
+}
+
+// builtinDocs documents every builtin and every function of the standard
+// prelude by name.
+var builtinDocs = map[string]Doc{
+	"len": {
+		Signature:   "len(value)",
+		Description: "The number of bytes in a string's UTF-8 encoding or in a byte string, or of elements in an array or in a tuple returned by a function.",
+		Examples: []string{
+			`len("abc") => 3`,
+			`len("é") => 2`,
+			`len(bytes("ab")) => 2`,
+			`len([1, 2]) => 2`,
+			`len(fn() { return 1, 2 }()) => 2`,
+		},
+	},
+	"first": {
+		Signature:   "first(array)",
+		Description: "The first element of an array, or null if it is empty.",
+		Examples:    []string{`first([1, 2, 3]) => 1`},
+	},
+	"last": {
+		Signature:   "last(array)",
+		Description: "The last element of an array, or null if it is empty.",
+		Examples:    []string{`last([1, 2, 3]) => 3`},
+	},
+	"rest": {
+		Signature:   "rest(array)",
+		Description: "A new array of every element but the first, or null if the array is empty.",
+		Examples:    []string{`rest([1, 2, 3]) => [2, 3]`},
+	},
+	"push": {
+		Signature:   "push(array, value)",
+		Description: "A new array with value added to the end.",
+		Examples:    []string{`push([1, 2], 3) => [1, 2, 3]`},
+	},
+	"type": {
+		Signature:   "type(value)",
+		Description: "The name of a value's type, the same name the is operator tests for.",
+		Examples:    []string{`type(1) => INTEGER`},
+	},
+	"keys": {
+		Signature:   "keys(hash)",
+		Description: "The keys of a hash in insertion order.",
+		Examples:    []string{`keys({"a": 1, "b": 2}) => [a, b]`},
+	},
+	"values": {
+		Signature:   "values(hash)",
+		Description: "The values of a hash in the order of their keys.",
+		Examples:    []string{`values({"a": 1, "b": 2}) => [1, 2]`},
+	},
+	"put": {
+		Signature:   "put(hash, key, value)",
+		Description: "A copy of a hash with key set to value.",
+		Examples:    []string{`put({"a": 1}, "b", 2)["b"] => 2`},
+	},
+	"hash_with_default": {
+		Signature:   "hash_with_default(default) or hash_with_default(default, hash)",
+		Description: "A copy of hash, or a new empty hash, whose missing keys index to default.",
+		Examples:    []string{`hash_with_default(0)["missing"] => 0`},
+	},
+	"any": {
+		Signature:   "any(array, predicate)",
+		Description: "Whether predicate is truthy for some element.",
+		Examples:    []string{`any([1, 2, 3], fn(x) { x > 2 }) => true`},
+	},
+	"all": {
+		Signature:   "all(array, predicate)",
+		Description: "Whether predicate is truthy for every element.",
+		Examples:    []string{`all([1, 2, 3], fn(x) { x > 2 }) => false`},
+	},
+	"find": {
+		Signature:   "find(array, predicate)",
+		Description: "The first element for which predicate is truthy, or null.",
+		Examples:    []string{`find([1, 2, 3], fn(x) { x > 1 }) => 2`},
+	},
+	"find_index": {
+		Signature:   "find_index(array, predicate)",
+		Description: "The index of the first element for which predicate is truthy, or -1.",
+		Examples:    []string{`find_index([1, 2, 3], fn(x) { x > 1 }) => 1`},
+	},
+	"min": {
+		Signature:   "min(array) or min(array, default)",
+		Description: "The smallest of an array of numbers or strings; an empty array gives default, or an error without one.",
+		Examples:    []string{`min([3, 1, 2]) => 1`, `min([], 0) => 0`},
+	},
+	"max": {
+		Signature:   "max(array) or max(array, default)",
+		Description: "The largest of an array of numbers or strings; an empty array gives default, or an error without one.",
+		Examples:    []string{`max([3, 1, 2]) => 3`},
+	},
+	"sum": {
+		Signature:   "sum(array) or sum(array, start)",
+		Description: "The sum of an array of numbers, added to start, which defaults to 0.",
+		Examples:    []string{`sum([1, 2, 3]) => 6`},
+	},
+	"product": {
+		Signature:   "product(array) or product(array, start)",
+		Description: "The product of an array of numbers, multiplied by start, which defaults to 1.",
+		Examples:    []string{`product([2, 3, 4]) => 24`},
+	},
+	"parse_int": {
+		Signature:   "parse_int(string) or parse_int(string, base)",
+		Description: "The integer written in string, in a base from 2 to 36 that defaults to 10.",
+		Examples:    []string{`parse_int("42") => 42`, `parse_int("ff", 16) => 255`},
+	},
+	"parse_float": {
+		Signature:   "parse_float(string)",
+		Description: "The number written in string as a float.",
+		Examples:    []string{`parse_float("2.5") => 2.5`},
+	},
+	"to_base": {
+		Signature:   "to_base(integer, base)",
+		Description: "An integer written in a base from 2 to 36 with lower-case digits.",
+		Examples:    []string{`to_base(255, 16) => ff`},
+	},
+	"to_bin": {
+		Signature:   "to_bin(integer) or to_bin(integer, width)",
+		Description: "An integer written in binary, zero-padded to width digits.",
+		Examples:    []string{`to_bin(5) => 101`, `to_bin(5, 8) => 00000101`},
+	},
+	"to_hex": {
+		Signature:   "to_hex(integer) or to_hex(integer, width)",
+		Description: "An integer written in hexadecimal, zero-padded to width digits.",
+		Examples:    []string{`to_hex(255) => ff`, `to_hex(255, 4) => 00ff`},
+	},
+	"bit_count": {
+		Signature:   "bit_count(integer)",
+		Description: "The number of set bits in a non-negative integer.",
+		Examples:    []string{`bit_count(7) => 3`},
+	},
+	"num_format": {
+		Signature:   "num_format(number) or num_format(number, options)",
+		Description: "A number written with the options decimals, thousands and point.",
+		Examples:    []string{`num_format(1234567, {"thousands": ","}) => 1,234,567`},
+	},
+	"chars": {
+		Signature:   "chars(string)",
+		Description: "A string split into an array of one-character strings.",
+		Examples:    []string{`len(chars("héllo")) => 5`},
+	},
+	"from_chars": {
+		Signature:   "from_chars(array)",
+		Description: "An array of strings joined into one, the inverse of chars.",
+		Examples:    []string{`from_chars(["a", "b"]) => ab`},
+	},
+	"byte_values": {
+		Signature:   "byte_values(data)",
+		Description: "The bytes of a string or byte string as an array of integers.",
+		Examples:    []string{`byte_values("AB") => [65, 66]`},
+	},
+	"bytes": {
+		Signature:   "bytes(string) or bytes(array)",
+		Description: "A byte string holding a string's UTF-8 encoding or an array of integers from 0 to 255.",
+		Examples:    []string{`len(bytes("héllo")) => 6`},
+	},
+	"string": {
+		Signature:   "string(bytes)",
+		Description: "A byte string decoded as text.",
+		Examples:    []string{`string(bytes([104, 105])) => hi`},
+	},
+	"pack": {
+		Signature:   "pack(format, values...)",
+		Description: "Values encoded as bytes by a format in the style of Python's struct module. A format may describe at most 16 MiB.",
+		Examples:    []string{`byte_values(pack("<H", 1)) => [1, 0]`},
+	},
+	"unpack": {
+		Signature:   "unpack(format, data) or unpack(format, data, offset)",
+		Description: "The values read from data by a pack format, starting at offset.",
+		Examples:    []string{`unpack("<H", bytes([1, 0])) => [1]`},
+	},
+	"diff": {
+		Signature:   "diff(a, b)",
+		Description: "The differences between two values as an array of hashes; strings are compared line by line.",
+		Examples:    []string{`diff([1], [1]) => []`},
+	},
+	"json_encode": {
+		Signature:   "json_encode(value) or json_encode(value, options)",
+		Description: "A value encoded as JSON, indented by the indent option and with hash keys sorted by sort_keys.",
+		Examples:    []string{`json_encode([1, "a"]) => [1,"a"]`},
+	},
+	"gofmt_sprintf": {
+		Signature:   "gofmt_sprintf(format, args...)",
+		Description: "Args formatted by Go's fmt.Sprintf; a verb that does not match its argument is an error.",
+		Examples:    []string{`gofmt_sprintf("%05.1f|%-3d|%q", 3.14159, 7, "hi") => 003.1|7  |"hi"`},
+	},
+	"gzip_compress": {
+		Signature:   "gzip_compress(data)",
+		Description: "A string or byte string compressed with gzip.",
+		Examples:    []string{`string(gzip_decompress(gzip_compress("hi"))) => hi`},
+	},
+	"gzip_decompress": {
+		Signature:   "gzip_decompress(bytes)",
+		Description: "The bytes held in gzip-compressed data.",
+	},
+	"zip_list": {
+		Signature:   "zip_list(archive)",
+		Description: "The names of the files in a zip archive held in memory.",
+	},
+	"zip_read": {
+		Signature:   "zip_read(archive, name)",
+		Description: "The contents of the named file in a zip archive, or null if it has no such file.",
+	},
+	"db_open": {
+		Signature:   "db_open(dsn)",
+		Description: `A connection to the database named by "driver:source", using a driver the host registered.`,
+	},
+	"db_query": {
+		Signature:   "db_query(db, sql) or db_query(db, sql, params)",
+		Description: "The rows a query returns, as hashes keyed by column name.",
+	},
+	"db_exec": {
+		Signature:   "db_exec(db, sql) or db_exec(db, sql, params)",
+		Description: "Runs a statement and returns a hash with rows_affected and, where known, last_insert_id.",
+	},
+	"db_close": {
+		Signature:   "db_close(db)",
+		Description: "Closes a database connection.",
+	},
+	"call_stack": {
+		Signature:   "call_stack()",
+		Description: "The function calls in progress, innermost first, as hashes of name, call, line and column. The call is the call expression's source text, and the line and column locate its opening parenthesis.",
+		Examples:    []string{`call_stack() => []`},
+	},
+	"locals": {
+		Signature:   "locals()",
+		Description: "The variables of the innermost function call, or the globals outside any, as a hash sorted by name.",
+		Examples:    []string{`fn(a) { locals() }(1) => {a: 1}`},
+	},
+	"open_handles": {
+		Signature:   "open_handles()",
+		Description: "The handles opened and not yet closed, oldest first.",
+		Examples:    []string{`open_handles() => []`},
+	},
+	"duration": {
+		Signature:   "duration(text)",
+		Description: `A duration parsed from text such as "1h30m" or "250ms".`,
+		Examples:    []string{`duration("90s") => 1m30s`},
+	},
+	"log_info": {
+		Signature:   "log_info(msg) or log_info(msg, fields)",
+		Description: "Logs msg at info level with one attribute per pair in fields.",
+	},
+	"log_warn": {
+		Signature:   "log_warn(msg) or log_warn(msg, fields)",
+		Description: "Logs msg at warning level with one attribute per pair in fields.",
+	},
+	"log_error": {
+		Signature:   "log_error(msg) or log_error(msg, fields)",
+		Description: "Logs msg at error level with one attribute per pair in fields.",
+	},
+	"on_signal": {
+		Signature:   "on_signal(name, handler)",
+		Description: `Calls handler when the process receives the signal name, such as "SIGINT", then stops evaluation.`,
+	},
+	"stub_builtin": {
+		Signature:   "stub_builtin(name, fn)",
+		Description: "Makes calls of the builtin name run fn instead until the calling function returns.",
+	},
+	"memoize": {
+		Signature:   "memoize(fn) or memoize(fn, limit)",
+		Description: "A function that remembers up to limit of fn's most recently used results.",
+		Examples:    []string{`memoize(fn(x) { x * 2 })(4) => 8`},
+	},
+	"arity": {
+		Signature:   "arity(fn)",
+		Description: "How many parameters a function declares, or null for builtins.",
+		Examples:    []string{`arity(fn(a, b) { a }) => 2`},
+	},
+	"params": {
+		Signature:   "params(fn)",
+		Description: "The parameter names of a function, or null for builtins.",
+		Examples:    []string{`params(fn(a, b) { a }) => [a, b]`},
+	},
+	"name": {
+		Signature:   "name(fn)",
+		Description: "The name a function was bound to or a builtin is registered under, or null.",
+		Examples:    []string{`name(len) => len`},
+	},
+	"partial": {
+		Signature:   "partial(fn, args...)",
+		Description: "A function that calls fn with args followed by the arguments it is given.",
+		Examples:    []string{`partial(fn(a, b) { a - b }, 10)(3) => 7`},
+	},
+	"curry": {
+		Signature:   "curry(fn) or curry(fn, arity)",
+		Description: "A function that collects arguments over any number of calls until it has arity of them; builtins need arity.",
+		Examples:    []string{`curry(fn(a, b) { a - b })(10)(3) => 7`},
+	},
+	"compose": {
+		Signature:   "compose(fns...)",
+		Description: "A function that calls the last of fns, then each earlier one with the previous result.",
+		Examples:    []string{`compose(fn(x) { x + 1 }, fn(x) { x * 2 })(5) => 11`},
+	},
+	"now": {
+		Signature:   "now()",
+		Description: "The current time.",
+	},
+	"every": {
+		Signature:   "every(interval, fn)",
+		Description: "Schedules fn to run every interval, in milliseconds or as a duration, and returns a timer.",
+	},
+	"after": {
+		Signature:   "after(delay, fn)",
+		Description: "Schedules fn to run once after delay, in milliseconds or as a duration, and returns a timer.",
+	},
+	"cancel": {
+		Signature:   "cancel(timer)",
+		Description: "Stops a timer from running again.",
+	},
+	"bench": {
+		Signature:   "bench(name, fn) or bench(name, fn, time)",
+		Description: "Calls fn in growing rounds until one lasts time, prints the cost per call and returns it as a hash.",
+	},
+	"forall": {
+		Signature:   "forall(gens..., property) or forall(gens..., property, options)",
+		Description: "Checks property against generated values for the options runs and seed, shrinking any counterexample.",
+	},
+	"gen_int": {
+		Signature:   "gen_int(lo, hi)",
+		Description: "A generator of integers from lo to hi inclusive.",
+		Examples:    []string{`type(gen_int(0, 9)) => GENERATOR`},
+	},
+	"gen_bool": {
+		Signature:   "gen_bool()",
+		Description: "A generator of booleans.",
+	},
+	"gen_string": {
+		Signature:   "gen_string(max_len)",
+		Description: "A generator of strings of up to max_len lower-case letters.",
+	},
+	"gen_array": {
+		Signature:   "gen_array(gen, max_len)",
+		Description: "A generator of arrays of up to max_len values from gen.",
+	},
+	"gen_one_of": {
+		Signature:   "gen_one_of(values)",
+		Description: "A generator choosing among the elements of an array.",
+	},
+	"events": {
+		Signature:   "events()",
+		Description: "A hash of on(name, fn), registering a handler, and emit(name, args...), calling the handlers.",
+		Examples:    []string{`events()["emit"]("ready") => 0`},
+	},
+	"retry": {
+		Signature:   "retry(n, backoff, fn)",
+		Description: "Calls fn up to n times until it does not fail, waiting backoff milliseconds and doubling the wait each time.",
+		Examples:    []string{`retry(3, 0, fn() { 1 }) => 1`},
+	},
+	"sleep": {
+		Signature:   "sleep(delay)",
+		Description: "Waits for delay, in milliseconds or as a duration.",
+	},
+	"time": {
+		Signature:   "time(seconds) or time(text) or time(text, layout)",
+		Description: "The time seconds after the Unix epoch, or parsed from RFC 3339 text or a Go time layout.",
+	},
+	"with_timeout": {
+		Signature:   "with_timeout(limit, fn)",
+		Description: "Calls fn, failing with a CancelledError if it takes longer than limit, in milliseconds or as a duration.",
+		Examples:    []string{`with_timeout(1000, fn() { 1 }) => 1`},
+	},
+	"ws_connect": {
+		Signature:   "ws_connect(url)",
+		Description: "A WebSocket connection to a ws:// or wss:// URL.",
+	},
+	"ws_send": {
+		Signature:   "ws_send(ws, message)",
+		Description: "Sends message over a WebSocket as text.",
+	},
+	"ws_recv": {
+		Signature:   "ws_recv(ws)",
+		Description: "The next message from a WebSocket, or null once the server has closed it.",
+	},
+	"ws_close": {
+		Signature:   "ws_close(ws)",
+		Description: "Closes a WebSocket connection.",
+	},
+	"style": {
+		Signature:   "style(text, styles...)",
+		Description: "Text wrapped in ANSI escapes for the named colors and attributes, when puts writes to a terminal or FORCE_COLOR is set, unless NO_COLOR is set.",
+	},
+	"is_tty": {
+		Signature:   "is_tty()",
+		Description: "Whether puts writes to a terminal.",
+	},
+	"prompt": {
+		Signature:   "prompt(question) or prompt(question, options)",
+		Description: "The line the user answers question with, with the options default and validate.",
+	},
+	"confirm": {
+		Signature:   "confirm(question) or confirm(question, default)",
+		Description: "Asks a yes-or-no question until it is answered and returns the answer as a boolean.",
+	},
+	"print_table": {
+		Signature:   "print_table(rows) or print_table(rows, headers)",
+		Description: "Prints an array of arrays or of hashes as aligned columns.",
+	},
+	"puts": {
+		Signature:   "puts(values...)",
+		Description: "Prints each value on a line of its own.",
+	},
+	"help": {
+		Signature:   "help() or help(name)",
+		Description: "Prints the usage of the builtin or prelude function name, or the signature of every one.",
+	},
+	"version": {
+		Signature:   "version()",
+		Description: "The interpreter's version.",
+	},
+	"features": {
+		Signature:   "features()",
+		Description: "A hash of the interpreter's version, engine, granted capabilities and whether the prelude and signal handling are enabled.",
+		Examples:    []string{`features()["engine"] => eval`},
+	},
+
+	"map": {
+		Signature:   "map(array, fn)",
+		Description: "A new array of fn applied to each element.",
+		Examples:    []string{`map([1, 2, 3], fn(x) { x * 2 }) => [2, 4, 6]`},
+	},
+	"filter": {
+		Signature:   "filter(array, predicate)",
+		Description: "A new array of the elements for which predicate is truthy.",
+		Examples:    []string{`filter([1, 2, 3], fn(x) { x > 1 }) => [2, 3]`},
+	},
+	"reduce": {
+		Signature:   "reduce(array, initial, fn)",
+		Description: "Combines the elements from left to right, starting with initial.",
+		Examples:    []string{`reduce([1, 2, 3], 0, fn(acc, x) { acc + x }) => 6`},
+	},
+	"reverse": {
+		Signature:   "reverse(array)",
+		Description: "A new array of the elements in reverse order.",
+		Examples:    []string{`reverse([1, 2, 3]) => [3, 2, 1]`},
+	},
+	"range": {
+		Signature:   "range(start, end)",
+		Description: "The integers from start up to but not including end.",
+		Examples:    []string{`range(0, 3) => [0, 1, 2]`},
+	},
+}
+
+// Doc returns the documentation of the builtin or prelude function name,
+// if the interpreter has one of that name.
+func (in *Interpreter) Doc(name string) (Doc, bool) {
+	if !in.HasBuiltin(name) {
+		return Doc{}, false
+	}
+	doc, ok := builtinDocs[name]
+	return doc, ok
+}
+
+// Documented returns the names of the interpreter's documented builtins and
+// prelude functions in sorted order.
+func (in *Interpreter) Documented() []string {
+	var names []string
+	for name := range builtinDocs {
+		if in.HasBuiltin(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// builtinHelp implements help(name), printing the usage of name, and
+// help(), printing the signature of everything documented.
+func (in *Interpreter) builtinHelp(ctx context.Context, args ...object.Object) object.Object {
+	switch len(args) {
+	case 0:
+		for _, name := range in.Documented() {
+			fmt.Fprintln(in.stdout, builtinDocs[name].Signature)
+		}
+		return NULL
+	case 1:
+	default:
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=0 or 1", len(args))
+	}
+
+	var name string
+	switch arg := args[0].(type) {
+	case *object.String:
+		name = arg.Value
+	case *object.Builtin, *object.Function:
+		name = in.builtinName(ctx, arg).Inspect()
+	default:
+		return newError(object.TypeError, "argument to `help` must be STRING or a function, got %s", args[0].Type())
+	}
+
+	doc, ok := in.Doc(name)
+	if !ok {
+		return newError(object.NameError, "help: no documentation for %s", name)
+	}
+	fmt.Fprint(in.stdout, doc)
+
+	return NULL
+}
@@ -1,99 +1,220 @@
 package evaluator
 
 import (
-	"fmt"
+	"context"
 	"monkey/ast"
 	"monkey/object"
 	"monkey/token"
+	"slices"
+	"strings"
 )
 
+// returnSignal is the result of a return statement while the returned value
+// waits in Interpreter.returnValue. It unwinds like an error until the
+// enclosing function call or program takes the value, so returning costs a
+// pointer comparison per level instead of an allocation and type switches.
+var returnSignal object.Object = &object.ReturnValue{}
+
+// TRUE, FALSE and NULL alias the canonical objects from the object package.
+// They are never modified, which is what lets interpreters running in
+// parallel goroutines share them and still compare booleans by identity.
 var (
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
-	NULL  = &object.Null{}
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
+	NULL  = object.NULL
 )
 
+// Eval evaluates node in env with a default-configured interpreter and no
+// deadline or cancellation. Each call builds a new interpreter, with its
+// builtins, prelude and streams, so hosts evaluating repeatedly should make
+// one with New and reuse it.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return New().Eval(node, env)
+}
+
+// EvalContext evaluates node in env with a default-configured interpreter,
+// built anew for each call like Eval's.
+func EvalContext(ctx context.Context, node ast.Node, env *object.Environment) object.Object {
+	return New().EvalContext(ctx, node, env)
+}
+
+// eval evaluates node, failing with a RecursionError instead of exhausting
+// the Go stack when nodes nest more deeply than maxNesting, whether through
+// deeply nested expressions or deep recursion with WithMaxDepth(0).
+func (in *Interpreter) eval(ctx context.Context, node ast.Node, env *object.Environment) object.Object {
+	if in.nesting >= maxNesting {
+		return newError(object.RecursionError, "evaluation nested too deeply (%d)", maxNesting)
+	}
+
+	in.nesting++
+	var result object.Object
+	if in.trace == nil {
+		result = in.evalNode(ctx, node, env)
+	} else {
+		in.trace(TraceEvent{Node: node, Env: env})
+		result = in.evalNode(ctx, node, env)
+		in.traceExit(node, env, result)
+	}
+	if in.stats != nil {
+		in.stats.countNode(node, result)
+	}
+	in.nesting--
+
+	return result
+}
+
+func (in *Interpreter) evalNode(ctx context.Context, node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
-		return evalProgram(node.Statements, env)
+		return in.evalProgram(ctx, node.Statements, env)
 	case *ast.BlockStatement:
-		return evalBlockStatement(node.Statements, env)
+		return in.evalBlockStatement(ctx, node.Statements, env)
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return in.eval(ctx, node.Expression, env)
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.IntegerLiteral:
-		return &object.Integer{Value: node.Value}
+		return object.NewInteger(node.Value)
 	case *ast.StringLiteral:
-		return &object.String{Value: node.Value}
+		return in.internString(node.Value)
+	case *ast.ImportExpression:
+		return in.importModule(ctx, node.Path)
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
-		if isError(right) {
+		right := in.eval(ctx, node.Right, env)
+		if isAbrupt(right) {
 			return right
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return in.evalIfExpression(ctx, node, env)
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
+		left := in.eval(ctx, node.Left, env)
+		if isAbrupt(left) {
 			return left
 		}
-		right := Eval(node.Right, env)
-		if isError(right) {
+		switch node.Operator {
+		case token.AND:
+			if !IsTruthy(left) {
+				return FALSE
+			}
+			return in.evalCondition(ctx, node.Right, env)
+		case token.OR:
+			if IsTruthy(left) {
+				return TRUE
+			}
+			return in.evalCondition(ctx, node.Right, env)
+		}
+		right := in.eval(ctx, node.Right, env)
+		if isAbrupt(right) {
 			return right
 		}
-		return evalInfixExpression(left, right, node.Operator)
+		if in.diagnose != nil {
+			in.checkInfix(node, left, right)
+		}
+		if node.Token.Type == token.IN {
+			return evalInExpression(left, right)
+		}
+		return evalInfixExpression(&in.integers, left, right, node.Operator)
+	case *ast.AssignExpression:
+		return in.evalAssignExpression(ctx, node, env)
+	case *ast.IsExpression:
+		left := in.eval(ctx, node.Left, env)
+		if isAbrupt(left) {
+			return left
+		}
+		return nativeBoolToBooleanObject(string(left.Type()) == node.Type.Value)
+	case *ast.WhileExpression:
+		return in.evalWhileExpression(ctx, node, env)
+	case *ast.ForExpression:
+		return in.evalForExpression(ctx, node, env)
+	case *ast.BranchStatement:
+		in.branchLabel = ""
+		if node.Label != nil {
+			in.branchLabel = node.Label.Value
+		}
+		if node.Token.Type == token.BREAK {
+			return breakSignal
+		}
+		return continueSignal
 	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, env)
-		if isError(val) {
+		val := in.eval(ctx, node.ReturnValue, env)
+		if isAbrupt(val) {
 			return val
 		}
-		return &object.ReturnValue{Value: val}
+		in.returnValue = val
+		return returnSignal
 	case *ast.LetStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
+		if env.Frozen() {
+			return newError(object.NameError, "cannot define %s in a read-only environment", node.Name.Value)
+		}
+		val := in.eval(ctx, node.Value, env)
+		if isAbrupt(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if node.Rest != nil {
+			return unpack(node, val, env)
+		}
+		bind(node.Name, val, env)
 	case *ast.Identifier:
-		val, ok := env.Get(node.Value)
-		if ok {
+		if node.Resolved {
+			if val, ok := env.GetAt(node.Depth, node.Index); ok {
+				return val
+			}
+		}
+
+		if val, ok := env.Get(node.Value); ok {
 			return val
 		}
 
-		if builtin, ok := builtins[node.Value]; ok {
+		// Builtins come before the prelude, so a host builtin replacing a
+		// prelude function such as map takes effect.
+		if builtin, ok := in.builtins[node.Value]; ok {
 			return builtin
 		}
-
-		if !ok {
-			return newError("identifier not found: %s", node.Value)
+		if val := in.fromPrelude(node.Value); val != nil {
+			return val
 		}
-		return val
+
+		return newError(object.NameError, "identifier not found: %s", node.Value)
 	case *ast.FunctionLiteral:
 		return &object.Function{
 			Parameters: node.Parameters,
 			Body:       node.Body,
 			Env:        env,
+			Name:       node.Name,
+			Locals:     node.Locals,
+			Captured:   node.Captured,
 		}
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
+		function := in.eval(ctx, node.Function, env)
+		if isAbrupt(function) {
 			return function
 		}
 
-		args := evalExpressions(node.Arguments, env)
+		args := in.evalExpressions(ctx, node.Arguments, env)
 
-		if len(args) == 1 && isError(args[0]) {
+		if len(args) == 1 && isAbrupt(args[0]) {
 			return args[0]
 		}
 
-		return applyFunction(function, args)
+		in.callSite = node
+		return in.applyFunction(ctx, function, args)
+	case *ast.TupleLiteral:
+		elems := in.evalExpressions(ctx, node.Elements, env)
+		if len(elems) == 1 && isAbrupt(elems[0]) {
+			return elems[0]
+		}
+		return &object.Tuple{Elements: elems}
 	case *ast.ArrayLiteral:
-		elems := evalExpressions(node.Elements, env)
+		if node.Constant {
+			return in.evalConstant(ctx, node, env)
+		}
+
+		elems := in.evalExpressions(ctx, node.Elements, env)
 
-		if len(elems) == 1 && isError(elems[0]) {
+		if len(elems) == 1 && isAbrupt(elems[0]) {
 			return elems[0]
 		}
 
@@ -101,126 +222,283 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			Elements: elems,
 		}
 	case *ast.IndexExpression:
-		array := Eval(node.Left, env)
-		if isError(array) {
+		array := in.eval(ctx, node.Left, env)
+		if isAbrupt(array) {
 			return array
 		}
 
-		index := Eval(node.Index, env)
-		if isError(index) {
+		index := in.eval(ctx, node.Index, env)
+		if isAbrupt(index) {
 			return index
 		}
 
-		return applyIndex(array, index)
+		return in.applyIndex(ctx, array, index)
 	case *ast.HashLiteral:
-		return evalHashLiteral(node, env)
+		if node.Constant {
+			return in.evalConstant(ctx, node, env)
+		}
+		return in.evalHashLiteral(ctx, node, env)
 	}
 
 	return nil
 }
 
-func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+// evalConstant returns the object built for a literal the optimizer marked
+// constant, building it on first use. Arrays and hashes are never modified
+// in place, so every evaluation can share the elements, but each gets its
+// own array or hash, as it would unfolded.
+func (in *Interpreter) evalConstant(ctx context.Context, node ast.Expression, env *object.Environment) object.Object {
+	if obj, ok := in.constants[node]; ok {
+		return freshConstant(obj)
+	}
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
-		if isError(key) {
+	var obj object.Object
+	switch node := node.(type) {
+	case *ast.ArrayLiteral:
+		elems := in.evalExpressions(ctx, node.Elements, env)
+		if len(elems) == 1 && isAbrupt(elems[0]) {
+			return elems[0]
+		}
+		obj = &object.Array{Elements: elems}
+	case *ast.HashLiteral:
+		obj = in.evalHashLiteral(ctx, node, env)
+		if isAbrupt(obj) {
+			return obj
+		}
+	}
+
+	if len(in.constants) < maxConstants {
+		in.constants[node] = obj
+	}
+
+	return obj
+}
+
+// freshConstant returns new arrays and hashes around the elements of a
+// cached constant, all the way down, so no two evaluations share one.
+func freshConstant(obj object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.Array:
+		elements, copied := obj.Elements, false
+		for i, element := range obj.Elements {
+			fresh := freshConstant(element)
+			if fresh == element {
+				continue
+			}
+			if !copied {
+				elements, copied = slices.Clone(obj.Elements), true
+			}
+			elements[i] = fresh
+		}
+		return &object.Array{Elements: elements}
+	case *object.Hash:
+		hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair, len(obj.Pairs))}
+		for _, pair := range obj.Ordered() {
+			hash.Set(pair.Key.(object.Hashable).HashKey(), object.HashPair{Key: pair.Key, Value: freshConstant(pair.Value)})
+		}
+		return hash
+	default:
+		return obj
+	}
+}
+
+func (in *Interpreter) evalHashLiteral(ctx context.Context, node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair, len(node.Keys))}
+
+	for _, keyNode := range node.Keys {
+		key := in.eval(ctx, keyNode, env)
+		if isAbrupt(key) {
 			return key
 		}
 
 		keyHash, ok := key.(object.Hashable)
 		if !ok {
-			return newError("unusable as hash key: %s", key.Type())
+			return newError(object.TypeError, "unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
-		if isError(value) {
+		value := in.eval(ctx, node.Pairs[keyNode], env)
+		if isAbrupt(value) {
 			return value
 		}
 
-		pairs[keyHash.HashKey()] = object.HashPair{
-			Key:   key,
-			Value: value,
-		}
+		hash.Set(keyHash.HashKey(), object.HashPair{Key: key, Value: value})
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
-func applyIndex(left object.Object, index object.Object) object.Object {
+func (in *Interpreter) applyIndex(ctx context.Context, left object.Object, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
-		return evalArrayIndexExpression(left, index)
+		return evalArrayIndexExpression(left.(*object.Array).Elements, index)
+	case left.Type() == object.TUPLE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalArrayIndexExpression(left.(*object.Tuple).Elements, index)
+	case left.Type() == object.BYTES_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalBytesIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
-		return evalHashIndexExpression(left, index)
+		return in.evalHashIndexExpression(ctx, left, index)
+	case isMethodProvider(left) && index.Type() == object.STRING_OBJ:
+		return evalMethodExpression(left.(object.MethodProvider), index.(*object.String).Value, left.Type())
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return newError(object.TypeError, "index operator not supported: %s", left.Type())
+	}
+}
+
+func isMethodProvider(obj object.Object) bool {
+	_, ok := obj.(object.MethodProvider)
+	return ok
+}
+
+func evalMethodExpression(provider object.MethodProvider, name string, objType object.ObjectType) object.Object {
+	method, ok := provider.Method(name)
+	if !ok {
+		return newError(object.NameError, "undefined method %s on %s", name, objType)
 	}
+
+	return &object.Builtin{Fn: method}
 }
 
-func evalHashIndexExpression(hashTable, index object.Object) object.Object {
+func (in *Interpreter) evalHashIndexExpression(ctx context.Context, hashTable, index object.Object) object.Object {
 	hashObject := hashTable.(*object.Hash)
 
 	key, ok := index.(object.Hashable)
 	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
+		return newError(object.TypeError, "unusable as hash key: %s", index.Type())
 	}
 
 	pair, ok := hashObject.Pairs[key.HashKey()]
 	if !ok {
-		return NULL
+		switch {
+		case hashObject.Default == nil:
+			return NULL
+		case isCallable(hashObject.Default):
+			return in.applyFunction(ctx, hashObject.Default, nil)
+		default:
+			return hashObject.Default
+		}
 	}
 
 	return pair.Value
 }
 
-func evalArrayIndexExpression(array, index object.Object) object.Object {
-	arrayObject := array.(*object.Array)
+func evalArrayIndexExpression(elements []object.Object, index object.Object) object.Object {
 	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
+	max := int64(len(elements) - 1)
 	if idx < 0 || idx > max {
 		return NULL
 	}
-	return arrayObject.Elements[idx]
+	return elements[idx]
+}
+
+func evalBytesIndexExpression(bytes, index object.Object) object.Object {
+	value := bytes.(*object.Bytes).Value
+	idx := index.(*object.Integer).Value
+	if idx < 0 || idx >= int64(len(value)) {
+		return NULL
+	}
+	return object.NewInteger(int64(value[idx]))
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+func (in *Interpreter) applyFunction(ctx context.Context, fn object.Object, args []object.Object) object.Object {
+	call := in.callSite
+	in.callSite = nil
+
+	if err := ctx.Err(); err != nil {
+		return newCancelledError("evaluation stopped", err)
+	}
+	if interrupted := in.checkSignals(ctx); interrupted != nil {
+		return interrupted
+	}
+
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnvironment(fn, args)
-		result := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(result)
+		if in.maxDepth > 0 && in.depth >= in.maxDepth {
+			return newError(object.RecursionError, "maximum call depth exceeded (%d)", in.maxDepth)
+		}
+		in.depth++
+		defer func() { in.depth-- }()
+
+		extendedEnv, err := in.extendFunctionEnvironment(fn, args)
+		if err != nil {
+			return err
+		}
+		if in.stats != nil {
+			in.stats.Environments++
+			in.stats.MaxDepth = max(in.stats.MaxDepth, in.depth)
+		}
+
+		in.frames = append(in.frames, frame{fn: fn, call: call, env: extendedEnv})
+		defer func(stubbed int) {
+			in.frames = in.frames[:len(in.frames)-1]
+			in.restoreStubs(stubbed)
+		}(len(in.stubs))
+
+		result := in.eval(ctx, fn.Body, extendedEnv)
+		if fn.Captured != nil {
+			extendedEnv.Release(fn.Captured)
+		}
+		return in.unwrapReturnValue(result)
 	case *object.Builtin:
-		return fn.Fn(args...)
+		result := fn.Fn(ctx, args...)
+		if in.stats != nil {
+			in.stats.countValue(result)
+		}
+		return result
 	default:
-		return newError("not a function: %s", fn.Type())
+		return newError(object.TypeError, "not a function: %s", fn.Type())
 	}
 }
 
-func unwrapReturnValue(obj object.Object) object.Object {
-	if returnValue, ok := obj.(*object.ReturnValue); ok {
-		return returnValue.Value
+// unwrapReturnValue turns the return signal back into the value being
+// returned.
+func (in *Interpreter) unwrapReturnValue(obj object.Object) object.Object {
+	if obj == returnSignal {
+		val := in.returnValue
+		in.returnValue = nil
+		return val
 	}
 
 	return obj
 }
 
-func extendFunctionEnvironment(fn *object.Function, args []object.Object) *object.Environment {
-	env := object.ExtendEnvironment(fn.Env)
+// extendFunctionEnvironment binds args to fn's parameters. Outside strict
+// mode missing arguments are null and extra ones are ignored.
+func (in *Interpreter) extendFunctionEnvironment(fn *object.Function, args []object.Object) (*object.Environment, *object.Error) {
+	if !in.lenient && len(args) != len(fn.Parameters) {
+		return nil, newError(object.ArgumentError, "wrong number of arguments. got=%d, want=%d",
+			len(args), len(fn.Parameters))
+	}
 
+	if fn.Locals == nil {
+		env := object.ExtendEnvironment(fn.Env)
+		for i, parameter := range fn.Parameters {
+			env.Set(parameter.Value, argumentAt(args, i))
+		}
+		return env, nil
+	}
+
+	env := object.NewFunctionEnvironment(fn.Env, fn.Locals)
 	for i, parameter := range fn.Parameters {
-		env.Set(parameter.Value, args[i])
+		env.SetAt(parameter.Index, argumentAt(args, i))
+	}
+
+	return env, nil
+}
+
+func argumentAt(args []object.Object, i int) object.Object {
+	if i < len(args) {
+		return args[i]
 	}
 
-	return env
+	return NULL
 }
 
-func evalExpressions(arguments []ast.Expression, env *object.Environment) []object.Object {
+func (in *Interpreter) evalExpressions(ctx context.Context, arguments []ast.Expression, env *object.Environment) []object.Object {
 	results := []object.Object{}
 
 	for _, argument := range arguments {
-		result := Eval(argument, env)
-		if isError(result) {
+		result := in.eval(ctx, argument, env)
+		if isAbrupt(result) {
 			return []object.Object{result}
 		}
 		results = append(results, result)
@@ -229,20 +507,25 @@ func evalExpressions(arguments []ast.Expression, env *object.Environment) []obje
 	return results
 }
 
-func evalIfExpression(node *ast.IfExpression, env *object.Environment) object.Object {
-	condition := Eval(node.Condition, env)
+func (in *Interpreter) evalIfExpression(ctx context.Context, node *ast.IfExpression, env *object.Environment) object.Object {
+	condition := in.eval(ctx, node.Condition, env)
+	if isAbrupt(condition) {
+		return condition
+	}
+
 	var returnValue object.Object
-	if isTruthy(condition) {
-		returnValue = Eval(node.Consequence, env)
+	if IsTruthy(condition) {
+		returnValue = in.eval(ctx, node.Consequence, env)
 	} else if node.Alternative != nil {
-		returnValue = Eval(node.Alternative, env)
+		returnValue = in.eval(ctx, node.Alternative, env)
 	} else {
 		return NULL
 	}
 	return returnValue
 }
 
-func isTruthy(obj object.Object) bool {
+// IsTruthy reports whether obj counts as true in a condition.
+func IsTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
 		return false
@@ -251,50 +534,158 @@ func isTruthy(obj object.Object) bool {
 	case FALSE:
 		return false
 	default:
+		if truther, ok := obj.(object.Truther); ok {
+			return truther.Truthy()
+		}
 		return true
 	}
 }
 
-func evalInfixExpression(left object.Object, right object.Object, operator string) object.Object {
+func bind(name *ast.Identifier, val object.Object, env *object.Environment) {
+	if name.Resolved {
+		env.SetAt(name.Index, val)
+	} else {
+		env.Set(name.Value, val)
+	}
+}
+
+// unpack binds the elements of a tuple or array to the names of a let
+// statement such as "let q, r = divmod(7, 2)", one name per element.
+func unpack(node *ast.LetStatement, val object.Object, env *object.Environment) object.Object {
+	var elements []object.Object
+	switch val := val.(type) {
+	case *object.Tuple:
+		elements = val.Elements
+	case *object.Array:
+		elements = val.Elements
+	default:
+		return newError(object.TypeError, "cannot unpack %s into %d names", val.Type(), len(node.Rest)+1)
+	}
+
+	if len(elements) != len(node.Rest)+1 {
+		return newError(object.TypeError, "cannot unpack %d values into %d names", len(elements), len(node.Rest)+1)
+	}
+
+	bind(node.Name, elements[0], env)
+	for i, name := range node.Rest {
+		bind(name, elements[i+1], env)
+	}
+	return nil
+}
+
+// evalAssignExpression rebinds an existing variable. A compound operator
+// such as += combines the current value with the new one first.
+func (in *Interpreter) evalAssignExpression(ctx context.Context, node *ast.AssignExpression, env *object.Environment) object.Object {
+	val := in.eval(ctx, node.Value, env)
+	if isAbrupt(val) {
+		return val
+	}
+
+	name := node.Name
+	if node.Operator != token.ASSIGN {
+		current, ok := lookup(name, env)
+		if !ok {
+			return newError(object.NameError, "cannot assign to undefined variable %s", name.Value)
+		}
+		val = evalInfixExpression(&in.integers, current, val, strings.TrimSuffix(node.Operator, "="))
+		if isError(val) {
+			return val
+		}
+	}
+
+	if name.Resolved && env.AssignAt(name.Depth, name.Index, val) {
+		return val
+	}
+	if !env.Assign(name.Value, val) {
+		if _, ok := env.Get(name.Value); ok {
+			return newError(object.NameError, "cannot assign to %s, which is bound in a read-only environment", name.Value)
+		}
+		return newError(object.NameError, "cannot assign to undefined variable %s", name.Value)
+	}
+	return val
+}
+
+// lookup returns the variable ident refers to, without falling back to
+// builtins.
+func lookup(ident *ast.Identifier, env *object.Environment) (object.Object, bool) {
+	if ident.Resolved {
+		if val, ok := env.GetAt(ident.Depth, ident.Index); ok {
+			return val, true
+		}
+	}
+	return env.Get(ident.Value)
+}
+
+// evalInfixExpression applies operator to left and right, taking integer
+// results from integers, or allocating each one if integers is nil.
+func evalInfixExpression(integers *object.IntegerArena, left object.Object, right object.Object, operator string) object.Object {
 	switch {
+	case isTemporal(left) || isTemporal(right):
+		return evalTemporalInfixExpression(left, right, operator)
+	case isFloatOperation(left, right):
+		return evalFloatInfixExpression(left, right, operator)
 	case left.Type() != right.Type():
-		return newError("type mismatch: %s + %s", left.Type(), right.Type())
+		return newError(object.TypeError, "type mismatch: %s + %s", left.Type(), right.Type())
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		leftValue := left.(*object.String)
 		rightValue := right.(*object.String)
 		return evalStringInfixExpression(leftValue, rightValue, operator)
+	case left.Type() == object.BYTES_OBJ && right.Type() == object.BYTES_OBJ && operator == token.PLUS:
+		leftValue := left.(*object.Bytes).Value
+		rightValue := right.(*object.Bytes).Value
+		return &object.Bytes{Value: append(leftValue[:len(leftValue):len(leftValue)], rightValue...)}
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		leftValue := left.(*object.Integer)
 		rightValue := right.(*object.Integer)
-		return evalIntegerInfixExpression(leftValue, rightValue, operator)
+		return evalIntegerInfixExpression(integers, leftValue, rightValue, operator)
 	case operator == token.EQ:
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(objectsEqual(left, right))
 	case operator == token.NOT_EQ:
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!objectsEqual(left, right))
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TypeError, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
+// objectsEqual compares two objects of the same type that have no dedicated
+// infix handling: by identity, unless the type defines its own equality.
+func objectsEqual(left, right object.Object) bool {
+	if equaler, ok := left.(object.Equaler); ok {
+		return equaler.Equal(right)
+	}
+
+	return left == right
+}
+
 func evalStringInfixExpression(left *object.String, right *object.String, operator string) object.Object {
 	switch operator {
 	case token.PLUS:
-		return &object.String{Value: left.Value + right.Value}
+		return object.NewString(left.Value + right.Value)
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TypeError, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
-func evalIntegerInfixExpression(left *object.Integer, right *object.Integer, operator string) object.Object {
+func evalIntegerInfixExpression(integers *object.IntegerArena, left *object.Integer, right *object.Integer, operator string) object.Object {
 	switch operator {
 	case token.PLUS:
-		return &object.Integer{Value: left.Value + right.Value}
+		return integers.New(left.Value + right.Value)
 	case token.MINUS:
-		return &object.Integer{Value: left.Value - right.Value}
+		return integers.New(left.Value - right.Value)
 	case token.ASTERISK:
-		return &object.Integer{Value: left.Value * right.Value}
+		return integers.New(left.Value * right.Value)
+	case token.POWER:
+		return integerPower(left.Value, right.Value)
 	case token.SLASH:
-		return &object.Integer{Value: left.Value / right.Value}
+		if right.Value == 0 {
+			return newError(object.ZeroDivisionError, "division by zero")
+		}
+		return &object.Float{Value: float64(left.Value) / float64(right.Value)}
+	case token.FLOOR_SLASH:
+		if right.Value == 0 {
+			return newError(object.ZeroDivisionError, "division by zero")
+		}
+		return integers.New(floorDivide(left.Value, right.Value))
 	case token.EQ:
 		return nativeBoolToBooleanObject(left.Value == right.Value)
 	case token.NOT_EQ:
@@ -303,29 +694,44 @@ func evalIntegerInfixExpression(left *object.Integer, right *object.Integer, ope
 		return nativeBoolToBooleanObject(left.Value < right.Value)
 	case token.GT:
 		return nativeBoolToBooleanObject(left.Value > right.Value)
+	case token.LT_EQ:
+		return nativeBoolToBooleanObject(left.Value <= right.Value)
+	case token.GT_EQ:
+		return nativeBoolToBooleanObject(left.Value >= right.Value)
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.TypeError, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// floorDivide divides a by b rounding toward negative infinity, so -7 // 2
+// is -4 and the remainder always has the sign of b.
+func floorDivide(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
 	}
+	return q
 }
 
-func evalPrefixExpression(operator string, object object.Object) object.Object {
+func evalPrefixExpression(operator string, right object.Object) object.Object {
 	switch operator {
 	case token.BANG:
-		return evalBangOperator(object)
+		return evalBangOperator(right)
 	case token.MINUS:
-		return evalMinusPrefixOperator(object)
+		return evalMinusPrefixOperator(right)
 	default:
-		return newError("unknown operator: %s%s", operator, object.Type())
+		return newError(object.TypeError, "unknown operator: %s%s", operator, right.Type())
 	}
 }
 
 func evalMinusPrefixOperator(o object.Object) object.Object {
-	if o.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: %s%s", token.MINUS, o.Type())
-	}
-
-	return &object.Integer{
-		Value: -o.(*object.Integer).Value,
+	switch o := o.(type) {
+	case *object.Integer:
+		return object.NewInteger(-o.Value)
+	case *object.Float:
+		return &object.Float{Value: -o.Value}
+	default:
+		return newError(object.TypeError, "unknown operator: %s%s", token.MINUS, o.Type())
 	}
 }
 
@@ -338,7 +744,7 @@ func evalBangOperator(o object.Object) object.Object {
 	case NULL:
 		return TRUE
 	default:
-		return FALSE
+		return nativeBoolToBooleanObject(!IsTruthy(o))
 	}
 }
 
@@ -349,41 +755,49 @@ func nativeBoolToBooleanObject(input bool) object.Object {
 	return FALSE
 }
 
-func evalBlockStatement(statements []ast.Statement, env *object.Environment) object.Object {
+func (in *Interpreter) evalBlockStatement(ctx context.Context, statements []ast.Statement, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, statement := range statements {
-		result = Eval(statement, env)
+		result = in.eval(ctx, statement, env)
 
-		if result != nil {
-			switch result.Type() {
-			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
-				return result
-			}
+		if isAbrupt(result) {
+			return result
 		}
 	}
 
 	return result
 }
 
-func evalProgram(statements []ast.Statement, env *object.Environment) object.Object {
+func (in *Interpreter) evalProgram(ctx context.Context, statements []ast.Statement, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, statement := range statements {
-		result = Eval(statement, env)
-		switch result := result.(type) {
-		case *object.ReturnValue:
-			return result.Value
-		case *object.Error:
-			return result
+		result = in.eval(ctx, statement, env)
+
+		if isAbrupt(result) {
+			return in.unwrapReturnValue(result)
 		}
 	}
 
 	return result
 }
 
-func newError(format string, a ...any) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
+func newError(category object.ErrorCategory, format string, a ...any) *object.Error {
+	return object.Errorf(category, format, a...)
+}
+
+// newCausedError is newError for errors wrapping a Go error.
+func newCausedError(category object.ErrorCategory, cause error, format string, a ...any) *object.Error {
+	err := object.Errorf(category, format, a...)
+	err.Cause = cause
+	return err
+}
+
+// newCancelledError reports that ctx ended evaluation, keeping the context
+// error as the cause so hosts can test for context.DeadlineExceeded.
+func newCancelledError(what string, err error) *object.Error {
+	return newCausedError(object.CancelledError, err, what+": %s", err)
 }
 
 func isError(obj object.Object) bool {
@@ -392,3 +806,24 @@ func isError(obj object.Object) bool {
 	}
 	return false
 }
+
+// isAbrupt reports whether obj ends the evaluation of the enclosing
+// expression: an error, or the return signal on its way to the function
+// call that consumes it.
+func isAbrupt(obj object.Object) bool {
+	if obj == returnSignal || obj == breakSignal || obj == continueSignal {
+		return true
+	}
+
+	_, ok := obj.(*object.Error)
+	return ok
+}
+
+// evalCondition evaluates the right operand of && or || as a boolean.
+func (in *Interpreter) evalCondition(ctx context.Context, node ast.Expression, env *object.Environment) object.Object {
+	value := in.eval(ctx, node, env)
+	if isAbrupt(value) {
+		return value
+	}
+	return nativeBoolToBooleanObject(IsTruthy(value))
+}
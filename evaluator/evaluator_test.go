@@ -1,10 +1,18 @@
 package evaluator
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"monkey/ast"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -22,11 +30,11 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"5 * 2 + 10", 20},
 		{"5 + 2 * 10", 25},
 		{"20 + 2 * -10", 0},
-		{"50 / 2 * 2 + 10", 60},
+		{"50 // 2 * 2 + 10", 60},
 		{"2 * (5 + 10)", 30},
 		{"3 * 3 * 3 + 10", 37},
 		{"3 * (3 * 3) + 10", 37},
-		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"(5 + 10 * 2 + 15 // 3) * 2 + -10", 50},
 	}
 
 	for _, tt := range tests {
@@ -111,6 +119,101 @@ func TestIfElseExpressions(t *testing.T) {
 	}
 }
 
+func TestUnlessAndLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"unless (false) { 10 }", "10"},
+		{"unless (1 < 2) { 10 }", "null"},
+		{"unless (1 < 2) { 10 } else { 20 }", "20"},
+		{"let i = 0; while (i < 5) { i += 1 }; i", "5"},
+		{"let i = 10; while (i < 5) { i += 1 }; i", "10"},
+		{"let i = 10; do { i += 1 } while (i < 5); i", "11"},
+		{"let i = 0; do { i += 1 } while (i < 5); i", "5"},
+		{"while (false) { 1 }", "null"},
+		{"let n = 0; let s = 0; while (n < 4) { n += 1; s += n }; s", "10"},
+		{"let f = fn() { let i = 0; while (true) { i += 1; if (i == 3) { return i } } }; f()", "3"},
+		{"let f = fn(n) { let steps = 0; do { n = n // 2; steps += 1 } while (n > 1); steps }; f(16)", "4"},
+		{"while (1 / 0) { 1 }", "ERROR: division by zero"},
+		{"let i = 0; do { i += \"a\" } while (true)", "ERROR: type mismatch: INTEGER + STRING"},
+		{"let s = 0; for (x in [1, 2, 3]) { s += x }; s", "6"},
+		{"let s = \"\"; for (c in \"héllo\") { s = c + s }; s", "olléh"},
+		{"let s = \"\"; for (k in {\"b\": 1, \"a\": 2}) { s += k }; s", "ba"},
+		{"for (x in []) { x }", "null"},
+		{"for (x in 5) { x }", "ERROR: cannot iterate over INTEGER"},
+		{"let f = fn(xs) { let s = 0; for (x in xs) { s += x }; s }; f([4, 5])", "9"},
+		{"let i = 0; while (true) { i += 1; if (i == 3) { break } }; i", "3"},
+		{"let s = 0; for (x in [1, 2, 3, 4]) { if (x == 2) { continue }; s += x }; s", "8"},
+		{"let i = 0; do { i += 1; continue; i = 100 } while (i < 3); i", "3"},
+		{"let found = 0; outer: for (row in [[1, 2], [3, 4], [5, 6]]) { for (x in row) { if (x == 4) { found = row; break outer } } }; found", "[3, 4]"},
+		{"let n = 0; outer: for (a in [1, 2, 3]) { for (b in [1, 2, 3]) { if (b > a) { continue outer }; n += 1 } }; n", "6"},
+		{"let n = 0; outer: while (n < 10) { inner: while (true) { n += 1; break } }; n", "10"},
+		{"let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x * 10 } } }; f()", "20"},
+		{"let r = []; for (x in [1, 2]) { for (y in [1, 2]) { if (y == 2) { break }; r = push(r, [x, y]) } }; r", "[[1, 1], [2, 1]]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestClosuresKeepOnlyCapturedVariables(t *testing.T) {
+	input := `
+let make = fn(big) {
+	let unused = big;
+	let count = 0;
+	fn() { count += 1 }
+};
+make([1, 2, 3])`
+
+	counter, ok := testEval(input).(*object.Function)
+	if !ok {
+		t.Fatalf("make did not return a function")
+	}
+
+	for _, name := range []string{"big", "unused"} {
+		if _, ok := counter.Env.Get(name); ok {
+			t.Errorf("closure keeps %s alive", name)
+		}
+	}
+	if _, ok := counter.Env.Get("count"); !ok {
+		t.Errorf("closure lost its captured variable")
+	}
+
+	env := object.NewEnvironment()
+	env.Set("counter", counter)
+	program := parser.New(lexer.New("counter(); counter()")).ParseProgram()
+	testIntegerObject(t, Eval(program, env), 2)
+}
+
+func TestMultipleReturnValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let divmod = fn(a, b) { return a // b, a - a // b * b }; divmod(7, 2)", "(3, 1)"},
+		{"let divmod = fn(a, b) { return a // b, a - a // b * b }; let q, r = divmod(17, 5); [q, r]", "[3, 2]"},
+		{"let f = fn() { return 1, \"two\", [3] }; let t = f(); [len(t), t[1], t[2], t[3]]", "[3, two, [3], null]"},
+		{"let a, b = [1, 2]; a + b", "3"},
+		{"let f = fn() { let x, y = [1, 2]; x * 10 + y }; f()", "12"},
+		{"let swap = fn(a, b) { return b, a }; let x, y = swap(1, 2); let x, y = swap(x, y); [x, y]", "[1, 2]"},
+		{"let f = fn() { return 1, 2 }; let a, b, c = f()", "ERROR: cannot unpack 2 values into 3 names"},
+		{"let a, b = 5", "ERROR: cannot unpack INTEGER into 2 names"},
+		{"let f = fn() { return 1, 1 / 0 }; f()", "ERROR: division by zero"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -169,6 +272,23 @@ func TestReturnStatements(t *testing.T) {
 	}
 }
 
+func TestReturnUnwindsThroughExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let f = fn() { let x = if (true) { return 5; }; 10 }; f();", 5},
+		{"let f = fn() { [1, if (true) { return 2; }]; 10 }; f();", 2},
+		{"let f = fn() { len(if (true) { return 3; }); 10 }; f();", 3},
+		{"let f = fn() { if (if (true) { return 4; }) { 10 } }; f();", 4},
+		{"let x = if (true) { return 6; }; 10;", 6},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
 func TestErrorHandling(t *testing.T) {
 	tests := []struct {
 		input           string
@@ -224,6 +344,14 @@ func TestErrorHandling(t *testing.T) {
 			`{"name":"Monkey"}[fn(x) {x}];'`,
 			"unusable as hash key: FUNCTION",
 		},
+		{
+			"[1, 2] + true",
+			"type mismatch: ARRAY + BOOLEAN",
+		},
+		{
+			"10 / 0",
+			"division by zero",
+		},
 	}
 
 	for _, tt := range tests {
@@ -319,6 +447,16 @@ func TestEnclosingEnvironments(t *testing.T) {
 
 	testIntegerObject(t, testEval(input), 70)
 }
+
+func TestDeeplyEnclosingEnvironments(t *testing.T) {
+	input := `
+	let x = 1;
+	let outer = fn() { fn() { fn() { x } } };
+	outer()()()`
+
+	testIntegerObject(t, testEval(input), 1)
+}
+
 func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
@@ -518,7 +656,7 @@ func TestHashLiterals(t *testing.T) {
 	{
 		"one": 10 - 9,
 		two: 1 + 1,
-		"thr" + "ee": 6 / 2,
+		"thr" + "ee": 6 // 2,
 		4: 4,
 		true: 5,
 		false: 6
@@ -598,3 +736,1171 @@ func TestHashIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestEvalContextCancellation(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"sleep(10000)", "sleep interrupted: context deadline exceeded"},
+		// Unbounded recursion such as fn(x) { f(x) } stops at the call depth
+		// limit before any deadline, so this recursion is bounded instead,
+		// but by far more calls than the deadline allows.
+		{"let f = fn(n) { if (n > 0) { f(n - 1); f(n - 1); } }; f(40)", "evaluation stopped: context deadline exceeded"},
+		{"while (true) { 1 }", "evaluation stopped: context deadline exceeded"},
+	}
+
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		evaluated := EvalContext(ctx, program, object.NewEnvironment())
+		cancel()
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`with_timeout(1000, fn() { 1 + 2 })`, "3"},
+		{`with_timeout(duration("1s"), fn() { return 4; 5 })`, "4"},
+		{`with_timeout(20, fn() { while (true) { 1 } })`, "ERROR: with_timeout: call took longer than 20ms"},
+		{`with_timeout(20, fn() { sleep(10000) }) + 1`, "ERROR: with_timeout: call took longer than 20ms"},
+		{`with_timeout(1000, fn() { 1 / 0 })`, "ERROR: division by zero"},
+		{`with_timeout("1s", fn() { 1 })`, "ERROR: limit passed to `with_timeout` must be INTEGER or DURATION, got STRING"},
+		{`with_timeout(1000, 1)`, "ERROR: function passed to `with_timeout` must be FUNCTION, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	evaluated := testEval(`with_timeout(1, fn() { sleep(1000) })`)
+	if !errors.Is(evaluated.(*object.Error), context.DeadlineExceeded) {
+		t.Errorf("timeout is not a deadline error: %s", evaluated.Inspect())
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let calls = 0; retry(3, 1, fn() { calls += 1; if (calls < 3) { 1 / 0 } else { calls } })`, "3"},
+		{`let calls = 0; retry(3, 1, fn() { calls += 1; 1 / 0 })`, "ERROR: retry: all attempts failed: division by zero; division by zero; division by zero"},
+		{`retry(1, duration("1ms"), fn() { "ok" })`, "ok"},
+		{`retry(0, 1, fn() { 1 })`, "ERROR: attempts passed to `retry` must be positive, got 0"},
+		{`retry(2, "1", fn() { 1 })`, "ERROR: backoff passed to `retry` must be INTEGER or DURATION, got STRING"},
+		{`retry(2, 1, 1)`, "ERROR: function passed to `retry` must be FUNCTION, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	evaluated := testEval(`retry(2, 1, fn() { 1 / 0 })`)
+	if !errors.Is(evaluated.(*object.Error), object.ZeroDivisionError) {
+		t.Errorf("retry error does not wrap the attempts' errors: %s", evaluated.Inspect())
+	}
+}
+
+func TestEvents(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let e = events(); e["emit"]("start")`, "0"},
+		{
+			`let e = events(); let log = []; e["on"]("add", fn(a, b) { log = push(log, a + b) }); e["on"]("add", fn(a, b) { log = push(log, a * b) }); [e["emit"]("add", 2, 3), log]`,
+			"[2, [5, 6]]",
+		},
+		{
+			`let e = events(); let ran = false; e["on"]("x", fn() { 1 / 0 }); e["on"]("x", fn() { ran = true }); e["on"]("x", fn() { -true }); e["emit"]("x")`,
+			"ERROR: emit x: handler 0: division by zero; handler 2: unknown operator: -BOOLEAN",
+		},
+		{`events()["on"](1, fn() {})`, "ERROR: event name passed to `on` must be STRING, got INTEGER"},
+		{`events()["on"]("x", 1)`, "ERROR: handler passed to `on` must be FUNCTION, got INTEGER"},
+		{`events()["emit"]()`, "ERROR: wrong number of arguments. got=0, want at least 1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	env := object.NewEnvironment()
+	program := parser.New(lexer.New(`let e = events(); let ran = false; e["on"]("x", fn() { 1 / 0 }); e["on"]("x", fn() { ran = true }); e["emit"]("x")`)).ParseProgram()
+	Eval(program, env)
+	if ran, _ := env.Get("ran"); ran != TRUE {
+		t.Errorf("handler after a failing one did not run")
+	}
+}
+
+func TestBuiltinsReceiveContext(t *testing.T) {
+	type hostKey struct{}
+
+	env := object.NewEnvironment()
+	env.Set("request_id", &object.Builtin{
+		Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			return &object.String{Value: ctx.Value(hostKey{}).(string)}
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), hostKey{}, "req-42")
+	program := parser.New(lexer.New("request_id()")).ParseProgram()
+	evaluated := EvalContext(ctx, program, env)
+
+	str, ok := evaluated.(*object.String)
+	if !ok || str.Value != "req-42" {
+		t.Errorf("builtin did not see host value. got=%T (%+v)", evaluated, evaluated)
+	}
+}
+
+func TestErrorCategories(t *testing.T) {
+	tests := []struct {
+		input    string
+		category object.ErrorCategory
+	}{
+		{"5 + true;", object.TypeError},
+		{"-true", object.TypeError},
+		{"foobar", object.NameError},
+		{"len(1, 2)", object.ArgumentError},
+		{"len(1)", object.TypeError},
+		{"10 / 0", object.ZeroDivisionError},
+		{"5()", object.TypeError},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		err, ok := evaluated.(error)
+		if !ok {
+			t.Errorf("%q: result does not implement error. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if !errors.Is(err, tt.category) {
+			t.Errorf("%q: wrong category. want=%s, got=%s", tt.input, tt.category, err)
+		}
+
+		var errObj *object.Error
+		if !errors.As(err, &errObj) {
+			t.Errorf("%q: errors.As failed to extract *object.Error", tt.input)
+		}
+	}
+}
+
+func TestCancelledErrorUnwrapsContextError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	program := parser.New(lexer.New("sleep(1000)")).ParseProgram()
+	evaluated := EvalContext(ctx, program, object.NewEnvironment())
+
+	err, ok := evaluated.(error)
+	if !ok {
+		t.Fatalf("result does not implement error. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if !errors.Is(err, object.CancelledError) || !errors.Is(err, context.Canceled) {
+		t.Errorf("cancellation not visible through errors.Is. got=%s", err)
+	}
+}
+
+func TestInterpreterOptions(t *testing.T) {
+	var out bytes.Buffer
+	custom := &object.Builtin{
+		Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			return &object.String{Value: "custom"}
+		},
+	}
+
+	tests := []struct {
+		input    string
+		options  []Option
+		expected string
+	}{
+		{"let f = fn(n) { f(n + 1) }; f(0)", []Option{WithMaxDepth(50)}, "ERROR: maximum call depth exceeded (50)"},
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(100)", []Option{WithMaxDepth(0)}, "0"},
+		{"fn(a, b) { b }(1)", nil, "ERROR: wrong number of arguments. got=1, want=2"},
+		{"fn(a, b) { b }(1, 2, 3)", nil, "ERROR: wrong number of arguments. got=3, want=2"},
+		{"fn(a, b) { b }(1)", []Option{WithLenientCalls()}, "null"},
+		{"fn(a, b) { b }(1, 2, 3)", []Option{WithLenientCalls()}, "2"},
+		{"len(1)", []Option{WithBuiltins(map[string]*object.Builtin{"len": custom})}, "custom"},
+		{"map([1], fn(x) { x })", []Option{WithBuiltins(map[string]*object.Builtin{"map": custom})}, "custom"},
+		{"reverse([1, 2])", []Option{WithBuiltins(map[string]*object.Builtin{"map": custom})}, "[2, 1]"},
+		{`puts("to buffer")`, []Option{WithStdout(&out)}, "null"},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		evaluated := New(tt.options...).Eval(program, object.NewEnvironment())
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	if out.String() != "to buffer\n" {
+		t.Errorf("puts did not write to the configured stdout. got=%q", out.String())
+	}
+}
+
+type testHandle struct {
+	name string
+	open bool
+}
+
+func (h *testHandle) Type() object.ObjectType { return "HANDLE" }
+
+func (h *testHandle) Inspect() string { return "<handle " + h.name + ">" }
+
+func (h *testHandle) Equal(other object.Object) bool {
+	return h.name == other.(*testHandle).name
+}
+
+func (h *testHandle) Truthy() bool { return h.open }
+
+func (h *testHandle) HashKey() object.HashKey {
+	return (&object.String{Value: h.name}).HashKey()
+}
+
+func (h *testHandle) Method(name string) (object.BuiltinFunction, bool) {
+	switch name {
+	case "name":
+		return func(ctx context.Context, args ...object.Object) object.Object {
+			return &object.String{Value: h.name}
+		}, true
+	case "close":
+		return func(ctx context.Context, args ...object.Object) object.Object {
+			h.open = false
+			return NULL
+		}, true
+	}
+	return nil, false
+}
+
+func TestExtensionObjects(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a", "<handle db>"},
+		{"a == b", "true"},
+		{"a != c", "true"},
+		{"if (a) { 1 } else { 2 }", "1"},
+		{"!a", "false"},
+		{"if (closed) { 1 } else { 2 }", "2"},
+		{"!closed", "true"},
+		{`a["name"]()`, "db"},
+		{`let h = c; h["close"](); if (h) { "open" } else { "closed" }`, "closed"},
+		{`{a: 1}[b]`, "1"},
+		{`a["missing"]`, "ERROR: undefined method missing on HANDLE"},
+		{"a == 1", "ERROR: type mismatch: HANDLE + INTEGER"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Set("a", &testHandle{name: "db", open: true})
+		env.Set("b", &testHandle{name: "db", open: true})
+		env.Set("c", &testHandle{name: "cache", open: true})
+		env.Set("closed", &testHandle{name: "old"})
+
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		evaluated := Eval(program, env)
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestResolvedVariableSemantics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1; let f = fn() { let y = x; let x = 2; [y, x] }; f()", "[1, 2]"},
+		{"let f = fn(x) { let x = x + 1; x }; f(1)", "2"},
+		{"let f = fn(x, x) { x }; f(1, 2)", "2"},
+		{"let adder = fn(a) { fn(b) { fn(c) { a + b + c } } }; adder(1)(2)(3)", "6"},
+		{"let f = fn() { let g = fn(n) { if (n == 0) { 0 } else { g(n - 1) } }; g(5) }; f()", "0"},
+		{"let f = fn() { if (true) { let a = 1 } else { let b = 2 }; [a, b] }; f()", "ERROR: identifier not found: b"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMemoize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let fib = memoize(fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }); fib(90)", "2880067194370816120"},
+		{"let add = memoize(fn(a, b) { a + b }); [add(1, 2), add(1, 2), add(2, 1)]", "[3, 3, 3]"},
+		{"let f = memoize(fn(x) { x }); [f(1), f(\"1\"), f(true)]", "[1, 1, true]"},
+		{"let f = memoize(fn(xs) { len(xs) }); [f([1]), f([1, 2])]", "[1, 2]"},
+		{"let f = memoize(len); f(\"abc\")", "3"},
+		{"memoize(1)", "ERROR: argument to `memoize` must be FUNCTION, got INTEGER"},
+		{"memoize(len, 0)", "ERROR: limit passed to `memoize` must be positive, got 0"},
+		{"memoize(len, \"a\")", "ERROR: limit passed to `memoize` must be INTEGER, got STRING"},
+		{"memoize()", "ERROR: wrong number of arguments. got=0, want=1 or 2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMemoizeLimit(t *testing.T) {
+	calls := 0
+	count := &object.Builtin{Fn: func(ctx context.Context, args ...object.Object) object.Object {
+		calls++
+		return args[0]
+	}}
+
+	tests := []struct {
+		input string
+		calls int
+	}{
+		{"let f = memoize(count); f(1); f(1); f(2); f(1)", 2},
+		{"let f = memoize(count, 1); f(1); f(2); f(1)", 3},
+		{"let f = memoize(count, 2); f(1); f(2); f(1); f(3); f(1)", 3},
+		{"let f = memoize(count); f([1]); f([1])", 2},
+	}
+
+	for _, tt := range tests {
+		calls = 0
+		in := New(WithBuiltins(map[string]*object.Builtin{"count": count}))
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		in.Eval(program, object.NewEnvironment())
+
+		if calls != tt.calls {
+			t.Errorf("%q: wrong number of calls. want=%d, got=%d", tt.input, tt.calls, calls)
+		}
+	}
+}
+
+func TestNestingLimit(t *testing.T) {
+	var deep ast.Expression = &ast.IntegerLiteral{Value: 1}
+	for i := 0; i < maxNesting; i++ {
+		deep = &ast.PrefixExpression{Operator: "-", Right: deep}
+	}
+
+	tests := []struct {
+		name    string
+		program func() ast.Node
+		in      *Interpreter
+	}{
+		{
+			name: "nested expression",
+			program: func() ast.Node {
+				return &ast.Program{Statements: []ast.Statement{&ast.ExpressionStatement{Expression: deep}}}
+			},
+			in: New(),
+		},
+		{
+			name: "long operator chain",
+			program: func() ast.Node {
+				return parser.New(lexer.New("0" + strings.Repeat(" + 1", maxNesting))).ParseProgram()
+			},
+			in: New(),
+		},
+		{
+			name: "unlimited recursion",
+			program: func() ast.Node {
+				return parser.New(lexer.New("let f = fn(n) { f(n + 1) }; f(0)")).ParseProgram()
+			},
+			in: New(WithMaxDepth(0)),
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := tt.in.Eval(tt.program(), object.NewEnvironment())
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: expected an error, got=%T (%+v)", tt.name, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Category != object.RecursionError {
+			t.Errorf("%s: wrong category. want=%s, got=%s", tt.name, object.RecursionError, errObj.Category)
+		}
+
+		if tt.in.nesting != 0 {
+			t.Errorf("%s: nesting not unwound, got=%d", tt.name, tt.in.nesting)
+		}
+	}
+}
+
+func TestTimesAndDurations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`duration("1h30m")`, "1h30m0s"},
+		{`time("2024-03-01T12:00:00Z")`, "2024-03-01T12:00:00Z"},
+		{`time(0)`, "1970-01-01T00:00:00Z"},
+		{`time("01/02/2024", "01/02/2006")`, "2024-01-02T00:00:00Z"},
+		{`time("2024-03-01T12:00:00Z") + duration("2h")`, "2024-03-01T14:00:00Z"},
+		{`duration("2h") + time("2024-03-01T12:00:00Z")`, "2024-03-01T14:00:00Z"},
+		{`time("2024-03-01T00:00:00Z") - duration("24h")`, "2024-02-29T00:00:00Z"},
+		{`time("2024-03-01T12:00:00Z") - time("2024-03-01T10:30:00Z")`, "1h30m0s"},
+		{`duration("1m") - duration("15s")`, "45s"},
+		{`duration("90s") * 2`, "3m0s"},
+		{`3 * duration("1s")`, "3s"},
+		{`duration("1h") / 4`, "15m0s"},
+		{`duration("1h") // duration("7m")`, "8"},
+		{`duration("1h") / duration("8m")`, "7.5"},
+		{`duration("1h") // duration("-7m")`, "-9"},
+		{`duration("1s") < duration("1m")`, "true"},
+		{`time("2024-03-01T12:00:00+02:00") == time("2024-03-01T10:00:00Z")`, "true"},
+		{`time(0) > time(1)`, "false"},
+		{`time(0) != time(0)`, "false"},
+		{`{duration("1h"): "hour"}[duration("60m")]`, "hour"},
+		{`time("2024-03-01T12:00:00Z")["format"]("Jan 2, 2006")`, "Mar 1, 2024"},
+		{`time("2024-03-01T12:00:00Z")["weekday"]()`, "5"},
+		{`time("2024-03-01T12:00:00+02:00")["utc"]()["hour"]()`, "10"},
+		{`duration("1h30m")["minutes"]()`, "90"},
+		{`duration("1h") / 0`, "ERROR: division by zero"},
+		{`duration("1h") + 1`, "ERROR: type mismatch: DURATION + INTEGER"},
+		{`time(0) == 0`, "ERROR: type mismatch: TIME == INTEGER"},
+		{`time(0) * time(0)`, "ERROR: unknown operator: TIME * TIME"},
+		{`duration("soon")`, "ERROR: invalid duration \"soon\""},
+		{`time(true)`, "ERROR: argument to `time` must be INTEGER or STRING, got BOOLEAN"},
+		{`sleep(duration("1ms"))`, "null"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	if _, ok := testEval("now()").(*object.Time); !ok {
+		t.Errorf("now() did not return a TIME")
+	}
+}
+
+func TestLoggingBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`log_warn("disk low", {"free": 12, "mount": "/var"})`, `{"level":"WARN","msg":"disk low","free":12,"mount":"/var"}` + "\n"},
+		{`log_error("failed", {"tags": ["a", 1], "retry": true, 2: "two"})`, `{"level":"ERROR","msg":"failed","2":"two","retry":true,"tags":["a",1]}` + "\n"},
+		{`log_error("odd", {"f": fn() { 1 }})`, `{"level":"ERROR","msg":"odd","f":"fn() {\n1\n}"}` + "\n"},
+		{`log_info("filtered", {"x": 1})`, ""},
+		{`log_warn("plain")`, `{"level":"WARN","msg":"plain"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{
+			Level: slog.LevelWarn,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+
+		in := New(WithLogger(logger))
+		result := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if result != NULL {
+			t.Errorf("%s: wrong result. got=%s", tt.input, result.Inspect())
+		}
+
+		if out.String() != tt.expected {
+			t.Errorf("%s: wrong output. want=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestLoggingBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`log_info()`, "wrong number of arguments. got=0, want=1 or 2"},
+		{`log_info(1)`, "message passed to `log_info` must be STRING, got INTEGER"},
+		{`log_error("x", [1])`, "fields passed to `log_error` must be HASH, got ARRAY"},
+	}
+
+	for _, tt := range tests {
+		in := New(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+		evaluated := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestFunctionIntrospection(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(a, b) { a + b }; [arity(add), params(add), name(add)]", "[2, [a, b], add]"},
+		{"let add = fn(a, b) { a + b }; let plus = add; name(plus)", "add"},
+		{"[arity(fn() { 1 }), params(fn() { 1 }), name(fn() { 1 })]", "[0, [], null]"},
+		{"let make = fn() { fn(x) { x } }; name(make())", "null"},
+		{"[arity(len), params(len), name(len)]", "[null, null, len]"},
+		{"name(memoize(len))", "null"},
+		{"arity(1)", "ERROR: argument to `arity` must be FUNCTION, got INTEGER"},
+		{"params(\"a\")", "ERROR: argument to `params` must be FUNCTION, got STRING"},
+		{"name()", "ERROR: wrong number of arguments. got=0, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestPartialAndCurry(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(a, b, c) { a + b + c }; partial(add, 1, 2)(3)", "6"},
+		{"let add = fn(a, b, c) { a + b + c }; let f = partial(add, 1); [f(2, 3), f(10, 20)]", "[6, 31]"},
+		{"partial(push, [1])(2)", "[1, 2]"},
+		{"partial(len)(\"abc\")", "3"},
+		{"let add = fn(a, b, c) { a + b + c }; let f = curry(add); [f(1)(2)(3), f(1, 2)(3), f(1)(2, 3), f(1, 2, 3)]", "[6, 6, 6, 6]"},
+		{"let add = fn(a, b) { a + b }; let f = curry(add); let inc = f(1); [inc(1), inc(2)]", "[2, 3]"},
+		{"curry(push, 2)([1])(2)", "[1, 2]"},
+		{"curry(fn() { 7 })()", "7"},
+		{"curry(len)", "ERROR: `curry` needs the arity of a builtin function"},
+		{"curry(len, -1)", "ERROR: arity passed to `curry` must not be negative, got -1"},
+		{"curry(1)", "ERROR: argument to `curry` must be FUNCTION, got INTEGER"},
+		{"partial(1, 2)", "ERROR: argument to `partial` must be FUNCTION, got INTEGER"},
+		{"partial()", "ERROR: wrong number of arguments. got=0, want=at least 1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestCompose(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let inc = fn(x) { x + 1 }; let double = fn(x) { x * 2 }; compose(inc, double)(5)", "11"},
+		{"let inc = fn(x) { x + 1 }; let double = fn(x) { x * 2 }; compose(double, inc)(5)", "12"},
+		{"let inc = fn(x) { x + 1 }; compose(inc, len, rest)([1, 2, 3])", "3"},
+		{"compose(len)(\"ab\")", "2"},
+		{"let add = fn(a, b) { a + b }; compose(fn(x) { -x }, add)(1, 2)", "-3"},
+		{"compose(len, fn(x) { x / 0 })(1)", "ERROR: division by zero"},
+		{"compose(len, 1)", "ERROR: argument to `compose` must be FUNCTION, got INTEGER"},
+		{"compose()", "ERROR: wrong number of arguments. got=0, want=at least 1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestHashDefaults(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let count = fn(xs, acc) {
+			if (len(xs) == 0) { return acc; }
+			count(rest(xs), put(acc, first(xs), acc[first(xs)] + 1))
+		};
+		let c = count(["a", "b", "a"], hash_with_default(0));
+		[c["a"], c["b"], c["z"]]`, "[2, 1, 0]"},
+		{`let h = hash_with_default(fn() { [] });
+		let h = put(h, "x", push(h["x"], 1));
+		let h = put(h, "x", push(h["x"], 2));
+		[h["x"], h["y"]]`, "[[1, 2], []]"},
+		{`let h = hash_with_default("?", {"a": 1}); [h["a"], h["b"]]`, "[1, ?]"},
+		{`let h = {"a": 1}; let g = hash_with_default(0, h); [h["b"], g["b"]]`, "[null, 0]"},
+		{`let h = put({"a": 1}, "b", 2); [h["a"], h["b"], h["c"]]`, "[1, 2, null]"},
+		{`let h = {"a": 1}; put(h, "a", 2); h["a"]`, "1"},
+		{`hash_with_default(fn() { 1 / 0 })["a"]`, "ERROR: division by zero"},
+		{`put({}, [1], 2)`, "ERROR: unusable as hash key: ARRAY"},
+		{`put([], 1, 2)`, "ERROR: argument to `put` must be HASH, got ARRAY"},
+		{`hash_with_default(0, [])`, "ERROR: second argument to `hash_with_default` must be HASH, got ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": 2, "c": 3}`, "{b: 1, a: 2, c: 3}"},
+		{`keys({"b": 1, "a": 2, 3: 3, true: 4})`, "[b, a, 3, true]"},
+		{`values({"b": 1, "a": 2})`, "[1, 2]"},
+		{`put(put({"b": 1, "a": 2}, "c", 3), "b", 4)`, "{b: 4, a: 2, c: 3}"},
+		{`keys(hash_with_default(0, {"z": 1, "y": 2}))`, "[z, y]"},
+		{`keys({})`, "[]"},
+		{`keys([])`, "ERROR: argument to `keys` must be HASH, got ARRAY"},
+		{`values(1)`, "ERROR: argument to `values` must be HASH, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestStringArrayConversions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`chars("abc")`, "[a, b, c]"},
+		{`chars("héllo, 世界")`, "[h, é, l, l, o, ,,  , 世, 界]"},
+		{`len(chars("世界"))`, "2"},
+		{`chars("")`, "[]"},
+		{`from_chars(chars("héllo"))`, "héllo"},
+		{`from_chars(["ab", "", "c"])`, "abc"},
+		{`from_chars([])`, ""},
+		{`byte_values("é")`, "[195, 169]"},
+		{`byte_values(bytes([0, 255]))`, "[0, 255]"},
+		{`string(bytes(byte_values("hi")))`, "hi"},
+		{`chars(1)`, "ERROR: argument to `chars` must be STRING, got INTEGER"},
+		{`from_chars(["a", 1])`, "ERROR: element 1 passed to `from_chars` must be STRING, got INTEGER"},
+		{`byte_values([])`, "ERROR: argument to `byte_values` must be BYTES or STRING, got ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestFloatExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2.5", "2.5"},
+		{"-2.5", "-2.5"},
+		{"1.5 + 1.5", "3.0"},
+		{"1 + 0.5", "1.5"},
+		{"0.5 * 4", "2.0"},
+		{"7 / 2.0", "3.5"},
+		{"1.0 - 3", "-2.0"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{"1.5 < 2", "true"},
+		{"2 > 2.5", "false"},
+		{"2 == 2.0", "true"},
+		{"2.5 != 2.5", "false"},
+		{"1.0 / 0", "ERROR: division by zero"},
+		{"1.5 + \"a\"", "ERROR: type mismatch: FLOAT + STRING"},
+		{"{1.5: 1}", "ERROR: unusable as hash key: FLOAT"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestNumberParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`parse_int("42")`, "42"},
+		{`parse_int(" -17 ")`, "-17"},
+		{`parse_int("ff", 16)`, "255"},
+		{`parse_int("101", 2)`, "5"},
+		{`parse_int("zz", 36)`, "1295"},
+		{`parse_int("12a")`, "ERROR: parse_int: invalid integer \"12a\" in base 10"},
+		{`parse_int("2", 2)`, "ERROR: parse_int: invalid integer \"2\" in base 2"},
+		{`parse_int("99999999999999999999")`, "ERROR: parse_int: \"99999999999999999999\" is out of range"},
+		{`parse_int("1", 1)`, "ERROR: base passed to `parse_int` must be from 2 to 36, got 1"},
+		{`parse_int(1)`, "ERROR: argument to `parse_int` must be STRING, got INTEGER"},
+		{`parse_float("3.25")`, "3.25"},
+		{`parse_float("1e3")`, "1000.0"},
+		{`parse_float("-0.5") * 2`, "-1.0"},
+		{`parse_float("abc")`, "ERROR: parse_float: invalid number \"abc\""},
+		{`parse_float("1e999")`, "ERROR: parse_float: \"1e999\" is out of range"},
+		{`to_base(255, 16)`, "ff"},
+		{`to_base(-5, 2)`, "-101"},
+		{`to_base(0, 36)`, "0"},
+		{`parse_int(to_base(123456, 7), 7)`, "123456"},
+		{`to_base(1, 37)`, "ERROR: base passed to `to_base` must be from 2 to 36, got 37"},
+		{`to_base("1", 2)`, "ERROR: argument to `to_base` must be INTEGER, got STRING"},
+		{`to_base(1, "2")`, "ERROR: base passed to `to_base` must be INTEGER, got STRING"},
+		{`to_bin(10)`, "1010"},
+		{`to_bin(5, 8)`, "00000101"},
+		{`to_bin(-5, 4)`, "-0101"},
+		{`to_bin(255, 4)`, "11111111"},
+		{`to_hex(255)`, "ff"},
+		{`to_hex(4096, 8)`, "00001000"},
+		{`to_hex(1, 65)`, "ERROR: width passed to `to_hex` must be from 0 to 64, got 65"},
+		{`to_bin("1")`, "ERROR: argument to `to_bin` must be INTEGER, got STRING"},
+		{`bit_count(0)`, "0"},
+		{`bit_count(255)`, "8"},
+		{`bit_count(1024)`, "1"},
+		{`bit_count(-1)`, "ERROR: argument to `bit_count` must not be negative, got -1"},
+		{`num_format(1234567)`, "1234567"},
+		{`num_format(1234567, {"thousands": ","})`, "1,234,567"},
+		{`num_format(-1234.567, {"decimals": 2, "thousands": ","})`, "-1,234.57"},
+		{`num_format(999, {"decimals": 2, "thousands": ","})`, "999.00"},
+		{`num_format(1234.5, {"decimals": 0})`, "1234"},
+		{`num_format(0.25)`, "0.25"},
+		{`num_format(1234567.5, {"thousands": ".", "point": ","})`, "1.234.567,5"},
+		{`num_format("1")`, "ERROR: argument to `num_format` must be INTEGER or FLOAT, got STRING"},
+		{`num_format(1, {"decimals": -1})`, "ERROR: option decimals for `num_format` must be from 0 to 100, got -1"},
+		{`num_format(1, {"thousands": 1})`, "ERROR: option thousands for `num_format` must be STRING, got INTEGER"},
+		{`num_format(1, {"width": 8})`, "ERROR: unknown option width for `num_format`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestJSONEncode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json_encode(1)`, `1`},
+		{`json_encode("a<b")`, `"a<b"`},
+		{`json_encode([1, 2.5, true, "x"])`, `[1,2.5,true,"x"]`},
+		{`json_encode({"b": 1, "a": [1, 2]})`, `{"b":1,"a":[1,2]}`},
+		{`json_encode({"b": 1, "a": {"d": 2, "c": 3}}, {"sort_keys": true})`, `{"a":{"c":3,"d":2},"b":1}`},
+		{`json_encode({"b": 1, "a": [1]}, {"indent": 2})`, "{\n  \"b\": 1,\n  \"a\": [\n    1\n  ]\n}"},
+		{"json_encode({\"a\": 1}, {\"indent\": \"\t\", \"sort_keys\": true})", "{\n\t\"a\": 1\n}"},
+		{`json_encode(1, {"indent": "--"})`, "ERROR: option indent for `json_encode` must be whitespace, got \"--\""},
+		{`json_encode({1: 2, "1": 3})`, "ERROR: json_encode: more than one key converts to \"1\""},
+		{`json_encode({"a": {true: 1, "true": 2}})`, "ERROR: json_encode: a: more than one key converts to \"true\""},
+		{`json_encode({"a": fn(x) { x }})`, "ERROR: json_encode: a: cannot convert FUNCTION to a Go value"},
+		{`json_encode(1, {"indent": true})`, "ERROR: option indent for `json_encode` must be INTEGER or STRING, got BOOLEAN"},
+		{`json_encode(1, {"sort_keys": 1})`, "ERROR: option sort_keys for `json_encode` must be BOOLEAN, got INTEGER"},
+		{`json_encode(1, {"pretty": true})`, "ERROR: unknown option pretty for `json_encode`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestGoSprintf(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`gofmt_sprintf("plain")`, "plain"},
+		{`gofmt_sprintf("%d-%s-%t", 42, "x", true)`, "42-x-true"},
+		{`gofmt_sprintf("%6.2f|%-4d|%x", 3.14159, 7, 255)`, "  3.14|7   |ff"},
+		{`gofmt_sprintf("%q %v", "hi", [1, "a"])`, `"hi" [1 a]`},
+		{`gofmt_sprintf("%v", {"a": 1})`, "map[a:1]"},
+		{`gofmt_sprintf("%v", duration("90s"))`, "1m30s"},
+		{`gofmt_sprintf("%c%c", 104, 105)`, "hi"},
+		{`gofmt_sprintf("100%%!")`, "100%!"},
+		{`gofmt_sprintf("%s", "%!d")`, "%!d"},
+		{`gofmt_sprintf("%d", "x")`, `ERROR: gofmt_sprintf: format "%d" does not match its arguments: %!d(string=x)`},
+		{`gofmt_sprintf("%d %d", 1)`, `ERROR: gofmt_sprintf: format "%d %d" does not match its arguments: 1 %!d(MISSING)`},
+		{`gofmt_sprintf("%d", 1, 2)`, `ERROR: gofmt_sprintf: format "%d" does not match its arguments: 1%!(EXTRA int64=2)`},
+		{`gofmt_sprintf("%v", fn(x) { x })`, "ERROR: argument 1 passed to `gofmt_sprintf`: cannot convert FUNCTION to a Go value"},
+		{`gofmt_sprintf(1)`, "ERROR: format passed to `gofmt_sprintf` must be STRING, got INTEGER"},
+		{`gofmt_sprintf()`, "ERROR: wrong number of arguments. got=0, want=at least 1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`diff([1, {"a": 2}], [1, {"a": 2}])`, `[]`},
+		{`diff(1, 1.0)`, `[{op: changed, path: [], old: 1, new: 1.0}]`},
+		{`diff([1, 2, 3], [1, 5])`, `[{op: changed, path: [1], old: 2, new: 5}, {op: removed, path: [2], old: 3}]`},
+		{`diff({"a": 1, "b": [1]}, {"b": [1, 2], "c": true})`, `[{op: removed, path: [a], old: 1}, {op: added, path: [b, 1], new: 2}, {op: added, path: [c], new: true}]`},
+		{`diff({"a": [1]}, {"a": "x"})`, `[{op: changed, path: [a], old: [1], new: x}]`},
+		{`diff("a", "a")`, `[]`},
+		{`diff("one
+two
+three", "one
+2
+three
+four")`, `[{op: removed, line: 2, text: two}, {op: added, line: 2, text: 2}, {op: added, line: 4, text: four}]`},
+		{`diff(1)`, "ERROR: wrong number of arguments. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestReductions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sum([1, 2, 3])", "6"},
+		{"sum([1, 2.5])", "3.5"},
+		{"sum([])", "0"},
+		{"sum([], 0.0)", "0.0"},
+		{"sum([1, 2], 10)", "13"},
+		{"product([2, 3, 4])", "24"},
+		{"product([])", "1"},
+		{"product([2, 0.5])", "1.0"},
+		{"min([3, 1, 2])", "1"},
+		{"max([3, 1, 2])", "3"},
+		{"min([2, 1.5, 3])", "1.5"},
+		{"max([1, 1.0])", "1"},
+		{`min(["pear", "apple", "fig"])`, "apple"},
+		{`max(["pear", "apple", "fig"])`, "pear"},
+		{"min([], 0)", "0"},
+		{"max([5], 0)", "5"},
+		{"min([])", "ERROR: `min` of an empty array"},
+		{`max([1, "a"])`, "ERROR: element 1 passed to `max` cannot be compared with INTEGER: STRING"},
+		{`min([true])`, "ERROR: element 0 passed to `min` must be INTEGER, FLOAT or STRING, got BOOLEAN"},
+		{`sum([1, "a"])`, "ERROR: element 1 passed to `sum` must be INTEGER or FLOAT, got STRING"},
+		{`sum([1], "a")`, "ERROR: start passed to `sum` must be INTEGER or FLOAT, got STRING"},
+		{"product(1)", "ERROR: argument to `product` must be ARRAY, got INTEGER"},
+		{"sum()", "ERROR: wrong number of arguments. got=0, want=1 or 2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSearchBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"any([1, 2, 3], fn(x) { x > 2 })", "true"},
+		{"any([1, 2, 3], fn(x) { x > 3 })", "false"},
+		{"any([], fn(x) { true })", "false"},
+		{"all([1, 2, 3], fn(x) { x > 0 })", "true"},
+		{"all([1, 2, 3], fn(x) { x > 1 })", "false"},
+		{"all([], fn(x) { false })", "true"},
+		{"find([1, 2, 3, 4], fn(x) { x > 2 })", "3"},
+		{"find([1, 2], fn(x) { x > 2 })", "null"},
+		{"find_index([5, 6], fn(x) { x == 6 })", "1"},
+		{"find_index([5, 6], fn(x) { x == 7 })", "-1"},
+		{"any([[1], []], len)", "true"},
+		{"any([1, 0, 2], fn(x) { 10 / x > 100 })", "ERROR: division by zero"},
+		{"any([0, 1], fn(x) { if (x == 0) { true } else { 1 / 0 } })", "true"},
+		{"all([0, 1], fn(x) { if (x == 0) { false } else { 1 / 0 } })", "false"},
+		{"find(1, len)", "ERROR: argument to `find` must be ARRAY, got INTEGER"},
+		{"all([1], 1)", "ERROR: predicate passed to `all` must be FUNCTION, got INTEGER"},
+		{"find_index([1])", "ERROR: wrong number of arguments. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestInOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"3 in [1, 2, 3]", "true"},
+		{"4 in [1, 2, 3]", "false"},
+		{"1000 in [999 + 1]", "true"},
+		{"2.0 in [1, 2]", "true"},
+		{`"b" in ["a", "b"]`, "true"},
+		{`[1, 2] in [[1], [1, 2]]`, "true"},
+		{`true in [1, true]`, "true"},
+		{`1 in []`, "false"},
+		{`"key" in {"key": 1}`, "true"},
+		{`"other" in {"key": 1}`, "false"},
+		{`1 in {1: "a"}`, "true"},
+		{`"sub" in "a substring"`, "true"},
+		{`"" in "abc"`, "true"},
+		{`"x" in "abc"`, "false"},
+		{`bytes("b") in bytes("abc")`, "true"},
+		{`!(1 in [1])`, "false"},
+		{`1 + 1 in [2]`, "true"},
+		{`1 in [1] == true`, "true"},
+		{`[1] in {}`, "ERROR: unusable as hash key: ARRAY"},
+		{`1 in "abc"`, "ERROR: left operand of `in` STRING must be STRING, got INTEGER"},
+		{`1 in 2`, "ERROR: unknown operator: INTEGER in INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestPowerOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2 ** 10", "1024"},
+		{"-2 ** 2", "-4"},
+		{"(-2) ** 3", "-8"},
+		{"2 ** 3 ** 2", "512"},
+		{"5 ** 0", "1"},
+		{"0 ** 0", "1"},
+		{"2 ** -1", "0.5"},
+		{"2 ** 62", "4611686018427387904"},
+		{"-2 ** 63", "ERROR: integer overflow in 2 ** 63"},
+		{"(-2) ** 63", "-9223372036854775808"},
+		{"3 ** 40", "ERROR: integer overflow in 3 ** 40"},
+		{"1 ** 9223372036854775807", "1"},
+		{"(-1) ** 9223372036854775807", "-1"},
+		{"2.0 ** 0.5", "1.4142135623730951"},
+		{"4 ** 0.5", "2.0"},
+		{"\"a\" ** 2", "ERROR: type mismatch: STRING + INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestDivision(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"7 // 2", "3"},
+		{"-7 // 2", "-4"},
+		{"7 // -2", "-4"},
+		{"-7 // -2", "3"},
+		{"-6 // 2", "-3"},
+		{"0 // -5", "0"},
+		{"7 / 2", "3.5"},
+		{"-7 / 2", "-3.5"},
+		{"6 / 3", "2.0"},
+		{"7.5 / 2.5", "3.0"},
+		{"7.0 / 2", "3.5"},
+		{"7.5 // 2", "3.0"},
+		{"-7.5 // 2", "-4.0"},
+		{"1 // 0", "ERROR: division by zero"},
+		{"1 / 0", "ERROR: division by zero"},
+		{"1.5 // 0", "ERROR: division by zero"},
+		{"let x = 9; x //= 2; x", "4"},
+		{"let x = 9; x /= 2; x", "4.5"},
+		{"\"a\" // 2", "ERROR: type mismatch: STRING + INTEGER"},
+		{"duration(\"1h\") // 2", "ERROR: type mismatch: DURATION // INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestTypeTests(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 is INTEGER", "true"},
+		{"1 is STRING", "false"},
+		{"\"a\" is STRING", "true"},
+		{"1.5 is FLOAT", "true"},
+		{"[] is ARRAY && {} is HASH", "true"},
+		{"if (true) { 1 } is NULL", "false"},
+		{"if (false) { 1 } is NULL", "true"},
+		{"fn() {} is FUNCTION", "true"},
+		{"len is BUILTIN", "true"},
+		{"let INTEGER = 5; \"x\" is INTEGER", "false"},
+		{"(1 / 0) is INTEGER", "ERROR: division by zero"},
+		{"type(1)", "INTEGER"},
+		{"type(\"a\")", "STRING"},
+		{"type(duration(\"1s\"))", "DURATION"},
+		{"let describe = fn(x) { if (x is INTEGER) { x + 1 } else { if (x is STRING) { x + \"!\" } else { type(x) } } }; [describe(1), describe(\"hi\"), describe([])]", "[2, hi!, ARRAY]"},
+		{"type()", "ERROR: wrong number of arguments. got=0, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1; x = 2; x", "2"},
+		{"let x = 1; x = x + 1", "2"},
+		{"let x = 1; let y = 1; x = y = 5; x + y", "10"},
+		{"let x = 10; x += 5; x", "15"},
+		{"let x = 10; x -= 5; x", "5"},
+		{"let x = 10; x *= 5; x", "50"},
+		{"let x = 10; x /= 5; x", "2.0"},
+		{"let x = 3; x **= 2; x **= 2; x", "81"},
+		{"let x = 1.5; x **= 2; x", "2.25"},
+		{"let s = \"a\"; s += \"b\"; s", "ab"},
+		{"let x = 1; let f = fn() { x = 2 }; f(); x", "2"},
+		{"let counter = fn() { let n = 0; fn() { n += 1 } }; let c = counter(); c(); c(); c()", "3"},
+		{"let f = fn(n) { n *= 2; n }; f(21)", "42"},
+		{"let x = 1; let f = fn() { let x = 5; x = 6; x }; f() + x", "7"},
+		{"y = 1", "ERROR: cannot assign to undefined variable y"},
+		{"y += 1", "ERROR: cannot assign to undefined variable y"},
+		{"len = 1", "ERROR: cannot assign to undefined variable len"},
+		{"let x = 1; x /= 0", "ERROR: division by zero"},
+		{"let x = 1; x += \"a\"; x", "ERROR: type mismatch: INTEGER + STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestComparisonsAndLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 <= 1", "true"},
+		{"2 <= 1", "false"},
+		{"1 >= 2", "false"},
+		{"1.5 >= 1", "true"},
+		{"duration(\"1s\") <= duration(\"2s\")", "true"},
+		{"time(0) >= time(1)", "false"},
+		{"true && false", "false"},
+		{"true && 1", "true"},
+		{"0 || false", "true"},
+		{"!true || false", "false"},
+		{"false || \"x\"", "true"},
+		{"false && 1 / 0", "false"},
+		{"true || 1 / 0", "true"},
+		{"true && 1 / 0", "ERROR: division by zero"},
+		{"let x = 5; 0 <= x < 10", "true"},
+		{"let x = 10; 0 <= x < 10", "false"},
+		{"let x = -1; 0 <= x < 10", "false"},
+		{"1 < 2 < 3 < 4", "true"},
+		{"1 < 3 > 2", "true"},
+		{"3 > 2 > 1 >= 1", "true"},
+		{"let f = fn() { 5 }; 1 < f() < 10", "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestIntrospectionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`call_stack()`, "[]"},
+		{
+			`let inner = fn(x) { call_stack() }; let outer = fn() { inner(1) }; outer()`,
+			"[{name: inner, call: inner(1), line: 1, column: 61}, {name: outer, call: outer(), line: 1, column: 73}]",
+		},
+		{"let f = fn() { call_stack()[0] };\n[f(),\n  f()]", "[{name: f, call: f(), line: 2, column: 3}, {name: f, call: f(), line: 3, column: 4}]"},
+		{`fn() { call_stack() }()`, "[{name: null, call: fn () call_stack()(), line: 1, column: 22}]"},
+		{`let f = fn() { call_stack() }; memoize(f)()`, "[{name: f, call: null, line: null, column: null}]"},
+		{`let f = fn(a, b) { let c = a + b; locals() }; f(1, 2)`, "{a: 1, b: 2, c: 3}"},
+		{`let b = 2; let a = 1; locals()`, "{a: 1, b: 2}"},
+		{`let f = fn() { locals() }; f()`, "{}"},
+		{`locals(1)`, "ERROR: wrong number of arguments. got=1, want=0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
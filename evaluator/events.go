@@ -0,0 +1,97 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monkey/object"
+	"strings"
+)
+
+// eventEmitter holds the handlers registered on the hash returned by
+// events, in registration order for each event name.
+type eventEmitter struct {
+	in       *Interpreter
+	handlers map[string][]object.Object
+}
+
+// builtinEvents implements events(), which returns a hash of two functions:
+// on(name, fn) registers fn to be called for the event name, and
+// emit(name, args...) calls each handler for name with args.
+func (in *Interpreter) builtinEvents(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	emitter := &eventEmitter{in: in, handlers: make(map[string][]object.Object)}
+
+	result := &object.Hash{}
+	for _, method := range []struct {
+		name string
+		fn   object.BuiltinFunction
+	}{
+		{"on", emitter.on},
+		{"emit", emitter.emit},
+	} {
+		key := object.NewString(method.name)
+		result.Set(key.HashKey(), object.HashPair{Key: key, Value: &object.Builtin{Fn: method.fn}})
+	}
+
+	return result
+}
+
+func (e *eventEmitter) on(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "event name passed to `on` must be STRING, got %s", args[0].Type())
+	}
+	if !isCallable(args[1]) {
+		return newError(object.TypeError, "handler passed to `on` must be FUNCTION, got %s", args[1].Type())
+	}
+
+	e.handlers[name.Value] = append(e.handlers[name.Value], args[1])
+	return NULL
+}
+
+// emit calls the handlers one after another and returns how many were
+// called. A failing handler does not stop the others; once all have run,
+// emit fails with the message of each handler that did.
+func (e *eventEmitter) emit(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want at least 1", len(args))
+	}
+
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "event name passed to `emit` must be STRING, got %s", args[0].Type())
+	}
+
+	// Handlers registered while emitting wait for the next emit.
+	handlers := e.handlers[name.Value]
+
+	var failures []error
+	var messages []string
+	for i, handler := range handlers {
+		result := e.in.applyFunction(ctx, handler, args[1:])
+		errObj, ok := result.(*object.Error)
+		if !ok {
+			continue
+		}
+		if errObj.Category == object.CancelledError {
+			return errObj
+		}
+
+		failures = append(failures, errObj)
+		messages = append(messages, fmt.Sprintf("handler %d: %s", i, errObj.Message))
+	}
+
+	if len(failures) != 0 {
+		return newCausedError(object.RuntimeError, errors.Join(failures...), "emit %s: %s", name.Value, strings.Join(messages, "; "))
+	}
+
+	return object.NewInteger(int64(len(handlers)))
+}
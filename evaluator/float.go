@@ -0,0 +1,69 @@
+package evaluator
+
+import (
+	"math"
+	"monkey/object"
+	"monkey/token"
+)
+
+// isFloatOperation reports whether an infix expression mixes floats with
+// numbers, in which case integers are converted to floats.
+func isFloatOperation(left, right object.Object) bool {
+	if left.Type() != object.FLOAT_OBJ && right.Type() != object.FLOAT_OBJ {
+		return false
+	}
+	_, leftOk := toFloat(left)
+	_, rightOk := toFloat(right)
+	return leftOk && rightOk
+}
+
+func toFloat(obj object.Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *object.Float:
+		return obj.Value, true
+	case *object.Integer:
+		return float64(obj.Value), true
+	default:
+		return 0, false
+	}
+}
+
+func evalFloatInfixExpression(left, right object.Object, operator string) object.Object {
+	leftValue, _ := toFloat(left)
+	rightValue, _ := toFloat(right)
+
+	switch operator {
+	case token.PLUS:
+		return &object.Float{Value: leftValue + rightValue}
+	case token.MINUS:
+		return &object.Float{Value: leftValue - rightValue}
+	case token.ASTERISK:
+		return &object.Float{Value: leftValue * rightValue}
+	case token.POWER:
+		return &object.Float{Value: math.Pow(leftValue, rightValue)}
+	case token.SLASH:
+		if rightValue == 0 {
+			return newError(object.ZeroDivisionError, "division by zero")
+		}
+		return &object.Float{Value: leftValue / rightValue}
+	case token.FLOOR_SLASH:
+		if rightValue == 0 {
+			return newError(object.ZeroDivisionError, "division by zero")
+		}
+		return &object.Float{Value: math.Floor(leftValue / rightValue)}
+	case token.EQ:
+		return nativeBoolToBooleanObject(leftValue == rightValue)
+	case token.NOT_EQ:
+		return nativeBoolToBooleanObject(leftValue != rightValue)
+	case token.LT:
+		return nativeBoolToBooleanObject(leftValue < rightValue)
+	case token.GT:
+		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case token.LT_EQ:
+		return nativeBoolToBooleanObject(leftValue <= rightValue)
+	case token.GT_EQ:
+		return nativeBoolToBooleanObject(leftValue >= rightValue)
+	default:
+		return newError(object.TypeError, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
@@ -0,0 +1,69 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+)
+
+// builtinArity returns how many parameters a function declares, or null for
+// builtins, which accept variable arguments.
+func builtinArity(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch fn := args[0].(type) {
+	case *object.Function:
+		return object.NewInteger(int64(len(fn.Parameters)))
+	case *object.Builtin:
+		return NULL
+	default:
+		return newError(object.TypeError, "argument to `arity` must be FUNCTION, got %s", args[0].Type())
+	}
+}
+
+// builtinParams returns the parameter names of a function, or null for
+// builtins.
+func builtinParams(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch fn := args[0].(type) {
+	case *object.Function:
+		names := make([]object.Object, len(fn.Parameters))
+		for i, param := range fn.Parameters {
+			names[i] = object.NewString(param.Value)
+		}
+		return &object.Array{Elements: names}
+	case *object.Builtin:
+		return NULL
+	default:
+		return newError(object.TypeError, "argument to `params` must be FUNCTION, got %s", args[0].Type())
+	}
+}
+
+// builtinName returns the name a function was let-bound to or a builtin is
+// registered under, or null for anonymous functions.
+func (in *Interpreter) builtinName(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch fn := args[0].(type) {
+	case *object.Function:
+		if fn.Name == "" {
+			return NULL
+		}
+		return object.NewString(fn.Name)
+	case *object.Builtin:
+		for name, builtin := range in.builtins {
+			if builtin == fn {
+				return object.NewString(name)
+			}
+		}
+		return NULL
+	default:
+		return newError(object.TypeError, "argument to `name` must be FUNCTION, got %s", args[0].Type())
+	}
+}
@@ -0,0 +1,62 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"monkey/object"
+	"slices"
+)
+
+// handle is an object that holds a host resource, such as a connection,
+// until it is closed.
+type handle interface {
+	object.Object
+	Close() error
+}
+
+// open records h as opened by the script, so Close can release it if the
+// script never does.
+func (in *Interpreter) open(h handle) object.Object {
+	in.handles = append(in.handles, h)
+	return h
+}
+
+// closeHandle closes h for builtin and forgets it.
+func (in *Interpreter) closeHandle(builtin string, h handle) object.Object {
+	in.handles = slices.DeleteFunc(in.handles, func(open handle) bool { return open == h })
+	if err := h.Close(); err != nil {
+		return newError(object.RuntimeError, "%s: %s", builtin, err)
+	}
+
+	return NULL
+}
+
+// Close closes the connections and other handles that scripts run by the
+// interpreter opened and did not close, and unregisters their signal
+// handlers. Hosts should call it once they are done with the interpreter,
+// so that buggy scripts cannot exhaust the host's file descriptors.
+func (in *Interpreter) Close() error {
+	in.resetSignals()
+
+	var errs []error
+	for _, h := range in.handles {
+		errs = append(errs, h.Close())
+	}
+	in.handles = nil
+
+	return errors.Join(errs...)
+}
+
+// builtinOpenHandles implements open_handles(), the handles opened and not
+// yet closed, oldest first.
+func (in *Interpreter) builtinOpenHandles(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	elements := make([]object.Object, len(in.handles))
+	for i, h := range in.handles {
+		elements[i] = h
+	}
+	return &object.Array{Elements: elements}
+}
@@ -0,0 +1,89 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+)
+
+// builtinHashWithDefault returns a copy of a hash, or a new empty one, whose
+// missing keys index to a default value.
+func builtinHashWithDefault(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	result := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+	if len(args) == 2 {
+		hash, ok := args[1].(*object.Hash)
+		if !ok {
+			return newError(object.TypeError, "second argument to `hash_with_default` must be HASH, got %s", args[1].Type())
+		}
+		result = hash.Clone()
+	}
+	result.Default = args[0]
+
+	return result
+}
+
+// builtinPut returns a copy of a hash with key set to value. The copy keeps
+// the hash's default.
+func builtinPut(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=3", len(args))
+	}
+
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return newError(object.TypeError, "argument to `put` must be HASH, got %s", args[0].Type())
+	}
+
+	key, ok := args[1].(object.Hashable)
+	if !ok {
+		return newError(object.TypeError, "unusable as hash key: %s", args[1].Type())
+	}
+
+	result := hash.Clone()
+	result.Set(key.HashKey(), object.HashPair{Key: args[1], Value: args[2]})
+
+	return result
+}
+
+// builtinKeys returns the keys of a hash in insertion order.
+func builtinKeys(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return newError(object.TypeError, "argument to `keys` must be HASH, got %s", args[0].Type())
+	}
+
+	pairs := hash.Ordered()
+	keys := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		keys[i] = pair.Key
+	}
+
+	return &object.Array{Elements: keys}
+}
+
+// builtinValues returns the values of a hash in the order of their keys.
+func builtinValues(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return newError(object.TypeError, "argument to `values` must be HASH, got %s", args[0].Type())
+	}
+
+	pairs := hash.Ordered()
+	values := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		values[i] = pair.Value
+	}
+
+	return &object.Array{Elements: values}
+}
@@ -0,0 +1,224 @@
+package evaluator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"monkey/object"
+	"os"
+	"strings"
+)
+
+// WithStdin sets where prompt and confirm read their answers. A
+// *bufio.Reader is read from directly, so a host that also reads from it
+// loses no buffered input; the host is then taken to handle echoing, as
+// the REPL does, and answers are not echoed. A host sharing the reader must
+// not read from it while a prompt cancelled mid-read may still be waiting
+// for its line.
+func WithStdin(r io.Reader) Option {
+	return func(in *Interpreter) {
+		if br, ok := r.(*bufio.Reader); ok {
+			in.stdin, in.interactive = br, true
+			return
+		}
+		in.stdin, in.interactive = bufio.NewReader(r), isTerminal(r)
+	}
+}
+
+// isTerminal reports whether stream is a character device such as a TTY.
+func isTerminal(stream any) bool {
+	f, ok := stream.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ask writes question and reads one line of answer without its line
+// ending. It reports false once the input is exhausted. When the input is
+// not a terminal the answer is echoed, so output reads the same whether a
+// script is driven by a user or by piped input. Answers are recorded and
+// replayed like other nondeterministic values, with the input left unread
+// when replaying.
+func (in *Interpreter) ask(ctx context.Context, builtin, question string) (string, bool, *object.Error) {
+	if err := ctx.Err(); err != nil {
+		return "", false, newCancelledError(builtin, err)
+	}
+
+	fmt.Fprint(in.stdout, question)
+	// The answer is recorded as read, line ending included, so that the
+	// empty string marks the end of input.
+	line, errObj := in.nondeterministic(builtin, func() (string, *object.Error) {
+		line, err := in.readLine(ctx)
+		switch {
+		case err == nil || err == io.EOF:
+			return line, nil
+		case err == ctx.Err():
+			return "", newCancelledError(builtin, err)
+		default:
+			return "", newCausedError(object.RuntimeError, err, "%s: %s", builtin, err)
+		}
+	})
+	if errObj != nil {
+		return "", false, errObj
+	}
+	if line == "" {
+		if !in.interactive {
+			fmt.Fprintln(in.stdout)
+		}
+		return "", false, nil
+	}
+
+	line = strings.TrimRight(line, "\r\n")
+	if !in.interactive {
+		fmt.Fprintln(in.stdout, line)
+	}
+	return line, true, nil
+}
+
+// lineRead is the outcome of reading a line of input.
+type lineRead struct {
+	line string
+	err  error
+}
+
+// readLine reads a line of input, giving up with ctx's error once ctx is
+// done. A read cannot be interrupted, so one given up on keeps waiting in
+// the background and the next call returns its line.
+func (in *Interpreter) readLine(ctx context.Context) (string, error) {
+	if in.pendingRead == nil {
+		read := make(chan lineRead, 1)
+		go func(stdin *bufio.Reader) {
+			line, err := stdin.ReadString('\n')
+			read <- lineRead{line: line, err: err}
+		}(in.stdin)
+		in.pendingRead = read
+	}
+
+	select {
+	case r := <-in.pendingRead:
+		in.pendingRead = nil
+		return r.line, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// builtinPrompt implements prompt(question) and prompt(question, options),
+// returning the line the user answers with, or null at the end of input.
+// The "default" option is returned for an empty answer or at the end of
+// input; the "validate" option is a function called with each answer that
+// accepts it by returning a truthy value and rejects it by returning false
+// or a string explaining why, after which the question is asked again.
+func (in *Interpreter) builtinPrompt(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	question, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "question passed to `prompt` must be STRING, got %s", args[0].Type())
+	}
+
+	var fallback, validate object.Object = NULL, nil
+	if len(args) == 2 {
+		options, ok := args[1].(*object.Hash)
+		if !ok {
+			return newError(object.TypeError, "options passed to `prompt` must be HASH, got %s", args[1].Type())
+		}
+		for _, pair := range options.Ordered() {
+			switch key := pair.Key.Inspect(); key {
+			case "default":
+				if _, ok := pair.Value.(*object.String); !ok {
+					return newError(object.TypeError, "option default for `prompt` must be STRING, got %s", pair.Value.Type())
+				}
+				fallback = pair.Value
+			case "validate":
+				if !isCallable(pair.Value) {
+					return newError(object.TypeError, "option validate for `prompt` must be FUNCTION, got %s", pair.Value.Type())
+				}
+				validate = pair.Value
+			default:
+				return newError(object.ArgumentError, "unknown option %s for `prompt`", key)
+			}
+		}
+	}
+
+	for {
+		line, ok, errObj := in.ask(ctx, "prompt", question.Value)
+		if errObj != nil {
+			return errObj
+		}
+		if !ok {
+			return fallback
+		}
+
+		var answer object.Object = object.NewString(line)
+		if line == "" && fallback != NULL {
+			answer = fallback
+		}
+		if validate == nil {
+			return answer
+		}
+
+		verdict := in.applyFunction(ctx, validate, []object.Object{answer})
+		switch verdict := verdict.(type) {
+		case *object.Error:
+			return verdict
+		case *object.String:
+			fmt.Fprintln(in.stdout, verdict.Value)
+		default:
+			if IsTruthy(verdict) {
+				return answer
+			}
+		}
+	}
+}
+
+// builtinConfirm implements confirm(question) and confirm(question,
+// default), asking a yes-or-no question until it is answered with y, yes,
+// n or no in any case. An empty answer or the end of input gives default,
+// or false without one.
+func (in *Interpreter) builtinConfirm(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	question, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "question passed to `confirm` must be STRING, got %s", args[0].Type())
+	}
+
+	fallback, choices := false, " [y/N] "
+	if len(args) == 2 {
+		b, ok := args[1].(*object.Boolean)
+		if !ok {
+			return newError(object.TypeError, "default passed to `confirm` must be BOOLEAN, got %s", args[1].Type())
+		}
+		if b.Value {
+			fallback, choices = true, " [Y/n] "
+		}
+	}
+
+	for {
+		line, ok, errObj := in.ask(ctx, "confirm", question.Value+choices)
+		if errObj != nil {
+			return errObj
+		}
+		if !ok {
+			return nativeBoolToBooleanObject(fallback)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "":
+			return nativeBoolToBooleanObject(fallback)
+		case "y", "yes":
+			return TRUE
+		case "n", "no":
+			return FALSE
+		}
+		fmt.Fprintln(in.stdout, "Please answer y or n.")
+	}
+}
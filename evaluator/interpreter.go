@@ -0,0 +1,337 @@
+package evaluator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"monkey/ast"
+	"monkey/diagnostic"
+	"monkey/object"
+	"os"
+	"runtime/debug"
+)
+
+// DefaultMaxDepth is the call depth an interpreter allows unless configured
+// otherwise with WithMaxDepth.
+const DefaultMaxDepth = 5000
+
+const (
+	// maxInternedStringLength and maxInternedStrings bound the table of
+	// string literals an interpreter reuses instead of reallocating.
+	maxInternedStringLength = 64
+	maxInternedStrings      = 4096
+
+	// maxConstants bounds the table of arrays and hashes built from
+	// literals that an interpreter reuses across evaluations.
+	maxConstants = 4096
+
+	// maxNesting bounds how deeply evaluation may recurse on the Go stack.
+	// It leaves room for DefaultMaxDepth calls of ordinary functions, which
+	// nest about seven levels each, while using about a tenth of Go's
+	// default stack limit even under the race detector, so growth in the
+	// evaluator's frames cannot turn the guard into a crash.
+	maxNesting = 50000
+)
+
+// Interpreter evaluates Monkey programs with its own configuration and
+// builtins. Separately created interpreters share no mutable state, but a
+// single Interpreter must not be used by several goroutines at once.
+type Interpreter struct {
+	maxDepth     int
+	lenient      bool
+	stdout       io.Writer
+	stderr       io.Writer
+	stdin        *bufio.Reader
+	pendingRead  chan lineRead
+	interactive  bool
+	color        bool
+	format       object.FormatOptions
+	logger       *slog.Logger
+	builtins     map[string]*object.Builtin
+	signals      *signalState
+	capabilities map[Capability]bool
+	trace        func(TraceEvent)
+	diagnose     func(diagnostic.Diagnostic)
+	recording    *ReplayLog
+	replay       *ReplayLog
+	metrics      Metrics
+	auditLog     *AuditLog
+	catalog      diagnostic.Catalog
+	modules      map[string]ModuleProvider
+	stats        *Stats
+	noPrelude    bool
+	prelude      *object.Environment
+
+	depth       int
+	nesting     int
+	strings     map[string]*object.String
+	integers    object.IntegerArena
+	constants   map[ast.Expression]object.Object
+	returnValue object.Object
+	branchLabel string
+	replayed    int
+	handles     []handle
+	frames      []frame
+	callSite    *ast.CallExpression
+	globals     *object.Environment
+	timers      []*Timer
+	stubs       []stub
+	loaded      map[string]map[string]*object.Builtin
+	diagnosed   map[ast.Node]bool
+}
+
+// Option configures an Interpreter created with New.
+type Option func(*Interpreter)
+
+// WithMaxDepth limits how deeply function calls may nest before evaluation
+// fails with a RecursionError. Zero or less disables the limit.
+func WithMaxDepth(depth int) Option {
+	return func(in *Interpreter) {
+		in.maxDepth = depth
+	}
+}
+
+// WithLenientCalls lets functions be called with the wrong number of
+// arguments: missing parameters are bound to null and extra arguments are
+// ignored. By default it is an ArgumentError.
+func WithLenientCalls() Option {
+	return func(in *Interpreter) {
+		in.lenient = true
+	}
+}
+
+// Streams are the standard streams programs use through an interpreter:
+// prompt and confirm read Stdin, puts and the other printing builtins write
+// Stdout, and the default logger writes Stderr. They default to the
+// process's own.
+type Streams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// WithStreams sets the interpreter's standard streams, leaving those that
+// are nil in s as they were, so embedders and tests can capture or feed
+// all of a program's I/O.
+func WithStreams(s Streams) Option {
+	return func(in *Interpreter) {
+		if s.Stdin != nil {
+			WithStdin(s.Stdin)(in)
+		}
+		if s.Stdout != nil {
+			in.stdout = s.Stdout
+		}
+		if s.Stderr != nil {
+			in.stderr = s.Stderr
+		}
+	}
+}
+
+// WithStdout sets where puts and other printing builtins write.
+func WithStdout(w io.Writer) Option {
+	return WithStreams(Streams{Stdout: w})
+}
+
+// WithFormat sets how puts lays out the arrays and hashes it prints. By
+// default they are printed on a single line.
+func WithFormat(opts object.FormatOptions) Option {
+	return func(in *Interpreter) {
+		in.format = opts
+	}
+}
+
+// WithLogger sets where the log_info, log_warn and log_error builtins
+// write. The logger's handler decides the format and which levels are kept.
+// By default records of level info and above go to Streams.Stderr as text.
+func WithLogger(logger *slog.Logger) Option {
+	return func(in *Interpreter) {
+		in.logger = logger
+	}
+}
+
+// WithDiagnostics reports problems noticed while evaluating, such as
+// integer divisions that discard a remainder, to fn. Each expression is
+// reported at most once per interpreter.
+func WithDiagnostics(fn func(diagnostic.Diagnostic)) Option {
+	return func(in *Interpreter) {
+		in.diagnose = fn
+		in.diagnosed = make(map[ast.Node]bool)
+	}
+}
+
+// WithCatalog words the messages of the errors that evaluation returns, and
+// of the diagnostics it reports, according to c.
+func WithCatalog(c diagnostic.Catalog) Option {
+	return func(in *Interpreter) {
+		in.catalog = c
+	}
+}
+
+// WithBuiltins adds host-supplied builtins, replacing any standard builtin
+// or prelude function with the same name. Their results pass through object.Canonical.
+func WithBuiltins(builtins map[string]*object.Builtin) Option {
+	return func(in *Interpreter) {
+		for name, builtin := range builtins {
+			in.builtins[name] = hostBuiltin(builtin)
+		}
+	}
+}
+
+// hostBuiltin wraps a host-supplied builtin so its results pass through
+// object.Canonical.
+func hostBuiltin(builtin *object.Builtin) *object.Builtin {
+	fn := builtin.Fn
+	return &object.Builtin{
+		Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			return object.Canonical(fn(ctx, args...))
+		},
+		Pure: builtin.Pure,
+	}
+}
+
+// New returns an interpreter configured by opts.
+func New(opts ...Option) *Interpreter {
+	in := &Interpreter{
+		maxDepth:  DefaultMaxDepth,
+		stdout:    os.Stdout,
+		stderr:    os.Stderr,
+		stdin:     bufio.NewReader(os.Stdin),
+		strings:   make(map[string]*object.String),
+		constants: make(map[ast.Expression]object.Object),
+
+		capabilities: make(map[Capability]bool),
+	}
+	in.interactive = isTerminal(os.Stdin)
+	in.builtins = newBuiltins(in)
+
+	for _, opt := range opts {
+		opt(in)
+	}
+	if in.logger == nil {
+		in.logger = slog.New(slog.NewTextHandler(in.stderr, nil))
+	}
+	// NO_COLOR wins over FORCE_COLOR, which styles output piped elsewhere.
+	in.color = os.Getenv("NO_COLOR") == "" && (os.Getenv("FORCE_COLOR") != "" || isTerminal(in.stdout))
+	if !in.noPrelude {
+		in.prelude = standardPrelude()
+	}
+
+	return in
+}
+
+// Eval evaluates node in env without a deadline or cancellation.
+func (in *Interpreter) Eval(node ast.Node, env *object.Environment) object.Object {
+	return in.EvalContext(context.Background(), node, env)
+}
+
+// EvalContext evaluates node in env. Evaluation stops with an error once ctx
+// is cancelled, and ctx is handed to every builtin the program calls so they
+// can honour its deadline and read host-supplied values. A panic during
+// evaluation ends it with an InternalError instead of reaching the host.
+func (in *Interpreter) EvalContext(ctx context.Context, node ast.Node, env *object.Environment) (result object.Object) {
+	defer func(globals *object.Environment) { in.globals = globals }(in.globals)
+	defer in.restoreStubs(len(in.stubs))
+	// Reported after recoverPanic runs, so panics are measured too.
+	done := in.measure()
+	defer func() { done(result) }()
+	defer in.recoverPanic(&result, in.nesting)
+	in.globals = env
+
+	trapped, finish, release := in.trapSignals(ctx)
+	defer release()
+	result = finish(in.unwrapReturnValue(in.eval(trapped, node, env)))
+	return in.localize(result)
+}
+
+// Call calls fn, a function or builtin, with args, as a host would call back
+// into a program after evaluating it.
+func (in *Interpreter) Call(fn object.Object, args ...object.Object) object.Object {
+	return in.CallContext(context.Background(), fn, args...)
+}
+
+// CallContext calls fn with args, stopping with an error once ctx is
+// cancelled like EvalContext. The args pass through object.Canonical.
+func (in *Interpreter) CallContext(ctx context.Context, fn object.Object, args ...object.Object) (result object.Object) {
+	done := in.measure()
+	defer func() { done(result) }()
+	defer in.recoverPanic(&result, in.nesting)
+	for i, arg := range args {
+		args[i] = object.Canonical(arg)
+	}
+
+	trapped, finish, release := in.trapSignals(ctx)
+	defer release()
+	result = finish(in.applyFunction(trapped, fn, args))
+	return in.localize(result)
+}
+
+// HasBuiltin reports whether programs run by the interpreter can call a
+// builtin or prelude function named name.
+func (in *Interpreter) HasBuiltin(name string) bool {
+	_, ok := in.builtins[name]
+	return ok || in.fromPrelude(name) != nil
+}
+
+// fromPrelude returns the prelude's binding of name, or nil.
+func (in *Interpreter) fromPrelude(name string) object.Object {
+	if in.prelude == nil {
+		return nil
+	}
+	val, _ := in.prelude.Get(name)
+	return val
+}
+
+// recoverPanic, deferred by the entry points, turns a panic into an
+// InternalError in *result carrying the Go stack, and restores the nesting
+// the panic skipped unwinding.
+func (in *Interpreter) recoverPanic(result *object.Object, nesting int) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	in.nesting = nesting
+	err := &object.Error{
+		Category: object.InternalError,
+		Message:  fmt.Sprintf("internal error: %v; please report this bug", r),
+		Stack:    string(debug.Stack()),
+	}
+	if cause, ok := r.(error); ok {
+		err.Cause = cause
+	}
+	*result = err
+}
+
+// localize rewords result with the interpreter's catalog if it is an
+// error.
+func (in *Interpreter) localize(result object.Object) object.Object {
+	err, ok := result.(*object.Error)
+	if !ok || in.catalog == nil || err.Format == "" {
+		return result
+	}
+
+	localized := *err
+	localized.Message = diagnostic.Sprintf(in.catalog, err.Format, err.Args...)
+	return &localized
+}
+
+// internString returns a String for a literal, reusing the object created
+// for an earlier occurrence of the same short literal.
+func (in *Interpreter) internString(value string) *object.String {
+	if len(value) > maxInternedStringLength {
+		return object.NewString(value)
+	}
+
+	if str, ok := in.strings[value]; ok {
+		return str
+	}
+
+	str := object.NewString(value)
+	if len(in.strings) < maxInternedStrings {
+		in.strings[value] = str
+	}
+
+	return str
+}
@@ -0,0 +1,995 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"monkey/ast"
+	"monkey/diagnostic"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/optimizer"
+	"monkey/parser"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentInterpreters runs many interpreters in parallel over one
+// shared parsed program. Run it with -race to check that instances share no
+// mutable state.
+func TestConcurrentInterpreters(t *testing.T) {
+	const workers = 16
+
+	cache := parser.NewCache(0)
+	source := `
+	let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };
+	let table = {"ok": true};
+	puts(fib(seed));
+	if (table["ok"]) { [fib(seed), table["missing"], !true] } else { 0 }
+	`
+
+	var wg sync.WaitGroup
+	results := make([]string, workers)
+	outputs := make([]bytes.Buffer, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			program, errors := cache.Parse(source)
+			if len(errors) != 0 {
+				t.Errorf("parser errors: %v", errors)
+				return
+			}
+
+			env := object.NewEnvironment()
+			env.Set("seed", &object.Integer{Value: int64(i % 8)})
+
+			in := New(WithStdout(&outputs[i]))
+			results[i] = in.Eval(program, env).Inspect()
+		}(i)
+	}
+
+	wg.Wait()
+
+	fibs := []int{0, 1, 1, 2, 3, 5, 8, 13}
+	for i := 0; i < workers; i++ {
+		fib := fibs[i%8]
+
+		expected := fmt.Sprintf("[%d, null, false]", fib)
+		if results[i] != expected {
+			t.Errorf("worker %d: wrong result. want=%q, got=%q", i, expected, results[i])
+		}
+
+		if outputs[i].String() != fmt.Sprintf("%d\n", fib) {
+			t.Errorf("worker %d: wrong output. got=%q", i, outputs[i].String())
+		}
+	}
+}
+
+func TestInterpreterInternsStringLiterals(t *testing.T) {
+	in := New()
+	env := object.NewEnvironment()
+
+	first := in.Eval(parser.New(lexer.New(`"hello"`)).ParseProgram(), env)
+	second := in.Eval(parser.New(lexer.New(`"hello"`)).ParseProgram(), env)
+
+	if first != second {
+		t.Errorf("identical string literals were allocated twice")
+	}
+}
+
+func TestInterpreterReusesConstantLiterals(t *testing.T) {
+	program := parser.New(lexer.New(`fn() { [1, "a", 2.5] }()`)).ParseProgram()
+	optimizer.Fold(program)
+
+	in := New()
+	first := in.Eval(program, object.NewEnvironment()).(*object.Array)
+	second := in.Eval(program, object.NewEnvironment()).(*object.Array)
+
+	if &first.Elements[0] != &second.Elements[0] {
+		t.Errorf("constant array literal was built twice")
+	}
+	if first == second {
+		t.Errorf("evaluations of a constant array literal are the same array")
+	}
+
+	if other := New().Eval(program, object.NewEnvironment()).(*object.Array); &other.Elements[0] == &first.Elements[0] {
+		t.Errorf("interpreters shared a constant literal")
+	}
+}
+
+func TestConstantLiteralIdentity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let f = fn() { [1, 2] }; f() == f()`, "false"},
+		{`let f = fn() { [1, 2] }; let a = f(); f(); a == f()`, "false"},
+		{`let f = fn() { {"a": [1]} }; f()["a"] == f()["a"]`, "false"},
+		{`let f = fn() { [[1], {"b": [2]}] }; let a = f(); let b = f(); [a[0] == b[0], a[1]["b"] == b[1]["b"], a == b]`, "[false, false, false]"},
+		{`let f = fn(x) { [x] }; f(1) == f(1)`, "false"},
+		{`[1, 2] == [1, 2]`, "false"},
+		{`let f = fn() { [1, [2], {"c": 3}] }; f(); f()`, "[1, [2], {c: 3}]"},
+	}
+
+	// Folding must not change what a program computes.
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		if got := New().Eval(program, object.NewEnvironment()).Inspect(); got != tt.expected {
+			t.Errorf("%s: unfolded want=%s, got=%s", tt.input, tt.expected, got)
+		}
+		optimizer.Fold(program)
+		if got := New().Eval(program, object.NewEnvironment()).Inspect(); got != tt.expected {
+			t.Errorf("%s: folded want=%s, got=%s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestPutsUsesFormat(t *testing.T) {
+	var out bytes.Buffer
+	in := New(WithStdout(&out), WithFormat(object.FormatOptions{Indent: "  "}))
+	in.Eval(parser.New(lexer.New(`puts([1, [2]], "x")`)).ParseProgram(), object.NewEnvironment())
+
+	expected := "[\n  1,\n  [\n    2\n  ]\n]\nx\n"
+	if out.String() != expected {
+		t.Errorf("wrong output. want=%q, got=%q", expected, out.String())
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var events []TraceEvent
+	in := New(WithTrace(func(ev TraceEvent) { events = append(events, ev) }))
+	program := parser.New(lexer.New(`fn() { return 1 + 2; }()`)).ParseProgram()
+	in.Eval(program, object.NewEnvironment())
+
+	open := 0
+	var returned []string
+	for _, ev := range events {
+		if !ev.Exit {
+			open++
+			continue
+		}
+		open--
+		if ev.Returned {
+			returned = append(returned, fmt.Sprintf("%T=%s", ev.Node, ev.Result.Inspect()))
+		}
+	}
+
+	if open != 0 {
+		t.Errorf("enter and exit events do not balance: %d left open", open)
+	}
+
+	expected := []string{"*ast.ReturnStatement=3", "*ast.BlockStatement=3"}
+	if fmt.Sprint(returned) != fmt.Sprint(expected) {
+		t.Errorf("wrong returning nodes. want=%v, got=%v", expected, returned)
+	}
+
+	last := events[len(events)-1]
+	if _, ok := last.Node.(*ast.Program); !ok || !last.Exit || last.Result.Inspect() != "3" {
+		t.Errorf("last event is not the program's exit: %+v", last)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	var list diagnostic.List
+	in := New(WithDiagnostics(list.Add))
+	program := parser.New(lexer.New(`let f = fn(a) { a // 2 }; [f(7), f(9), f(8), 6 // 3, 7 // 0, 7 / 2]`)).ParseProgram()
+	in.Eval(program, object.NewEnvironment())
+
+	expected := []string{"info: integer division 7 // 2 rounds down to 3; 7 / 2 is 3.5 [truncating-division]"}
+	if len(list) != len(expected) || list[0].String() != expected[0] {
+		t.Errorf("wrong diagnostics. want=%q, got=%v", expected, list)
+	}
+}
+
+func TestCall(t *testing.T) {
+	in := New()
+	env := object.NewEnvironment()
+	program := parser.New(lexer.New(`let main = fn(argv) { len(argv) * 10 }; let fail = fn() { 1 / 0 };`)).ParseProgram()
+	in.Eval(program, env)
+
+	main, _ := env.Get("main")
+	argv := &object.Array{Elements: []object.Object{object.NewString("script.mky"), object.NewString("a")}}
+	if result := in.Call(main, argv); result.Inspect() != "20" {
+		t.Errorf("wrong result from main. got=%s", result.Inspect())
+	}
+
+	fail, _ := env.Get("fail")
+	if result := in.Call(fail); result.Inspect() != "ERROR: division by zero" {
+		t.Errorf("wrong result from fail. got=%s", result.Inspect())
+	}
+
+	if result := in.Call(object.NewInteger(1)); result.Inspect() != "ERROR: not a function: INTEGER" {
+		t.Errorf("wrong result from calling an integer. got=%s", result.Inspect())
+	}
+}
+
+func TestReplay(t *testing.T) {
+	program := parser.New(lexer.New(`[now(), now()]`)).ParseProgram()
+
+	var log ReplayLog
+	recorded := New(WithRecording(&log)).Eval(program, object.NewEnvironment()).Inspect()
+	if len(log.Entries) != 2 || log.Entries[0].Builtin != "now" {
+		t.Fatalf("wrong log entries: %+v", log.Entries)
+	}
+
+	var encoded bytes.Buffer
+	if err := log.Encode(&encoded); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	decoded, err := DecodeReplayLog(&encoded)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		replayed := New(WithReplay(decoded)).Eval(program, object.NewEnvironment()).Inspect()
+		if replayed != recorded {
+			t.Errorf("replay %d differs. want=%s, got=%s", i, recorded, replayed)
+		}
+	}
+
+	short := &ReplayLog{Entries: log.Entries[:1]}
+	result := New(WithReplay(short)).Eval(program, object.NewEnvironment())
+	if result.Inspect() != "ERROR: replay: now called after the log ran out" {
+		t.Errorf("wrong result for an exhausted log. got=%s", result.Inspect())
+	}
+}
+
+func TestRunTimers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		log      string
+	}{
+		{`after(20, fn() { log = push(log, "b") }); after(0, fn() { log = push(log, "a") })`, "null", "[a, b]"},
+		{`let t = every(1, fn() { log = push(log, len(log)); if (len(log) == 3) { cancel(t) } })`, "null", "[0, 1, 2]"},
+		{`let t = after(1, fn() { log = push(log, 1) }); cancel(t)`, "null", "[]"},
+		{`after(1, fn() { 1 / 0 }); after(5, fn() { log = push(log, 1) })`, "ERROR: division by zero", "[]"},
+		{`every(1, fn() { log = push(log, 1) })`, "ERROR: timers stopped: context deadline exceeded", ""},
+		{`after(1, fn() { sleep(1000) })`, "ERROR: timers stopped: context deadline exceeded", ""},
+		{`after(1, fn() { fail_late() })`, "ERROR: disk full", ""},
+	}
+
+	// fail_late fails for its own reasons after the deadline has passed.
+	failLate := &object.Builtin{Fn: func(ctx context.Context, args ...object.Object) object.Object {
+		<-ctx.Done()
+		return newError(object.RuntimeError, "disk full")
+	}}
+
+	for _, tt := range tests {
+		in := New()
+		env := object.NewEnvironment()
+		env.Set("log", &object.Array{})
+		env.Set("fail_late", failLate)
+		if result := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env); isError(result) {
+			t.Fatalf("%s: %s", tt.input, result.Inspect())
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		result := in.RunTimers(ctx)
+		cancel()
+
+		if result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+		if log, _ := env.Get("log"); tt.log != "" && log.Inspect() != tt.log {
+			t.Errorf("%s: wrong log. want=%s, got=%s", tt.input, tt.log, log.Inspect())
+		}
+	}
+
+	for _, tt := range []struct{ input, expected string }{
+		{`every(0, fn() {})`, "ERROR: interval passed to `every` must be positive, got 0s"},
+		{`after("1s", fn() {})`, "ERROR: delay passed to `after` must be INTEGER or DURATION, got STRING"},
+		{`cancel(1)`, "ERROR: argument to `cancel` must be TIMER, got INTEGER"},
+		{`[every(duration("1s"), fn() {}), after(5, fn() {})]`, "[<timer every 1s>, <timer after 5ms>]"},
+	} {
+		if result := testEval(tt.input); result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+}
+
+// testMetrics records the metrics reported to it.
+type testMetrics struct {
+	counters     map[string]int
+	observations map[string][]float64
+}
+
+func (m *testMetrics) Inc(name string, labels ...string) {
+	m.counters[fmt.Sprint(name, labels)]++
+}
+
+func (m *testMetrics) Observe(name string, value float64) {
+	m.observations[name] = append(m.observations[name], value)
+}
+
+func TestMetrics(t *testing.T) {
+	m := &testMetrics{counters: make(map[string]int), observations: make(map[string][]float64)}
+	in := New(WithMetrics(m), WithBuiltins(map[string]*object.Builtin{
+		// Host builtins may leave Category empty, which means RuntimeError.
+		"host_fail": {Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			return &object.Error{Message: "host failed"}
+		}},
+	}))
+	env := object.NewEnvironment()
+
+	for _, input := range []string{`let f = fn() { [1, 2, 3] }; f()`, `1 / 0`, `missing`, `-true`, `host_fail()`} {
+		in.Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+	}
+	f, _ := env.Get("f")
+	in.Call(f)
+
+	expected := map[string]int{
+		"monkey_evaluations_total[]":             6,
+		"monkey_errors_total[ZeroDivisionError]": 1,
+		"monkey_errors_total[NameError]":         1,
+		"monkey_errors_total[TypeError]":         1,
+		"monkey_errors_total[RuntimeError]":      1,
+		"monkey_errors_total[]":                  0,
+	}
+	for name, count := range expected {
+		if m.counters[name] != count {
+			t.Errorf("counter %s is %d, want %d", name, m.counters[name], count)
+		}
+	}
+
+	for _, name := range []string{MetricEvalDuration, MetricEvalAllocations} {
+		if len(m.observations[name]) != 6 {
+			t.Errorf("histogram %s has %d observations, want 6", name, len(m.observations[name]))
+		}
+	}
+}
+
+func TestHostBooleansAreCanonical(t *testing.T) {
+	in := New(WithBuiltins(map[string]*object.Builtin{
+		"flag": {Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			return &object.Array{Elements: []object.Object{&object.Boolean{Value: false}, &object.Null{}}}
+		}},
+	}))
+	env := object.NewEnvironment()
+	program := parser.New(lexer.New(`let f = flag(); let g = fn(x) { x == false }; [f[0] == false, if (f[0]) { 1 } else { 2 }, if (f[1]) { 1 } else { 2 }]`)).ParseProgram()
+	if result := in.Eval(program, env); result.Inspect() != "[true, 2, 2]" {
+		t.Errorf("wrong result. got=%s", result.Inspect())
+	}
+
+	g, _ := env.Get("g")
+	if result := in.Call(g, &object.Boolean{Value: false}); result != TRUE {
+		t.Errorf("call argument was not canonicalized. got=%s", result.Inspect())
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	var list diagnostic.List
+	in := New(WithDiagnostics(list.Add), WithCatalog(diagnostic.Messages{
+		"division by zero":       "Division durch null",
+		"type mismatch: %s + %s": "Typen passen nicht: %[2]s und %[1]s",
+		"integer division %d // %d rounds down to %d; %d / %d is %s": "%[1]d // %[2]d ergibt %[3]d",
+	}))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let f = fn(x) { x / 0 }; f(1)`, "ERROR: Division durch null"},
+		{`1 + "a"`, "ERROR: Typen passen nicht: STRING und INTEGER"},
+		{`-true`, "ERROR: unknown operator: -BOOLEAN"},
+		{`7 // 2`, "3"},
+	}
+
+	for _, tt := range tests {
+		result := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+
+	if len(list) != 1 || list[0].Message != "7 // 2 ergibt 3" {
+		t.Errorf("diagnostic not reworded: %v", list)
+	}
+
+	errObj := in.Eval(parser.New(lexer.New(`1 / 0`)).ParseProgram(), object.NewEnvironment()).(*object.Error)
+	if errObj.Category != object.ZeroDivisionError || errObj.Format != "division by zero" {
+		t.Errorf("reworded error lost its category or format: %+v", errObj)
+	}
+}
+
+func TestIsPure(t *testing.T) {
+	tests := []struct {
+		input string
+		pure  bool
+	}{
+		{`fn(x) { x * 2 }`, true},
+		{`fn(xs) { let total = 0; for (x in xs) { total += x }; total }`, true},
+		{`fn(xs) { len(push(xs, 1)) + first(xs) }`, true},
+		{`fn(xs) { sum(xs) + max(xs) - min(xs) * product(xs) }`, true},
+		{`let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib`, true},
+		{`let double = fn(x) { x * 2 }; fn(x) { double(x) + 1 }`, true},
+		{`fn(x) { let g = fn(y) { y + 1 }; g(x) }`, true},
+		{`fn(x) { puts(x) }`, false},
+		{`let count = 0; fn(x) { count += 1; x }`, false},
+		{`fn(x) { let inner = fn() { x = 2 }; inner(); x }`, true},
+		{`let log = fn(x) { puts(x) }; fn(x) { log(x); x }`, false},
+		{`fn(f, x) { f(x) }`, false},
+		{`fn(x) { missing(x) }`, false},
+		{`len`, true},
+		{`now`, false},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		fn := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if pure := in.IsPure(fn); pure != tt.pure {
+			t.Errorf("%s: IsPure is %t, want %t", tt.input, pure, tt.pure)
+		}
+	}
+
+	var list diagnostic.List
+	in := New(WithDiagnostics(list.Add))
+	program := parser.New(lexer.New(`let n = 0; let f = memoize(fn(x) { n += x; n }); let g = memoize(fn(x) { x * 2 }); let h = memoize(fn(xs) { sum(xs) + max(xs) }); f(1) + g(1) + h([1, 2])`)).ParseProgram()
+	in.Eval(program, object.NewEnvironment())
+
+	expected := "warning: `memoize` was given a function that may have side effects [impure-callback]"
+	if len(list) != 1 || list[0].String() != expected {
+		t.Errorf("wrong diagnostics. want=%q, got=%v", expected, list)
+	}
+}
+
+func TestPromptAndConfirm(t *testing.T) {
+	tests := []struct {
+		input    string
+		stdin    string
+		expected string
+		output   string
+	}{
+		{`prompt("Name? ")`, "Ada\nrest\n", "Ada", "Name? Ada\n"},
+		{`prompt("Name? ")`, "Ada", "Ada", "Name? Ada\n"},
+		{`prompt("Name? ")`, "", "null", "Name? \n"},
+		{`prompt("Name? ", {"default": "anon"})`, "\n", "anon", "Name? \n"},
+		{`prompt("Name? ", {"default": "anon"})`, "", "anon", "Name? \n"},
+		{`prompt("Age? ", {"validate": fn(s) { if (len(s) > 1) { true } else { "too short" } }})`, "x\n12\n", "12", "Age? x\ntoo short\nAge? 12\n"},
+		{`prompt("Age? ", {"validate": fn(s) { false }})`, "1\n2\n", "null", "Age? 1\nAge? 2\nAge? \n"},
+		{`[prompt("a? "), prompt("b? ")]`, "1\n2\n", "[1, 2]", "a? 1\nb? 2\n"},
+		{`confirm("Go?")`, "y\n", "true", "Go? [y/N] y\n"},
+		{`confirm("Go?")`, "maybe\nNO\n", "false", "Go? [y/N] maybe\nPlease answer y or n.\nGo? [y/N] NO\n"},
+		{`confirm("Go?", true)`, "\n", "true", "Go? [Y/n] \n"},
+		{`confirm("Go?")`, "", "false", "Go? [y/N] \n"},
+		{`prompt(1)`, "", "ERROR: question passed to `prompt` must be STRING, got INTEGER", ""},
+		{`prompt("?", {"validate": 1})`, "", "ERROR: option validate for `prompt` must be FUNCTION, got INTEGER", ""},
+		{`prompt("?", {"hidden": true})`, "", "ERROR: unknown option hidden for `prompt`", ""},
+		{`confirm("?", "yes")`, "", "ERROR: default passed to `confirm` must be BOOLEAN, got STRING", ""},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		in := New(WithStdin(strings.NewReader(tt.stdin)), WithStdout(&out))
+		result := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+
+		if result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+		if out.String() != tt.output {
+			t.Errorf("%s: wrong output. want=%q, got=%q", tt.input, tt.output, out.String())
+		}
+	}
+}
+
+func TestPromptCancellation(t *testing.T) {
+	stdin, answer := io.Pipe()
+	var out bytes.Buffer
+	in := New(WithStdin(stdin), WithStdout(&out))
+	env := object.NewEnvironment()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result := in.EvalContext(ctx, parser.New(lexer.New(`prompt("Name? ")`)).ParseProgram(), env)
+	if expected := "ERROR: prompt: context deadline exceeded"; result.Inspect() != expected {
+		t.Fatalf("wrong result. want=%q, got=%q", expected, result.Inspect())
+	}
+
+	// The abandoned read still gets the next line, for the next question.
+	go answer.Write([]byte("Ada\n"))
+	result = in.Eval(parser.New(lexer.New(`prompt("Name? ")`)).ParseProgram(), env)
+	if result.Inspect() != "Ada" {
+		t.Errorf("wrong result after cancellation. got=%q", result.Inspect())
+	}
+}
+
+func TestPromptReplay(t *testing.T) {
+	program := parser.New(lexer.New(`[prompt("Name? "), confirm("Sure?"), prompt("Age? ")]`)).ParseProgram()
+
+	var log ReplayLog
+	var out bytes.Buffer
+	recorded := New(WithRecording(&log), WithStdin(strings.NewReader("Ada\ny\n")), WithStdout(&out))
+	if result := recorded.Eval(program, object.NewEnvironment()); result.Inspect() != "[Ada, true, null]" {
+		t.Fatalf("wrong recorded result. got=%s", result.Inspect())
+	}
+
+	expected := []ReplayEntry{{"prompt", "Ada\n"}, {"confirm", "y\n"}, {"prompt", ""}}
+	if !reflect.DeepEqual(log.Entries, expected) {
+		t.Errorf("wrong log. want=%v, got=%v", expected, log.Entries)
+	}
+
+	var replayedOut bytes.Buffer
+	replayed := New(WithReplay(&log), WithStdin(strings.NewReader("Grace\nn\n42\n")), WithStdout(&replayedOut))
+	if result := replayed.Eval(program, object.NewEnvironment()); result.Inspect() != "[Ada, true, null]" {
+		t.Errorf("wrong replayed result. got=%s", result.Inspect())
+	}
+	if replayedOut.String() != out.String() {
+		t.Errorf("replayed output differs. want=%q, got=%q", out.String(), replayedOut.String())
+	}
+}
+
+func TestStyle(t *testing.T) {
+	tests := []struct {
+		input      string
+		noColor    string
+		forceColor string
+		expected   string
+	}{
+		{`style("ok", "green")`, "", "1", "\x1b[32mok\x1b[0m"},
+		{`style("fail", "red", "bold", "bg_white")`, "", "1", "\x1b[31;1;47mfail\x1b[0m"},
+		{`style("plain")`, "", "1", "plain"},
+		// Output to a buffer is not a terminal.
+		{`style("ok", "green")`, "", "", "ok"},
+		{`style("ok", "green")`, "1", "", "ok"},
+		{`style("ok", "green")`, "1", "1", "ok"},
+		{`style("ok", "purple")`, "1", "", `ERROR: unknown style "purple" passed to ` + "`style`"},
+		{`style(1, "red")`, "", "1", "ERROR: text passed to `style` must be STRING, got INTEGER"},
+		{`is_tty()`, "", "1", "false"},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("NO_COLOR", tt.noColor)
+		t.Setenv("FORCE_COLOR", tt.forceColor)
+		in := New(WithStdout(&bytes.Buffer{}))
+		result := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`print_table([{"name": "ada", "age": 36}, {"name": "grace", "age": 85, "lang": "cobol"}])`,
+			"name   age  lang\n-----  ---  -----\nada     36\ngrace   85  cobol\n",
+		},
+		{
+			`print_table([{"name": "ada", "age": 36}], ["age", "name", "id"])`,
+			"age  name  id\n---  ----  --\n 36  ada\n",
+		},
+		{
+			`print_table([[1, "one"], [100, "hundred", true]])`,
+			"  1  one\n100  hundred  true\n",
+		},
+		{
+			`print_table([[1.5, "x"]], ["n", "label"])`,
+			"n    label\n---  -----\n1.5  x\n",
+		},
+		{`print_table([])`, ""},
+		{`print_table([[1], {"a": 1}])`, "ERROR: row 1 passed to `print_table` must be ARRAY like the rows before it, got HASH"},
+		{`print_table([1])`, "ERROR: row 0 passed to `print_table` must be ARRAY or HASH, got INTEGER"},
+		{`print_table([[1]], [1])`, "ERROR: headers passed to `print_table` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		in := New(WithStdout(&out))
+		result := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if isError(result) {
+			out.WriteString(result.Inspect())
+		}
+		if out.String() != tt.expected {
+			t.Errorf("%s: wrong output. want=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestForall(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`forall(gen_int(-50, 50), gen_int(-50, 50), fn(a, b) { a + b == b + a })`, "true"},
+		{`forall(gen_int(0, 100), fn(x) { x < 10 }, {"seed": 1})`, "ERROR: forall: property failed on run 1 with seed 1; smallest counterexample (10): returned false"},
+		{`forall(gen_int(-100, -5), fn(x) { x > -20 }, {"seed": 1, "runs": 500})`, "ERROR: forall: property failed on run 2 with seed 1; smallest counterexample (-20): returned false"},
+		{`forall(gen_array(gen_int(0, 9), 10), fn(a) { len(a) < 3 }, {"seed": 7})`, "ERROR: forall: property failed on run 1 with seed 7; smallest counterexample ([0, 0, 0]): returned false"},
+		{`forall(gen_string(8), fn(s) { len(s) < 2 }, {"seed": 3})`, "ERROR: forall: property failed on run 1 with seed 3; smallest counterexample (aa): returned false"},
+		{`forall(gen_int(1, 9), gen_bool(), fn(x, b) { if (b) { x / 0 } else { true } }, {"seed": 2})`, "ERROR: forall: property failed on run 2 with seed 2; smallest counterexample (1, true): division by zero"},
+		{`forall(gen_one_of([1, 2, 3]), fn(x) { x < 3 }, {"seed": 2})`, "ERROR: forall: property failed on run 2 with seed 2; smallest counterexample (3): returned false"},
+		{`gen_int(5, 1)`, "ERROR: bounds passed to `gen_int` must not be reversed, got 5 and 1"},
+		{`gen_one_of([])`, "ERROR: argument to `gen_one_of` must not be empty"},
+		{`forall(fn(x) { true })`, "ERROR: `forall` needs at least one generator and a property"},
+		{`forall(1, fn(x) { true })`, "ERROR: generator passed to `forall` must be GENERATOR, got INTEGER"},
+		{`forall(gen_bool(), fn(x) { true }, {"runs": 0})`, "ERROR: option runs for `forall` must be positive, got 0"},
+		{`gen_array(gen_bool(), 3)`, "<generator array(bool, 3)>"},
+	}
+
+	for _, tt := range tests {
+		if result := testEval(tt.input); result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+
+	// An unseeded run draws its seed through the replay log, so a failure
+	// can be reproduced by replaying it.
+	input := `forall(gen_int(0, 1000000), fn(x) { x < 10 })`
+	log := &ReplayLog{}
+	first := New(WithRecording(log)).Eval(parser.New(lexer.New(input)).ParseProgram(), object.NewEnvironment())
+	again := New(WithReplay(log)).Eval(parser.New(lexer.New(input)).ParseProgram(), object.NewEnvironment())
+	if len(log.Entries) != 1 || first.Inspect() != again.Inspect() {
+		t.Errorf("replayed forall differs. first=%q, again=%q", first.Inspect(), again.Inspect())
+	}
+}
+
+func TestBench(t *testing.T) {
+	var out bytes.Buffer
+	in := New(WithStdout(&out))
+	env := object.NewEnvironment()
+	result := in.Eval(parser.New(lexer.New(`let calls = 0; let r = bench("count", fn() { calls = calls + 1 }, 5); [r["name"], r["iterations"] <= calls, r["iterations"] > 1, r["ns_per_op"] > 0.0]`)).ParseProgram(), env)
+	if result.Inspect() != "[count, true, true, true]" {
+		t.Fatalf("wrong result: %s", result.Inspect())
+	}
+	if !strings.HasPrefix(out.String(), "count  ") || !strings.HasSuffix(out.String(), " ns/op\n") {
+		t.Errorf("wrong report: %q", out.String())
+	}
+
+	for _, tt := range []struct{ input, expected string }{
+		{`bench("fail", fn() { 1 / 0 }, 5)`, "ERROR: division by zero"},
+		{`bench("x", 1)`, "ERROR: function passed to `bench` must be FUNCTION, got INTEGER"},
+		{`bench("x", fn() {}, "1s")`, "ERROR: time passed to `bench` must be INTEGER or DURATION, got STRING"},
+	} {
+		if result := testEval(tt.input); result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestStubBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let size = fn(x) { len(x) }; let test = fn() { stub_builtin("len", fn(x) { 42 }); size([1]) }; [test(), size([1])]`, "[42, 1]"},
+		{`let test = fn() { stub_builtin("len", fn(x) { 1 }); stub_builtin("len", fn(x) { 2 }); len([]) }; [test(), len([])]`, "[2, 0]"},
+		{`stub_builtin("first", fn(x) { "stub" }); first([1])`, "stub"},
+		{`stub_builtin("http_get", fn(url) { "" })`, "ERROR: stub_builtin: no builtin named http_get"},
+		{`stub_builtin("len", 1)`, "ERROR: function passed to `stub_builtin` must be FUNCTION, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		if result := testEval(tt.input); result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+
+	// A stub made at the top level lasts until the evaluation ends.
+	in := New()
+	env := object.NewEnvironment()
+	in.Eval(parser.New(lexer.New(`stub_builtin("len", fn(x) { 42 })`)).ParseProgram(), env)
+	if result := in.Eval(parser.New(lexer.New(`len([1])`)).ParseProgram(), env); result.Inspect() != "1" {
+		t.Errorf("stub outlived its evaluation: len([1]) = %s", result.Inspect())
+	}
+}
+
+type failingModule struct{}
+
+func (failingModule) Load(ctx context.Context) (map[string]*object.Builtin, error) {
+	return nil, fmt.Errorf("library not found")
+}
+
+func TestModules(t *testing.T) {
+	calls := 0
+	mylib := Module{
+		"double": {Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			calls++
+			return object.NewInteger(args[0].(*object.Integer).Value * 2)
+		}},
+		"yes": {Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			return &object.Boolean{Value: true}
+		}},
+	}
+	in := New(WithModules(map[string]ModuleProvider{"mylib": mylib, "broken": failingModule{}}))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let m = import "go:mylib"; m["double"](21)`, "42"},
+		{`keys(import "go:mylib")`, "[double, yes]"},
+		{`(import "go:mylib")["yes"]() == true`, "true"},
+		{`len`, "builtin function"},
+		{`double`, "ERROR: identifier not found: double"},
+		{`import "go:other"`, `ERROR: import "go:other": no such module`},
+		{`import "go:broken"`, `ERROR: import "go:broken": library not found`},
+		{`import "lib.mky"`, `ERROR: import "lib.mky": only host modules, named with a "go:" prefix, can be imported`},
+	}
+
+	for _, tt := range tests {
+		result := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+	if calls != 1 {
+		t.Errorf("double called %d times, want 1", calls)
+	}
+}
+
+func TestStats(t *testing.T) {
+	var stats Stats
+	in := New(WithStats(&stats))
+	input := `let down = fn(n) { if (n > 0) { down(n - 1) } else { [n, 1.5, {"k": "v" + "w"}] } }; down(3); len([1]) > 0`
+	if result := in.Eval(parser.New(lexer.New(input)).ParseProgram(), object.NewEnvironment()); result.Inspect() != "true" {
+		t.Fatalf("wrong result: %s", result.Inspect())
+	}
+
+	if stats.MaxDepth != 4 || stats.Environments != 4 {
+		t.Errorf("wrong depth or environments. want=4 and 4, got=%d and %d", stats.MaxDepth, stats.Environments)
+	}
+	want := map[object.ObjectType]int{
+		object.FUNCTION_OBJ: 1,
+		object.INTEGER_OBJ:  4, // n - 1 three times, then len
+		object.FLOAT_OBJ:    1,
+		object.STRING_OBJ:   1,
+		object.ARRAY_OBJ:    2,
+		object.HASH_OBJ:     1,
+	}
+	if fmt.Sprint(stats.Values) != fmt.Sprint(want) {
+		t.Errorf("wrong values. want=%v, got=%v", want, stats.Values)
+	}
+}
+
+func TestStreams(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	in := New(WithStreams(Streams{Stdin: strings.NewReader("Ada\n"), Stdout: &stdout, Stderr: &stderr}))
+
+	input := `let name = prompt("Name? "); puts("hello " + name); log_warn("greeted", {"name": name})`
+	if result := in.Eval(parser.New(lexer.New(input)).ParseProgram(), object.NewEnvironment()); isError(result) {
+		t.Fatal(result.Inspect())
+	}
+
+	if stdout.String() != "Name? Ada\nhello Ada\n" {
+		t.Errorf("wrong stdout: %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), `level=WARN msg=greeted name=Ada`) {
+		t.Errorf("wrong stderr: %q", stderr.String())
+	}
+
+	// Streams left nil keep their earlier setting.
+	var out bytes.Buffer
+	in = New(WithStdout(&out), WithStreams(Streams{Stderr: &stderr}))
+	in.Eval(parser.New(lexer.New(`puts(1)`)).ParseProgram(), object.NewEnvironment())
+	if out.String() != "1\n" {
+		t.Errorf("stdout was replaced: %q", out.String())
+	}
+}
+
+func TestPrelude(t *testing.T) {
+	prelude := object.NewEnvironment()
+	setup := `let greet = fn(n) { "hi " + n }; let count = 0; let bump = fn() { count = count + 1 }`
+	if result := New().Eval(parser.New(lexer.New(setup)).ParseProgram(), prelude); isError(result) {
+		t.Fatal(result.Inspect())
+	}
+	prelude.Freeze()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`greet("ada")`, "hi ada"},
+		{`let count = 5; count`, "5"},
+		{`count`, "0"},
+		{`let greet = fn(n) { n }; greet("x")`, "x"},
+		{`greet("bob")`, "hi bob"},
+		{`count = 3`, "ERROR: cannot assign to count, which is bound in a read-only environment"},
+		{`bump()`, "ERROR: cannot assign to count, which is bound in a read-only environment"},
+		{`nosuch = 1`, "ERROR: cannot assign to undefined variable nosuch"},
+	}
+
+	for _, tt := range tests {
+		env := object.ExtendEnvironment(prelude)
+		if result := New().Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env); result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+
+	if result := New().Eval(parser.New(lexer.New(`let x = 1`)).ParseProgram(), prelude); result.Inspect() != "ERROR: cannot define x in a read-only environment" {
+		t.Errorf("let in a frozen environment: got=%q", result.Inspect())
+	}
+
+	// Children of one prelude can run in parallel; run with -race.
+	program := parser.New(lexer.New(`let n = 0; for (i in [1, 2, 3]) { n += i }; greet(to_base(n, 10))`)).ParseProgram()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result := New().Eval(program, object.ExtendEnvironment(prelude)); result.Inspect() != "hi 6" {
+				t.Errorf("wrong parallel result: %q", result.Inspect())
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStandardPrelude(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`filter(range(0, 10), fn(x) { x // 3 * 3 == x })`, "[0, 3, 6, 9]"},
+		{`reduce([1, 2, 3, 4], 0, fn(acc, x) { acc + x })`, "10"},
+		{`reverse(["a", "b", "c"])`, "[c, b, a]"},
+		{`range(3, 3)`, "[]"},
+		{`let map = fn(xs, f) { "mine" }; map([1], fn(x) { x })`, "mine"},
+		{`map = 1`, "ERROR: cannot assign to undefined variable map"},
+		{`map([1], fn(x) { call_stack()[1]["name"] })`, "[map]"},
+	}
+
+	for _, tt := range tests {
+		if result := testEval(tt.input); result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+
+	in := New(WithoutPrelude())
+	if in.HasBuiltin("map") || !New().HasBuiltin("map") {
+		t.Errorf("HasBuiltin does not follow WithoutPrelude")
+	}
+	result := in.Eval(parser.New(lexer.New(`map([1], fn(x) { x })`)).ParseProgram(), object.NewEnvironment())
+	if result.Inspect() != "ERROR: identifier not found: map" {
+		t.Errorf("prelude not left out: %s", result.Inspect())
+	}
+}
+
+func TestDocs(t *testing.T) {
+	in := New()
+	for name := range in.builtins {
+		if _, ok := in.Doc(name); !ok {
+			t.Errorf("builtin %s is not documented", name)
+		}
+	}
+	in.prelude.Each(func(name string, obj object.Object) {
+		if _, ok := in.Doc(name); !ok {
+			t.Errorf("prelude function %s is not documented", name)
+		}
+	})
+	for name, doc := range builtinDocs {
+		if !in.HasBuiltin(name) {
+			t.Errorf("%s is documented but not defined", name)
+		}
+		for _, ex := range doc.Examples {
+			input, expected, ok := strings.Cut(ex, " => ")
+			if !ok {
+				t.Errorf("%s: example %q has no result", name, ex)
+				continue
+			}
+			if result := testEval(input); result.Inspect() != expected {
+				t.Errorf("%s: example %s gives %q", name, ex, result.Inspect())
+			}
+		}
+	}
+	if _, ok := New(WithoutPrelude()).Doc("map"); ok {
+		t.Errorf("Doc documents map without the prelude")
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`help("first")`, "first(array)\n    The first element of an array, or null if it is empty.\n" +
+			"examples:\n    first([1, 2, 3]) => 1\n"},
+		{`help(reverse)`, "reverse(array)\n    A new array of the elements in reverse order.\n" +
+			"examples:\n    reverse([1, 2, 3]) => [3, 2, 1]\n"},
+		{`help("cancel")`, "cancel(timer)\n    Stops a timer from running again.\n"},
+		{`help("nope")`, "ERROR: help: no documentation for nope"},
+		{`help(1)`, "ERROR: argument to `help` must be STRING or a function, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		in := New(WithStdout(&out))
+		result := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		got := out.String()
+		if result != NULL {
+			got = result.Inspect()
+		}
+		if got != tt.expected {
+			t.Errorf("%s: wrong output. want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	var out bytes.Buffer
+	New(WithStdout(&out)).Eval(parser.New(lexer.New(`help()`)).ParseProgram(), object.NewEnvironment())
+	if !strings.Contains(out.String(), "len(value)\n") || !strings.Contains(out.String(), "map(array, fn)\n") {
+		t.Errorf("help() does not list every signature: %q", out.String())
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	tests := []struct {
+		opts     []Option
+		input    string
+		expected string
+	}{
+		{nil, `version()`, Version},
+		{nil, `features()`, `{version: ` + Version + `, engine: eval, capabilities: [], prelude: true, signals: false}`},
+		{[]Option{WithCapabilities(CapabilityNetwork, CapabilityDatabase), WithSignalHandling(), WithoutPrelude()},
+			`features()`, `{version: ` + Version + `, engine: eval, capabilities: [database, network], prelude: false, signals: true}`},
+		{nil, `version(1)`, "ERROR: wrong number of arguments. got=1, want=0"},
+	}
+
+	for _, tt := range tests {
+		in := New(tt.opts...)
+		result := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+		in.Close()
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	boom := &object.Builtin{Fn: func(ctx context.Context, args ...object.Object) object.Object {
+		panic("boom")
+	}}
+	in := New()
+	env := object.NewEnvironment()
+	env.Set("boom", boom)
+
+	results := map[string]object.Object{
+		"Eval": in.Eval(parser.New(lexer.New(`let f = fn(x) { [x, boom()] }; f(1)`)).ParseProgram(), env),
+		"Call": in.Call(boom),
+	}
+	for entry, result := range results {
+		err, ok := result.(*object.Error)
+		if !ok || err.Category != object.InternalError {
+			t.Fatalf("%s: panic not recovered as an InternalError: %v", entry, result)
+		}
+		if err.Inspect() != "ERROR: internal error: boom; please report this bug" {
+			t.Errorf("%s: wrong message: %s", entry, err.Inspect())
+		}
+		if !strings.Contains(err.Stack, "TestPanicRecovery") {
+			t.Errorf("%s: stack does not reach the panic:\n%s", entry, err.Stack)
+		}
+	}
+
+	// The interpreter is left usable.
+	if in.nesting != 0 || in.depth != 0 || len(in.frames) != 0 {
+		t.Errorf("state not unwound: nesting=%d depth=%d frames=%d", in.nesting, in.depth, len(in.frames))
+	}
+	if result := in.Eval(parser.New(lexer.New(`let g = fn(x) { x + 1 }; g(2)`)).ParseProgram(), env); result.Inspect() != "3" {
+		t.Errorf("wrong result after a panic: %s", result.Inspect())
+	}
+	if result := in.Eval(parser.New(lexer.New(`len(call_stack())`)).ParseProgram(), env); result.Inspect() != "0" {
+		t.Errorf("call stack not unwound: %s", result.Inspect())
+	}
+
+	// Evaluations cut short by a panic are still measured.
+	m := &testMetrics{counters: make(map[string]int), observations: make(map[string][]float64)}
+	in = New(WithMetrics(m))
+	in.Eval(parser.New(lexer.New(`boom()`)).ParseProgram(), env)
+	in.Call(boom)
+	if count := m.counters["monkey_errors_total[InternalError]"]; count != 2 {
+		t.Errorf("recovered panics counted %d times, want 2", count)
+	}
+	if count := m.counters["monkey_evaluations_total[]"]; count != 2 {
+		t.Errorf("evaluations counted %d times, want 2", count)
+	}
+	if n := len(m.observations[MetricEvalDuration]); n != 2 {
+		t.Errorf("histogram %s has %d observations, want 2", MetricEvalDuration, n)
+	}
+}
@@ -0,0 +1,90 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/ast"
+	"monkey/object"
+	"sort"
+)
+
+// frame is a call of a Monkey function in progress.
+type frame struct {
+	fn *object.Function
+	// call is the call expression, or nil if a builtin or the host called
+	// the function.
+	call *ast.CallExpression
+	env  *object.Environment
+}
+
+// builtinCallStack implements call_stack(), which returns a hash for each
+// function call in progress, innermost first, with the function's name, or
+// null if it is anonymous, the call expression's source text, such as
+// "f(1, 2)", and the line and column of its opening parenthesis. The call,
+// line and column are null if a builtin made the call.
+func (in *Interpreter) builtinCallStack(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	stack := &object.Array{Elements: make([]object.Object, 0, len(in.frames))}
+	for i := len(in.frames) - 1; i >= 0; i-- {
+		f := in.frames[i]
+
+		var name, call, line, column object.Object = NULL, NULL, NULL, NULL
+		if f.fn.Name != "" {
+			name = object.NewString(f.fn.Name)
+		}
+		if f.call != nil {
+			call = object.NewString(f.call.String())
+			line = object.NewInteger(int64(f.call.Token.Line))
+			column = object.NewInteger(int64(f.call.Token.Column))
+		}
+
+		entry := &object.Hash{}
+		for _, pair := range []object.HashPair{
+			{Key: object.NewString("name"), Value: name},
+			{Key: object.NewString("call"), Value: call},
+			{Key: object.NewString("line"), Value: line},
+			{Key: object.NewString("column"), Value: column},
+		} {
+			entry.Set(pair.Key.(object.Hashable).HashKey(), pair)
+		}
+		stack.Elements = append(stack.Elements, entry)
+	}
+
+	return stack
+}
+
+// builtinLocals implements locals(), which returns the variables of the
+// innermost function call in progress, or the globals outside any, as a
+// hash sorted by name.
+func (in *Interpreter) builtinLocals(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	env := in.globals
+	if len(in.frames) != 0 {
+		env = in.frames[len(in.frames)-1].env
+	}
+
+	result := &object.Hash{}
+	if env == nil {
+		return result
+	}
+
+	bindings := make(map[string]object.Object)
+	env.Each(func(name string, obj object.Object) { bindings[name] = obj })
+
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := object.NewString(name)
+		result.Set(key.HashKey(), object.HashPair{Key: key, Value: bindings[name]})
+	}
+	return result
+}
@@ -0,0 +1,171 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"monkey/object"
+	"sort"
+	"strings"
+)
+
+// builtinJSONEncode implements json_encode(value) and json_encode(value,
+// options). Hash pairs are written in insertion order unless the
+// "sort_keys" option is true; the "indent" option, a number of spaces or
+// a string of whitespace, spreads the output over indented lines. Keys are
+// written with Inspect, and two keys written the same way are an error.
+func builtinJSONEncode(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	var enc jsonEncoder
+	indent := ""
+	if len(args) == 2 {
+		options, ok := args[1].(*object.Hash)
+		if !ok {
+			return newError(object.TypeError, "options passed to `json_encode` must be HASH, got %s", args[1].Type())
+		}
+		for _, pair := range options.Ordered() {
+			switch key := pair.Key.Inspect(); key {
+			case "indent":
+				switch value := pair.Value.(type) {
+				case *object.Integer:
+					if value.Value < 0 || value.Value > 16 {
+						return newError(object.ArgumentError, "option indent for `json_encode` must be from 0 to 16, got %d", value.Value)
+					}
+					indent = strings.Repeat(" ", int(value.Value))
+				case *object.String:
+					if strings.Trim(value.Value, " \t\r\n") != "" {
+						return newError(object.ArgumentError, "option indent for `json_encode` must be whitespace, got %q", value.Value)
+					}
+					indent = value.Value
+				default:
+					return newError(object.TypeError, "option indent for `json_encode` must be INTEGER or STRING, got %s", pair.Value.Type())
+				}
+			case "sort_keys":
+				value, ok := pair.Value.(*object.Boolean)
+				if !ok {
+					return newError(object.TypeError, "option sort_keys for `json_encode` must be BOOLEAN, got %s", pair.Value.Type())
+				}
+				enc.sortKeys = value.Value
+			default:
+				return newError(object.ArgumentError, "unknown option %s for `json_encode`", key)
+			}
+		}
+	}
+
+	if err := enc.encode(args[0]); err != nil {
+		return newError(object.TypeError, "json_encode: %s", err)
+	}
+	if indent == "" {
+		return object.NewString(enc.buf.String())
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, enc.buf.Bytes(), "", indent); err != nil {
+		return newError(object.TypeError, "json_encode: %s", err)
+	}
+	return object.NewString(out.String())
+}
+
+// jsonEncoder writes compact JSON for a value, keeping hash pairs in
+// insertion order, which encoding/json cannot do for Go maps.
+type jsonEncoder struct {
+	buf      bytes.Buffer
+	sortKeys bool
+	active   map[object.Object]bool
+}
+
+func (e *jsonEncoder) encode(obj object.Object) error {
+	switch obj := obj.(type) {
+	case *object.Array:
+		return e.container(obj, func() error { return e.elements(obj.Elements) })
+	case *object.Tuple:
+		return e.container(obj, func() error { return e.elements(obj.Elements) })
+	case *object.Hash:
+		return e.container(obj, func() error { return e.pairs(obj) })
+	}
+
+	value, err := object.ToGo(obj)
+	if err != nil {
+		return err
+	}
+	if err := e.write(value); err != nil {
+		return fmt.Errorf("cannot encode %s: %w", obj.Inspect(), err)
+	}
+	return nil
+}
+
+// write appends value without the HTML escaping json.Marshal applies, so
+// strings read as written.
+func (e *jsonEncoder) write(value any) error {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	e.buf.Write(bytes.TrimSuffix(data.Bytes(), []byte("\n")))
+	return nil
+}
+
+// container encodes a collection with write, failing rather than looping
+// forever if the collection contains itself.
+func (e *jsonEncoder) container(obj object.Object, write func() error) error {
+	if e.active[obj] {
+		return fmt.Errorf("%s contains itself", obj.Type())
+	}
+	if e.active == nil {
+		e.active = make(map[object.Object]bool)
+	}
+	e.active[obj] = true
+	defer delete(e.active, obj)
+
+	return write()
+}
+
+func (e *jsonEncoder) elements(elements []object.Object) error {
+	e.buf.WriteByte('[')
+	for i, element := range elements {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		if err := e.encode(element); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteByte(']')
+	return nil
+}
+
+func (e *jsonEncoder) pairs(hash *object.Hash) error {
+	pairs := hash.Ordered()
+	if e.sortKeys {
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Key.Inspect() < pairs[j].Key.Inspect() })
+	}
+
+	written := make(map[string]bool, len(pairs))
+	e.buf.WriteByte('{')
+	for i, pair := range pairs {
+		key := pair.Key.Inspect()
+		if written[key] {
+			return fmt.Errorf("more than one key converts to %q", key)
+		}
+		written[key] = true
+
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		if err := e.write(key); err != nil {
+			return err
+		}
+		e.buf.WriteByte(':')
+		if err := e.encode(pair.Value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	e.buf.WriteByte('}')
+	return nil
+}
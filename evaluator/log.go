@@ -0,0 +1,59 @@
+package evaluator
+
+import (
+	"context"
+	"log/slog"
+	"monkey/object"
+	"sort"
+)
+
+// logBuiltin returns the builtin for log_<name>(msg) and
+// log_<name>(msg, fields), which logs msg at level with one attribute per
+// pair in the fields hash.
+func (in *Interpreter) logBuiltin(name string, level slog.Level) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+
+			msg, ok := args[0].(*object.String)
+			if !ok {
+				return newError(object.TypeError, "message passed to `%s` must be STRING, got %s", name, args[0].Type())
+			}
+
+			if !in.logger.Enabled(ctx, level) {
+				return NULL
+			}
+
+			var attrs []slog.Attr
+			if len(args) == 2 {
+				fields, ok := args[1].(*object.Hash)
+				if !ok {
+					return newError(object.TypeError, "fields passed to `%s` must be HASH, got %s", name, args[1].Type())
+				}
+				attrs = logAttrs(fields)
+			}
+
+			in.logger.LogAttrs(ctx, level, msg.Value, attrs...)
+			return NULL
+		},
+	}
+}
+
+// logAttrs converts fields into attributes sorted by key. Values that have
+// a plain Go form are logged as such, so handlers can encode them natively;
+// anything else is logged as its Inspect text.
+func logAttrs(fields *object.Hash) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields.Pairs))
+	for _, pair := range fields.Pairs {
+		value, err := object.ToGo(pair.Value)
+		if err != nil {
+			value = pair.Value.Inspect()
+		}
+		attrs = append(attrs, slog.Any(pair.Key.Inspect(), value))
+	}
+
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	return attrs
+}
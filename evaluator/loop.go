@@ -0,0 +1,97 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/ast"
+	"monkey/object"
+)
+
+// breakSignal and continueSignal are the results of break and continue
+// statements. Like returnSignal they unwind to the enclosing loop, with the
+// label they target, if any, in Interpreter.branchLabel. The parser ensures
+// a matching loop exists.
+var (
+	breakSignal    object.Object = &object.ReturnValue{}
+	continueSignal object.Object = &object.ReturnValue{}
+)
+
+// evalWhileExpression runs a loop to completion. Loops have no value of their
+// own, so the result is null unless the body returns or fails.
+func (in *Interpreter) evalWhileExpression(ctx context.Context, node *ast.WhileExpression, env *object.Environment) object.Object {
+	for first := true; ; first = false {
+		if !first || !node.DoWhile {
+			condition := in.eval(ctx, node.Condition, env)
+			if isAbrupt(condition) {
+				return condition
+			}
+			if !IsTruthy(condition) {
+				return NULL
+			}
+		}
+
+		if result, done := in.iterate(ctx, node.Label, node.Body, env); done {
+			return result
+		}
+	}
+}
+
+// evalForExpression runs the body once for each element of an array, each
+// character of a string or each key of a hash, in order.
+func (in *Interpreter) evalForExpression(ctx context.Context, node *ast.ForExpression, env *object.Environment) object.Object {
+	iterable := in.eval(ctx, node.Iterable, env)
+	if isAbrupt(iterable) {
+		return iterable
+	}
+
+	var elements []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		elements = iterable.Elements
+	case *object.String:
+		for _, r := range iterable.Value {
+			elements = append(elements, object.NewString(string(r)))
+		}
+	case *object.Hash:
+		for _, pair := range iterable.Ordered() {
+			elements = append(elements, pair.Key)
+		}
+	default:
+		return newError(object.TypeError, "cannot iterate over %s", iterable.Type())
+	}
+
+	for _, element := range elements {
+		if node.Variable.Resolved {
+			env.SetAt(node.Variable.Index, element)
+		} else {
+			env.Set(node.Variable.Value, element)
+		}
+
+		if result, done := in.iterate(ctx, node.Label, node.Body, env); done {
+			return result
+		}
+	}
+
+	return NULL
+}
+
+// iterate runs one iteration of the body of a loop labelled label. It
+// reports whether the loop is done, and if so the loop's result.
+func (in *Interpreter) iterate(ctx context.Context, label string, body *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	if err := ctx.Err(); err != nil {
+		return newCancelledError("evaluation stopped", err), true
+	}
+	if interrupted := in.checkSignals(ctx); interrupted != nil {
+		return interrupted, true
+	}
+
+	result := in.eval(ctx, body, env)
+	switch {
+	case (result == breakSignal || result == continueSignal) && (in.branchLabel == "" || in.branchLabel == label):
+		in.branchLabel = ""
+		return NULL, result == breakSignal
+	case isAbrupt(result):
+		return result, true
+	default:
+		return nil, false
+	}
+}
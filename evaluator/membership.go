@@ -0,0 +1,77 @@
+package evaluator
+
+import (
+	"bytes"
+	"monkey/object"
+	"strings"
+)
+
+// evalInExpression implements needle in container: element membership for
+// arrays, key membership for hashes and substring search for strings and
+// bytes.
+func evalInExpression(needle, container object.Object) object.Object {
+	switch container := container.(type) {
+	case *object.Array:
+		for _, element := range container.Elements {
+			if valuesEqual(needle, element) {
+				return TRUE
+			}
+		}
+		return FALSE
+	case *object.Hash:
+		key, ok := needle.(object.Hashable)
+		if !ok {
+			return newError(object.TypeError, "unusable as hash key: %s", needle.Type())
+		}
+		_, ok = container.Pairs[key.HashKey()]
+		return nativeBoolToBooleanObject(ok)
+	case *object.String:
+		sub, ok := needle.(*object.String)
+		if !ok {
+			return newError(object.TypeError, "left operand of `in` STRING must be STRING, got %s", needle.Type())
+		}
+		return nativeBoolToBooleanObject(strings.Contains(container.Value, sub.Value))
+	case *object.Bytes:
+		sub, ok := needle.(*object.Bytes)
+		if !ok {
+			return newError(object.TypeError, "left operand of `in` BYTES must be BYTES, got %s", needle.Type())
+		}
+		return nativeBoolToBooleanObject(bytes.Contains(container.Value, sub.Value))
+	default:
+		return newError(object.TypeError, "unknown operator: %s in %s", needle.Type(), container.Type())
+	}
+}
+
+// valuesEqual compares two values by content: numbers by value regardless
+// of integer or float, strings by text and arrays element by element.
+// Anything else is compared as == compares it.
+func valuesEqual(left, right object.Object) bool {
+	switch left := left.(type) {
+	case *object.Integer:
+		if right, ok := right.(*object.Integer); ok {
+			return left.Value == right.Value
+		}
+	case *object.String:
+		right, ok := right.(*object.String)
+		return ok && left.Value == right.Value
+	case *object.Array:
+		right, ok := right.(*object.Array)
+		if !ok || len(left.Elements) != len(right.Elements) {
+			return false
+		}
+		for i := range left.Elements {
+			if !valuesEqual(left.Elements[i], right.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if isNumber(left) && isNumber(right) {
+		l, _ := toFloat(left)
+		r, _ := toFloat(right)
+		return l == r
+	}
+
+	return left.Type() == right.Type() && objectsEqual(left, right)
+}
@@ -0,0 +1,135 @@
+package evaluator
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"monkey/object"
+)
+
+// defaultMemoizeLimit is how many results memoize keeps per function unless
+// given a limit.
+const defaultMemoizeLimit = 1024
+
+// memoizeCache remembers the results of calling fn, evicting the least
+// recently used one when it holds limit results.
+type memoizeCache struct {
+	in      *Interpreter
+	fn      object.Object
+	limit   int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type memoizeEntry struct {
+	key    string
+	args   []object.Object
+	result object.Object
+}
+
+// memoize implements the memoize builtin: memoize(fn) or memoize(fn, limit).
+func (in *Interpreter) memoize(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	if !isCallable(args[0]) {
+		return newError(object.TypeError, "argument to `memoize` must be FUNCTION, got %s", args[0].Type())
+	}
+	in.checkPure("memoize", args[0])
+
+	limit := defaultMemoizeLimit
+	if len(args) == 2 {
+		n, ok := args[1].(*object.Integer)
+		if !ok {
+			return newError(object.TypeError, "limit passed to `memoize` must be INTEGER, got %s", args[1].Type())
+		}
+		if n.Value < 1 {
+			return newError(object.ArgumentError, "limit passed to `memoize` must be positive, got %d", n.Value)
+		}
+		limit = int(n.Value)
+	}
+
+	cache := &memoizeCache{
+		in:      in,
+		fn:      args[0],
+		limit:   limit,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+
+	return &object.Builtin{Fn: cache.call}
+}
+
+// call returns the remembered result for args, calling the function on a
+// miss. Calls with arguments that cannot be hash keys and calls that fail
+// are never remembered.
+func (c *memoizeCache) call(ctx context.Context, args ...object.Object) object.Object {
+	key, ok := memoizeKey(args)
+	if !ok {
+		return c.in.applyFunction(ctx, c.fn, args)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*memoizeEntry)
+		if argumentsEqual(entry.args, args) {
+			c.order.MoveToFront(element)
+			return entry.result
+		}
+	}
+
+	result := c.in.applyFunction(ctx, c.fn, args)
+	if isError(result) {
+		return result
+	}
+
+	// The call may have filled the cache recursively, possibly with this
+	// very key.
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+	}
+
+	entry := &memoizeEntry{key: key, args: args, result: result}
+	c.entries[key] = c.order.PushFront(entry)
+
+	if c.order.Len() > c.limit {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoizeEntry).key)
+	}
+
+	return result
+}
+
+// memoizeKey combines the hash keys of args into one map key.
+func memoizeKey(args []object.Object) (string, bool) {
+	var buf []byte
+	for _, arg := range args {
+		hashable, ok := arg.(object.Hashable)
+		if !ok {
+			return "", false
+		}
+
+		hashKey := hashable.HashKey()
+		buf = append(buf, hashKey.Type...)
+		buf = append(buf, 0)
+		buf = binary.LittleEndian.AppendUint64(buf, hashKey.Value)
+	}
+
+	return string(buf), true
+}
+
+// argumentsEqual guards against hash key collisions.
+func argumentsEqual(a, b []object.Object) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Type() != b[i].Type() || a[i].Inspect() != b[i].Inspect() {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,70 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"runtime/metrics"
+	"time"
+)
+
+// Names of the metrics an interpreter reports. They follow Prometheus
+// naming conventions so hosts can export them unchanged.
+const (
+	// MetricEvaluations counts calls to EvalContext and CallContext.
+	MetricEvaluations = "monkey_evaluations_total"
+	// MetricErrors counts evaluations that ended in an error, labelled with
+	// the error's category.
+	MetricErrors = "monkey_errors_total"
+	// MetricEvalDuration is a histogram of how long evaluations took.
+	MetricEvalDuration = "monkey_eval_duration_seconds"
+	// MetricEvalAllocations is a histogram of the bytes allocated on the
+	// heap while evaluations ran. The Go runtime only counts allocations
+	// per process, so the bytes of anything else running meanwhile are
+	// included.
+	MetricEvalAllocations = "monkey_eval_allocated_bytes"
+)
+
+// Metrics receives counters and histogram observations from an interpreter.
+// Hosts implement it to feed a monitoring system. It is called on the
+// goroutine evaluating, so implementations shared between interpreters must
+// be safe for concurrent use.
+type Metrics interface {
+	// Inc adds one to the counter name for the given label values.
+	Inc(name string, labels ...string)
+	// Observe records value in the histogram name.
+	Observe(name string, value float64)
+}
+
+// WithMetrics reports each evaluation's outcome, duration and allocations
+// to m.
+func WithMetrics(m Metrics) Option {
+	return func(in *Interpreter) {
+		in.metrics = m
+	}
+}
+
+const heapAllocs = "/gc/heap/allocs:bytes"
+
+// measure starts measuring an evaluation. The returned function reports it
+// once it has produced result.
+func (in *Interpreter) measure() func(result object.Object) {
+	if in.metrics == nil {
+		return func(object.Object) {}
+	}
+
+	sample := []metrics.Sample{{Name: heapAllocs}}
+	metrics.Read(sample)
+	allocated := sample[0].Value.Uint64()
+	start := time.Now()
+
+	return func(result object.Object) {
+		elapsed := time.Since(start)
+		metrics.Read(sample)
+
+		in.metrics.Inc(MetricEvaluations)
+		if err, ok := result.(*object.Error); ok {
+			in.metrics.Inc(MetricErrors, string(err.EffectiveCategory()))
+		}
+		in.metrics.Observe(MetricEvalDuration, elapsed.Seconds())
+		in.metrics.Observe(MetricEvalAllocations, float64(sample[0].Value.Uint64()-allocated))
+	}
+}
@@ -0,0 +1,84 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+	"sort"
+	"strings"
+)
+
+// goModulePrefix marks an import path as naming a module the host provides.
+const goModulePrefix = "go:"
+
+// ModuleProvider supplies the functions of a module that programs import
+// with import "go:name".
+type ModuleProvider interface {
+	// Load returns the module's functions by name. An interpreter calls it
+	// once, when a program first imports the module.
+	Load(ctx context.Context) (map[string]*object.Builtin, error)
+}
+
+// Module is a ModuleProvider with a fixed set of functions.
+type Module map[string]*object.Builtin
+
+func (m Module) Load(ctx context.Context) (map[string]*object.Builtin, error) {
+	return m, nil
+}
+
+// WithModules lets programs import the given modules by name, so that
+// import "go:name" gives a hash of the functions of modules[name]. Like
+// builtins added by WithBuiltins, their results pass through
+// object.Canonical.
+func WithModules(modules map[string]ModuleProvider) Option {
+	return func(in *Interpreter) {
+		if in.modules == nil {
+			in.modules = make(map[string]ModuleProvider, len(modules))
+		}
+		for name, provider := range modules {
+			in.modules[name] = provider
+		}
+	}
+}
+
+// importModule evaluates import path to a new hash of the module's
+// functions, sorted by name.
+func (in *Interpreter) importModule(ctx context.Context, path string) object.Object {
+	name, ok := strings.CutPrefix(path, goModulePrefix)
+	if !ok {
+		return newError(object.NameError, "import %q: only host modules, named with a %q prefix, can be imported", path, goModulePrefix)
+	}
+
+	functions, ok := in.loaded[name]
+	if !ok {
+		provider, ok := in.modules[name]
+		if !ok {
+			return newError(object.NameError, "import %q: no such module", path)
+		}
+		loaded, err := provider.Load(ctx)
+		if err != nil {
+			return newCausedError(object.RuntimeError, err, "import %q: %s", path, err)
+		}
+
+		functions = make(map[string]*object.Builtin, len(loaded))
+		for fname, builtin := range loaded {
+			functions[fname] = hostBuiltin(builtin)
+		}
+		if in.loaded == nil {
+			in.loaded = make(map[string]map[string]*object.Builtin)
+		}
+		in.loaded[name] = functions
+	}
+
+	names := make([]string, 0, len(functions))
+	for fname := range functions {
+		names = append(names, fname)
+	}
+	sort.Strings(names)
+
+	module := &object.Hash{}
+	for _, fname := range names {
+		key := object.NewString(fname)
+		module.Set(key.HashKey(), object.HashPair{Key: key, Value: functions[fname]})
+	}
+	return module
+}
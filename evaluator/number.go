@@ -0,0 +1,232 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"math/bits"
+	"monkey/object"
+	"strconv"
+	"strings"
+)
+
+// builtinParseInt implements parse_int(string) and parse_int(string, base)
+// for bases 2 to 36. Surrounding whitespace is ignored.
+func builtinParseInt(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "argument to `parse_int` must be STRING, got %s", args[0].Type())
+	}
+
+	base := int64(10)
+	if len(args) == 2 {
+		var errObj *object.Error
+		if base, errObj = baseArgument("parse_int", args[1]); errObj != nil {
+			return errObj
+		}
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(str.Value), int(base), 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return newError(object.ArgumentError, "parse_int: %q is out of range", str.Value)
+	}
+	if err != nil {
+		return newError(object.ArgumentError, "parse_int: invalid integer %q in base %d", str.Value, base)
+	}
+
+	return object.NewInteger(n)
+}
+
+// builtinParseFloat implements parse_float(string). Surrounding whitespace
+// is ignored.
+func builtinParseFloat(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "argument to `parse_float` must be STRING, got %s", args[0].Type())
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(str.Value), 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return newError(object.ArgumentError, "parse_float: %q is out of range", str.Value)
+	}
+	if err != nil {
+		return newError(object.ArgumentError, "parse_float: invalid number %q", str.Value)
+	}
+
+	return &object.Float{Value: f}
+}
+
+// builtinToBase implements to_base(integer, base), formatting an integer in
+// a base from 2 to 36 with lower-case digits.
+func builtinToBase(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	n, ok := args[0].(*object.Integer)
+	if !ok {
+		return newError(object.TypeError, "argument to `to_base` must be INTEGER, got %s", args[0].Type())
+	}
+
+	base, errObj := baseArgument("to_base", args[1])
+	if errObj != nil {
+		return errObj
+	}
+
+	return object.NewString(strconv.FormatInt(n.Value, int(base)))
+}
+
+func baseArgument(builtin string, arg object.Object) (int64, *object.Error) {
+	base, ok := arg.(*object.Integer)
+	if !ok {
+		return 0, newError(object.TypeError, "base passed to `%s` must be INTEGER, got %s", builtin, arg.Type())
+	}
+	if base.Value < 2 || base.Value > 36 {
+		return 0, newError(object.ArgumentError, "base passed to `%s` must be from 2 to 36, got %d", builtin, base.Value)
+	}
+
+	return base.Value, nil
+}
+
+// builtinNumFormat implements num_format(number) and num_format(number,
+// options). The "decimals" option fixes the number of digits after the
+// point, rounding as needed; "thousands" separates groups of three integer
+// digits; "point" replaces the decimal point.
+func builtinNumFormat(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	decimals, thousands, point := -1, "", "."
+	if len(args) == 2 {
+		options, ok := args[1].(*object.Hash)
+		if !ok {
+			return newError(object.TypeError, "options passed to `num_format` must be HASH, got %s", args[1].Type())
+		}
+		for _, pair := range options.Ordered() {
+			switch key := pair.Key.Inspect(); key {
+			case "decimals":
+				n, ok := pair.Value.(*object.Integer)
+				if !ok {
+					return newError(object.TypeError, "option decimals for `num_format` must be INTEGER, got %s", pair.Value.Type())
+				}
+				if n.Value < 0 || n.Value > 100 {
+					return newError(object.ArgumentError, "option decimals for `num_format` must be from 0 to 100, got %d", n.Value)
+				}
+				decimals = int(n.Value)
+			case "thousands", "point":
+				s, ok := pair.Value.(*object.String)
+				if !ok {
+					return newError(object.TypeError, "option %s for `num_format` must be STRING, got %s", key, pair.Value.Type())
+				}
+				if key == "thousands" {
+					thousands = s.Value
+				} else {
+					point = s.Value
+				}
+			default:
+				return newError(object.ArgumentError, "unknown option %s for `num_format`", pair.Key.Inspect())
+			}
+		}
+	}
+
+	var digits string
+	switch n := args[0].(type) {
+	case *object.Integer:
+		digits = strconv.FormatInt(n.Value, 10)
+		if decimals > 0 {
+			digits += "." + strings.Repeat("0", decimals)
+		}
+	case *object.Float:
+		digits = strconv.FormatFloat(n.Value, 'f', decimals, 64)
+	default:
+		return newError(object.TypeError, "argument to `num_format` must be INTEGER or FLOAT, got %s", args[0].Type())
+	}
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	whole, fraction, hasFraction := strings.Cut(digits, ".")
+
+	var out strings.Builder
+	out.WriteString(sign)
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			out.WriteString(thousands)
+		}
+		out.WriteRune(digit)
+	}
+	if hasFraction {
+		out.WriteString(point)
+		out.WriteString(fraction)
+	}
+
+	return object.NewString(out.String())
+}
+
+// radixBuiltin returns the builtin for name(integer) and name(integer,
+// width), formatting in base and zero-padding the digits to width.
+func radixBuiltin(name string, base int) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+
+			n, ok := args[0].(*object.Integer)
+			if !ok {
+				return newError(object.TypeError, "argument to `%s` must be INTEGER, got %s", name, args[0].Type())
+			}
+
+			width := int64(0)
+			if len(args) == 2 {
+				w, ok := args[1].(*object.Integer)
+				if !ok {
+					return newError(object.TypeError, "width passed to `%s` must be INTEGER, got %s", name, args[1].Type())
+				}
+				if w.Value < 0 || w.Value > 64 {
+					return newError(object.ArgumentError, "width passed to `%s` must be from 0 to 64, got %d", name, w.Value)
+				}
+				width = w.Value
+			}
+
+			digits := strconv.FormatInt(n.Value, base)
+			sign := ""
+			if n.Value < 0 {
+				sign, digits = "-", digits[1:]
+			}
+			if pad := int(width) - len(digits); pad > 0 {
+				digits = strings.Repeat("0", pad) + digits
+			}
+
+			return object.NewString(sign + digits)
+		},
+		Pure: true,
+	}
+}
+
+// builtinBitCount implements bit_count(integer), the number of set bits in
+// a non-negative integer.
+func builtinBitCount(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	n, ok := args[0].(*object.Integer)
+	if !ok {
+		return newError(object.TypeError, "argument to `bit_count` must be INTEGER, got %s", args[0].Type())
+	}
+	if n.Value < 0 {
+		return newError(object.ArgumentError, "argument to `bit_count` must not be negative, got %d", n.Value)
+	}
+
+	return object.NewInteger(int64(bits.OnesCount64(uint64(n.Value))))
+}
@@ -0,0 +1,336 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"monkey/object"
+	"strconv"
+)
+
+// byteOrder is implemented by the byte orders of encoding/binary.
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// maxPackSize bounds the bytes a pack format describes, so a large repeat
+// count cannot exhaust memory.
+const maxPackSize = 16 << 20
+
+// packField is one item of a pack format: a code and how many times it
+// repeats, or for s the length of the byte string.
+type packField struct {
+	code  byte
+	count int
+}
+
+// parsePackFormat parses formats in the style of Python's struct module. An
+// optional first character picks the byte order: < little-endian, > or !
+// big-endian, = native. Big-endian is the default. Codes, each optionally
+// preceded by a repeat count:
+//
+//	b B  signed and unsigned 8-bit integer
+//	h H  signed and unsigned 16-bit integer
+//	i I  signed and unsigned 32-bit integer
+//	q Q  signed and unsigned 64-bit integer
+//	?    boolean byte
+//	x    padding byte, no value
+//	s    byte string; the count is its length
+//
+// Formats describing more than maxPackSize bytes are an error.
+func parsePackFormat(format string) (byteOrder, []packField, error) {
+	var order byteOrder = binary.BigEndian
+	if len(format) > 0 {
+		switch format[0] {
+		case '<':
+			order = binary.LittleEndian
+			format = format[1:]
+		case '>', '!':
+			format = format[1:]
+		case '=':
+			order = binary.NativeEndian
+			format = format[1:]
+		}
+	}
+
+	var fields []packField
+	size := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] == ' ' {
+			continue
+		}
+
+		start := i
+		for i < len(format) && isDigit(format[i]) {
+			i++
+		}
+		count := 1
+		if i > start {
+			n, err := strconv.Atoi(format[start:i])
+			if err != nil {
+				return nil, nil, fmt.Errorf("bad count %q", format[start:i])
+			}
+			count = n
+		}
+		if i == len(format) {
+			return nil, nil, fmt.Errorf("count %d is not followed by a code", count)
+		}
+
+		switch code := format[i]; code {
+		case 'b', 'B', 'h', 'H', 'i', 'I', 'q', 'Q', '?', 'x', 's':
+			fields = append(fields, packField{code: code, count: count})
+		default:
+			return nil, nil, fmt.Errorf("unknown code %q", code)
+		}
+
+		// Checking count first keeps the product from overflowing.
+		if count > maxPackSize || size+count*packSize(format[i]) > maxPackSize {
+			return nil, nil, fmt.Errorf("format describes more than %d bytes", maxPackSize)
+		}
+		size += count * packSize(format[i])
+	}
+
+	return order, fields, nil
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func packSize(code byte) int {
+	switch code {
+	case 'h', 'H':
+		return 2
+	case 'i', 'I':
+		return 4
+	case 'q', 'Q':
+		return 8
+	default:
+		return 1
+	}
+}
+
+// packLimits returns the range of integers code can hold.
+func packLimits(code byte) (int64, int64) {
+	switch code {
+	case 'b':
+		return math.MinInt8, math.MaxInt8
+	case 'B':
+		return 0, math.MaxUint8
+	case 'h':
+		return math.MinInt16, math.MaxInt16
+	case 'H':
+		return 0, math.MaxUint16
+	case 'i':
+		return math.MinInt32, math.MaxInt32
+	case 'I':
+		return 0, math.MaxUint32
+	case 'q':
+		return math.MinInt64, math.MaxInt64
+	default:
+		// Monkey integers are signed, so Q only holds non-negative ones.
+		return 0, math.MaxInt64
+	}
+}
+
+// builtinPack implements pack(format, values...), returning bytes.
+func builtinPack(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or more", len(args))
+	}
+
+	format, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "format passed to `pack` must be STRING, got %s", args[0].Type())
+	}
+
+	order, fields, err := parsePackFormat(format.Value)
+	if err != nil {
+		return newError(object.ArgumentError, "pack: %s", err)
+	}
+
+	values := args[1:]
+	next := func() (object.Object, bool) {
+		if len(values) == 0 {
+			return nil, false
+		}
+		value := values[0]
+		values = values[1:]
+		return value, true
+	}
+
+	var out []byte
+	for _, field := range fields {
+		switch field.code {
+		case 'x':
+			out = append(out, make([]byte, field.count)...)
+			continue
+		case 's':
+			value, ok := next()
+			if !ok {
+				return newError(object.ArgumentError, "pack: not enough values for format %q", format.Value)
+			}
+			var data []byte
+			switch value := value.(type) {
+			case *object.Bytes:
+				data = value.Value
+			case *object.String:
+				data = []byte(value.Value)
+			default:
+				return newError(object.TypeError, "pack: s needs BYTES or STRING, got %s", value.Type())
+			}
+			padded := make([]byte, field.count)
+			copy(padded, data)
+			out = append(out, padded...)
+			continue
+		}
+
+		for n := 0; n < field.count; n++ {
+			value, ok := next()
+			if !ok {
+				return newError(object.ArgumentError, "pack: not enough values for format %q", format.Value)
+			}
+
+			if field.code == '?' {
+				if IsTruthy(value) {
+					out = append(out, 1)
+				} else {
+					out = append(out, 0)
+				}
+				continue
+			}
+
+			integer, ok := value.(*object.Integer)
+			if !ok {
+				return newError(object.TypeError, "pack: %c needs INTEGER, got %s", field.code, value.Type())
+			}
+			low, high := packLimits(field.code)
+			if integer.Value < low || integer.Value > high {
+				return newError(object.ArgumentError, "pack: %d does not fit in %c", integer.Value, field.code)
+			}
+
+			switch packSize(field.code) {
+			case 1:
+				out = append(out, byte(integer.Value))
+			case 2:
+				out = order.AppendUint16(out, uint16(integer.Value))
+			case 4:
+				out = order.AppendUint32(out, uint32(integer.Value))
+			case 8:
+				out = order.AppendUint64(out, uint64(integer.Value))
+			}
+		}
+	}
+
+	if len(values) != 0 {
+		return newError(object.ArgumentError, "pack: %d values left over for format %q", len(values), format.Value)
+	}
+
+	return &object.Bytes{Value: out}
+}
+
+// builtinUnpack implements unpack(format, data) and unpack(format, data,
+// offset), returning an array of the values read from data starting at
+// offset. data may be longer than the format needs.
+func builtinUnpack(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2 or 3", len(args))
+	}
+
+	format, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "format passed to `unpack` must be STRING, got %s", args[0].Type())
+	}
+
+	data, ok := args[1].(*object.Bytes)
+	if !ok {
+		return newError(object.TypeError, "data passed to `unpack` must be BYTES, got %s", args[1].Type())
+	}
+
+	offset := int64(0)
+	if len(args) == 3 {
+		n, ok := args[2].(*object.Integer)
+		if !ok {
+			return newError(object.TypeError, "offset passed to `unpack` must be INTEGER, got %s", args[2].Type())
+		}
+		if n.Value < 0 || n.Value > int64(len(data.Value)) {
+			return newError(object.ArgumentError, "unpack: offset %d out of range", n.Value)
+		}
+		offset = n.Value
+	}
+
+	order, fields, err := parsePackFormat(format.Value)
+	if err != nil {
+		return newError(object.ArgumentError, "unpack: %s", err)
+	}
+
+	buf := data.Value[offset:]
+	take := func(n int) ([]byte, bool) {
+		if len(buf) < n {
+			return nil, false
+		}
+		chunk := buf[:n]
+		buf = buf[n:]
+		return chunk, true
+	}
+	short := func() object.Object {
+		return newError(object.ArgumentError, "unpack: data too short for format %q", format.Value)
+	}
+
+	var values []object.Object
+	for _, field := range fields {
+		switch field.code {
+		case 'x':
+			if _, ok := take(field.count); !ok {
+				return short()
+			}
+			continue
+		case 's':
+			chunk, ok := take(field.count)
+			if !ok {
+				return short()
+			}
+			values = append(values, &object.Bytes{Value: append([]byte(nil), chunk...)})
+			continue
+		}
+
+		for n := 0; n < field.count; n++ {
+			chunk, ok := take(packSize(field.code))
+			if !ok {
+				return short()
+			}
+
+			var value int64
+			switch field.code {
+			case '?':
+				values = append(values, nativeBoolToBooleanObject(chunk[0] != 0))
+				continue
+			case 'b':
+				value = int64(int8(chunk[0]))
+			case 'B':
+				value = int64(chunk[0])
+			case 'h':
+				value = int64(int16(order.Uint16(chunk)))
+			case 'H':
+				value = int64(order.Uint16(chunk))
+			case 'i':
+				value = int64(int32(order.Uint32(chunk)))
+			case 'I':
+				value = int64(order.Uint32(chunk))
+			case 'q':
+				value = int64(order.Uint64(chunk))
+			case 'Q':
+				u := order.Uint64(chunk)
+				if u > math.MaxInt64 {
+					return newError(object.ArgumentError, "unpack: %d does not fit in an INTEGER", u)
+				}
+				value = int64(u)
+			}
+			values = append(values, object.NewInteger(value))
+		}
+	}
+
+	return &object.Array{Elements: values}
+}
@@ -0,0 +1,67 @@
+package evaluator
+
+import "testing"
+
+func TestPack(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`pack(">H", 258)`, `b"\x01\x02"`},
+		{`pack("<H", 258)`, `b"\x02\x01"`},
+		{`pack("!I", 1)`, `b"\x00\x00\x00\x01"`},
+		{`pack("H", 258)`, `b"\x01\x02"`},
+		{`pack("<bB", -1, 255)`, `b"\xff\xff"`},
+		{`pack("<2h", -2, 3)`, `b"\xfe\xff\x03\x00"`},
+		{`pack("<q", -1)`, `b"\xff\xff\xff\xff\xff\xff\xff\xff"`},
+		{`pack("4s", "PNG")`, `b"PNG\x00"`},
+		{`pack("2s", bytes("long"))`, `b"lo"`},
+		{`pack("B2x?", 7, true)`, `b"\a\x00\x00\x01"`},
+		{`pack("")`, `b""`},
+		{`pack("B", 256)`, "ERROR: pack: 256 does not fit in B"},
+		{`pack("Q", -1)`, "ERROR: pack: -1 does not fit in Q"},
+		{`pack("2B", 1)`, "ERROR: pack: not enough values for format \"2B\""},
+		{`pack("B", 1, 2)`, "ERROR: pack: 1 values left over for format \"B\""},
+		{`pack("B", "x")`, "ERROR: pack: B needs INTEGER, got STRING"},
+		{`pack("f", 1)`, "ERROR: pack: unknown code 'f'"},
+		{`pack("3", 1)`, "ERROR: pack: count 3 is not followed by a code"},
+		{`pack("<9223372036854775807x")`, "ERROR: pack: format describes more than 16777216 bytes"},
+		{`pack("<1000000000x")`, "ERROR: pack: format describes more than 16777216 bytes"},
+		{`pack("<3000000q")`, "ERROR: pack: format describes more than 16777216 bytes"},
+		{`pack("16777216x1x")`, "ERROR: pack: format describes more than 16777216 bytes"},
+		{`pack("99999999999999999999x")`, "ERROR: pack: bad count \"99999999999999999999\""},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`unpack(">H", bytes([1, 2]))`, "[258]"},
+		{`unpack("<H", bytes([1, 2]))`, "[513]"},
+		{`unpack("<bBhHiIqQ", pack("<bBhHiIqQ", -5, 250, -300, 60000, -70000, 4000000000, -9, 9))`, "[-5, 250, -300, 60000, -70000, 4000000000, -9, 9]"},
+		{`unpack("4sx?", bytes("GIF8") + bytes([0, 1]))`, `[b"GIF8", true]`},
+		{`unpack(">I", bytes([0, 0, 0, 0, 42]), 1)`, "[42]"},
+		{`unpack("B", bytes([1, 2, 3]))`, "[1]"},
+		{`unpack(">I", bytes([1, 2]))`, "ERROR: unpack: data too short for format \">I\""},
+		{`unpack(">Q", bytes([255, 255, 255, 255, 255, 255, 255, 255]))`, "ERROR: unpack: 18446744073709551615 does not fit in an INTEGER"},
+		{`unpack("B", bytes([1]), 2)`, "ERROR: unpack: offset 2 out of range"},
+		{`unpack("B", "x")`, "ERROR: data passed to `unpack` must be BYTES, got STRING"},
+		{`unpack("9223372036854775807s", bytes([1]))`, "ERROR: unpack: format describes more than 16777216 bytes"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
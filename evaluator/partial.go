@@ -0,0 +1,103 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+)
+
+// isCallable reports whether obj can be called like a function.
+func isCallable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Function, *object.Builtin:
+		return true
+	default:
+		return false
+	}
+}
+
+// partial returns a function that calls args[0] with args[1:] followed by
+// the arguments it is called with.
+func (in *Interpreter) partial(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=at least 1", len(args))
+	}
+	if !isCallable(args[0]) {
+		return newError(object.TypeError, "argument to `partial` must be FUNCTION, got %s", args[0].Type())
+	}
+
+	return in.bind(args[0], args[1:])
+}
+
+func (in *Interpreter) bind(fn object.Object, bound []object.Object) *object.Builtin {
+	bound = bound[:len(bound):len(bound)]
+	return &object.Builtin{Fn: func(ctx context.Context, args ...object.Object) object.Object {
+		return in.applyFunction(ctx, fn, append(bound, args...))
+	}}
+}
+
+// curry returns a function that collects arguments over any number of calls
+// and calls args[0] once it has as many as the function declares. Builtins
+// declare no parameters, so their arity must be passed as args[1].
+func (in *Interpreter) curry(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+	if !isCallable(args[0]) {
+		return newError(object.TypeError, "argument to `curry` must be FUNCTION, got %s", args[0].Type())
+	}
+
+	var arity int
+	switch {
+	case len(args) == 2:
+		n, ok := args[1].(*object.Integer)
+		if !ok {
+			return newError(object.TypeError, "arity passed to `curry` must be INTEGER, got %s", args[1].Type())
+		}
+		if n.Value < 0 {
+			return newError(object.ArgumentError, "arity passed to `curry` must not be negative, got %d", n.Value)
+		}
+		arity = int(n.Value)
+	case args[0].Type() == object.FUNCTION_OBJ:
+		arity = len(args[0].(*object.Function).Parameters)
+	default:
+		return newError(object.ArgumentError, "`curry` needs the arity of a builtin function")
+	}
+
+	return in.curried(args[0], arity, nil)
+}
+
+func (in *Interpreter) curried(fn object.Object, arity int, bound []object.Object) *object.Builtin {
+	bound = bound[:len(bound):len(bound)]
+	return &object.Builtin{Fn: func(ctx context.Context, args ...object.Object) object.Object {
+		all := append(bound, args...)
+		if len(all) < arity {
+			return in.curried(fn, arity, all)
+		}
+		return in.applyFunction(ctx, fn, all)
+	}}
+}
+
+// compose returns a function that calls the last of args with the arguments
+// it is called with, then each earlier function with the previous result.
+func (in *Interpreter) compose(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=at least 1", len(args))
+	}
+	for _, arg := range args {
+		if !isCallable(arg) {
+			return newError(object.TypeError, "argument to `compose` must be FUNCTION, got %s", arg.Type())
+		}
+	}
+
+	fns := append([]object.Object(nil), args...)
+	return &object.Builtin{Fn: func(ctx context.Context, args ...object.Object) object.Object {
+		result := in.applyFunction(ctx, fns[len(fns)-1], args)
+		for i := len(fns) - 2; i >= 0; i-- {
+			if isError(result) {
+				return result
+			}
+			result = in.applyFunction(ctx, fns[i], []object.Object{result})
+		}
+		return result
+	}}
+}
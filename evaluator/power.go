@@ -0,0 +1,42 @@
+package evaluator
+
+import (
+	"math"
+	"monkey/object"
+)
+
+// integerPower raises base to exp by repeated squaring. A negative exponent
+// gives a float, and a result that does not fit in an int64 is an error
+// rather than a silently wrapped number.
+func integerPower(base, exp int64) object.Object {
+	if exp < 0 {
+		return &object.Float{Value: math.Pow(float64(base), float64(exp))}
+	}
+
+	result, square, n := int64(1), base, exp
+	for ok := true; ok; {
+		if n&1 == 1 {
+			if result, ok = multiply(result, square); !ok {
+				break
+			}
+		}
+		if n >>= 1; n == 0 {
+			return object.NewInteger(result)
+		}
+		square, ok = multiply(square, square)
+	}
+
+	return newError(object.RuntimeError, "integer overflow in %d ** %d", base, exp)
+}
+
+// multiply returns a * b and whether the product fits in an int64.
+func multiply(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	product := a * b
+	return product, product/b == a
+}
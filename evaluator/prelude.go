@@ -0,0 +1,56 @@
+package evaluator
+
+import (
+	_ "embed"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"sync"
+)
+
+// preludeSource defines functions written in Monkey that programs can call
+// like builtins:
+//
+//	map(xs, f)               the results of f for each element of xs
+//	filter(xs, pred)         the elements of xs for which pred is truthy
+//	reduce(xs, initial, f)   the elements of xs combined from the left
+//	reverse(xs)              the elements of xs in reverse order
+//	range(start, end)        the integers from start up to but not end
+//
+//go:embed prelude.mky
+var preludeSource string
+
+var (
+	preludeOnce sync.Once
+	preludeEnv  *object.Environment
+)
+
+// standardPrelude evaluates the prelude once, into a frozen environment
+// that every interpreter shares.
+func standardPrelude() *object.Environment {
+	preludeOnce.Do(loadPrelude)
+	return preludeEnv
+}
+
+func loadPrelude() {
+	p := parser.New(lexer.New(preludeSource))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		panic("evaluator: prelude.mky: " + p.Errors()[0])
+	}
+
+	env := object.NewEnvironment()
+	if result := New(WithoutPrelude()).Eval(program, env); isError(result) {
+		panic("evaluator: prelude.mky: " + result.Inspect())
+	}
+	env.Freeze()
+	preludeEnv = env
+}
+
+// WithoutPrelude leaves out the functions of the standard prelude, such as
+// map and filter, so that only builtins are predefined.
+func WithoutPrelude() Option {
+	return func(in *Interpreter) {
+		in.noPrelude = true
+	}
+}
@@ -0,0 +1,356 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"monkey/object"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GENERATOR_OBJ is the type of the value generators forall draws from.
+const GENERATOR_OBJ = "GENERATOR"
+
+const (
+	// defaultRuns is how many sets of values forall tries by default.
+	defaultRuns = 100
+
+	// maxShrinks bounds how many times forall simplifies a counterexample.
+	maxShrinks = 1000
+)
+
+// Generator produces random values for property tests and proposes
+// simpler variants of a value, simplest first, to shrink counterexamples.
+type Generator struct {
+	desc     string
+	generate func(r *rand.Rand) object.Object
+	shrink   func(v object.Object) []object.Object
+}
+
+func (g *Generator) Type() object.ObjectType { return GENERATOR_OBJ }
+func (g *Generator) Inspect() string         { return "<generator " + g.desc + ">" }
+
+// builtinGenInt implements gen_int(lo, hi), generating integers from lo to
+// hi inclusive that shrink toward the one nearest zero.
+func builtinGenInt(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	lo, ok := args[0].(*object.Integer)
+	if !ok {
+		return newError(object.TypeError, "bounds passed to `gen_int` must be INTEGER, got %s", args[0].Type())
+	}
+	hi, ok := args[1].(*object.Integer)
+	if !ok {
+		return newError(object.TypeError, "bounds passed to `gen_int` must be INTEGER, got %s", args[1].Type())
+	}
+	if lo.Value > hi.Value {
+		return newError(object.ArgumentError, "bounds passed to `gen_int` must not be reversed, got %d and %d", lo.Value, hi.Value)
+	}
+
+	target := min(max(0, lo.Value), hi.Value)
+	return &Generator{
+		desc: fmt.Sprintf("int(%d, %d)", lo.Value, hi.Value),
+		generate: func(r *rand.Rand) object.Object {
+			return object.NewInteger(lo.Value + int64(r.Uint64N(uint64(hi.Value-lo.Value)+1)))
+		},
+		shrink: func(v object.Object) []object.Object {
+			n := v.(*object.Integer).Value
+			if n == target {
+				return nil
+			}
+			candidates := []object.Object{object.NewInteger(target)}
+			for d := (n - target) / 2; d != 0; d /= 2 {
+				candidates = append(candidates, object.NewInteger(n-d))
+			}
+			return candidates
+		},
+	}
+}
+
+// builtinGenBool implements gen_bool(), generating booleans that shrink to
+// false.
+func builtinGenBool(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	return &Generator{
+		desc:     "bool",
+		generate: func(r *rand.Rand) object.Object { return nativeBoolToBooleanObject(r.IntN(2) == 1) },
+		shrink: func(v object.Object) []object.Object {
+			if v == TRUE {
+				return []object.Object{FALSE}
+			}
+			return nil
+		},
+	}
+}
+
+// builtinGenString implements gen_string(max_len), generating strings of
+// up to max_len lower-case letters that shrink by dropping letters and
+// then by replacing them with a.
+func builtinGenString(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	maxLen, errObj := maxLenArgument("gen_string", args[0])
+	if errObj != nil {
+		return errObj
+	}
+
+	return &Generator{
+		desc: fmt.Sprintf("string(%d)", maxLen),
+		generate: func(r *rand.Rand) object.Object {
+			letters := make([]byte, r.IntN(maxLen+1))
+			for i := range letters {
+				letters[i] = 'a' + byte(r.IntN(26))
+			}
+			return object.NewString(string(letters))
+		},
+		shrink: func(v object.Object) []object.Object {
+			s := v.(*object.String).Value
+			var candidates []object.Object
+			for i := range s {
+				candidates = append(candidates, object.NewString(s[:i]+s[i+1:]))
+			}
+			for i := range s {
+				if s[i] != 'a' {
+					candidates = append(candidates, object.NewString(s[:i]+"a"+s[i+1:]))
+				}
+			}
+			return candidates
+		},
+	}
+}
+
+// builtinGenArray implements gen_array(gen, max_len), generating arrays of
+// up to max_len values from gen that shrink by dropping elements and then
+// by shrinking them.
+func builtinGenArray(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	elem, ok := args[0].(*Generator)
+	if !ok {
+		return newError(object.TypeError, "generator passed to `gen_array` must be GENERATOR, got %s", args[0].Type())
+	}
+	maxLen, errObj := maxLenArgument("gen_array", args[1])
+	if errObj != nil {
+		return errObj
+	}
+
+	return &Generator{
+		desc: fmt.Sprintf("array(%s, %d)", elem.desc, maxLen),
+		generate: func(r *rand.Rand) object.Object {
+			elements := make([]object.Object, r.IntN(maxLen+1))
+			for i := range elements {
+				elements[i] = elem.generate(r)
+			}
+			return &object.Array{Elements: elements}
+		},
+		shrink: func(v object.Object) []object.Object {
+			elements := v.(*object.Array).Elements
+			var candidates []object.Object
+			for i := range elements {
+				candidates = append(candidates, &object.Array{Elements: append(elements[:i:i], elements[i+1:]...)})
+			}
+			for i, element := range elements {
+				for _, smaller := range elem.shrink(element) {
+					shrunk := append([]object.Object(nil), elements...)
+					shrunk[i] = smaller
+					candidates = append(candidates, &object.Array{Elements: shrunk})
+				}
+			}
+			return candidates
+		},
+	}
+}
+
+// builtinGenOneOf implements gen_one_of(values), choosing among the
+// elements of an array and shrinking toward the earlier ones.
+func builtinGenOneOf(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	values, ok := args[0].(*object.Array)
+	if !ok {
+		return newError(object.TypeError, "argument to `gen_one_of` must be ARRAY, got %s", args[0].Type())
+	}
+	if len(values.Elements) == 0 {
+		return newError(object.ArgumentError, "argument to `gen_one_of` must not be empty")
+	}
+	choices := append([]object.Object(nil), values.Elements...)
+
+	return &Generator{
+		desc:     fmt.Sprintf("one_of(%d)", len(choices)),
+		generate: func(r *rand.Rand) object.Object { return choices[r.IntN(len(choices))] },
+		shrink: func(v object.Object) []object.Object {
+			for i, choice := range choices {
+				if choice == v {
+					return choices[:i:i]
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func maxLenArgument(builtin string, arg object.Object) (int, *object.Error) {
+	n, ok := arg.(*object.Integer)
+	if !ok {
+		return 0, newError(object.TypeError, "length passed to `%s` must be INTEGER, got %s", builtin, arg.Type())
+	}
+	if n.Value < 0 || n.Value > 1<<16 {
+		return 0, newError(object.ArgumentError, "length passed to `%s` must be from 0 to 65536, got %d", builtin, n.Value)
+	}
+	return int(n.Value), nil
+}
+
+// builtinForall implements forall(gens..., property) and forall(gens...,
+// property, options), calling property with one value from each generator
+// until it returns false or fails. The failing values are then shrunk to
+// the simplest that still fail and reported in an error with the seed that
+// reproduces them. The "seed" option fixes the seed, which is otherwise
+// drawn fresh and kept in a replay log; "runs" sets how many sets of
+// values are tried. forall returns true if every run passes.
+func (in *Interpreter) builtinForall(ctx context.Context, args ...object.Object) object.Object {
+	var options *object.Hash
+	if len(args) > 0 {
+		if hash, ok := args[len(args)-1].(*object.Hash); ok {
+			options, args = hash, args[:len(args)-1]
+		}
+	}
+	if len(args) < 2 {
+		return newError(object.ArgumentError, "`forall` needs at least one generator and a property")
+	}
+
+	property := args[len(args)-1]
+	if !isCallable(property) {
+		return newError(object.TypeError, "property passed to `forall` must be FUNCTION, got %s", property.Type())
+	}
+	gens := make([]*Generator, len(args)-1)
+	for i, arg := range args[:len(args)-1] {
+		gen, ok := arg.(*Generator)
+		if !ok {
+			return newError(object.TypeError, "generator passed to `forall` must be GENERATOR, got %s", arg.Type())
+		}
+		gens[i] = gen
+	}
+
+	runs, seed, seeded := int64(defaultRuns), int64(0), false
+	if options != nil {
+		for _, pair := range options.Ordered() {
+			switch key := pair.Key.Inspect(); key {
+			case "runs", "seed":
+				n, ok := pair.Value.(*object.Integer)
+				if !ok {
+					return newError(object.TypeError, "option %s for `forall` must be INTEGER, got %s", key, pair.Value.Type())
+				}
+				if key == "seed" {
+					seed, seeded = n.Value, true
+				} else if runs = n.Value; runs < 1 {
+					return newError(object.ArgumentError, "option runs for `forall` must be positive, got %d", runs)
+				}
+			default:
+				return newError(object.ArgumentError, "unknown option %s for `forall`", key)
+			}
+		}
+	}
+	if !seeded {
+		value, errObj := in.nondeterministic("forall", func() (string, *object.Error) {
+			return strconv.FormatInt(time.Now().UnixNano(), 10), nil
+		})
+		if errObj != nil {
+			return errObj
+		}
+		var err error
+		if seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return newError(object.RuntimeError, "replay: invalid seed %q for forall", value)
+		}
+	}
+
+	r := rand.New(rand.NewPCG(uint64(seed), uint64(seed)))
+	for run := int64(1); run <= runs; run++ {
+		values := make([]object.Object, len(gens))
+		for i, gen := range gens {
+			values[i] = gen.generate(r)
+		}
+
+		reason, errObj := in.falsify(ctx, property, values)
+		if errObj != nil {
+			return errObj
+		}
+		if reason == "" {
+			continue
+		}
+
+		values, reason, errObj = in.shrinkCounterexample(ctx, property, gens, values, reason)
+		if errObj != nil {
+			return errObj
+		}
+		inspected := make([]string, len(values))
+		for i, value := range values {
+			inspected[i] = value.Inspect()
+		}
+		return newError(object.RuntimeError, "forall: property failed on run %d with seed %d; smallest counterexample (%s): %s",
+			run, seed, strings.Join(inspected, ", "), reason)
+	}
+
+	return TRUE
+}
+
+// falsify calls property with values and returns why it failed, or "" if
+// it held. Cancellation is returned as an error rather than a failure.
+func (in *Interpreter) falsify(ctx context.Context, property object.Object, values []object.Object) (string, *object.Error) {
+	if err := ctx.Err(); err != nil {
+		return "", newCancelledError("forall", err)
+	}
+
+	switch result := in.applyFunction(ctx, property, values).(type) {
+	case *object.Error:
+		if result.Category == object.CancelledError {
+			return "", result
+		}
+		return result.Message, nil
+	case *object.Boolean:
+		if !result.Value {
+			return "returned false", nil
+		}
+	}
+	return "", nil
+}
+
+// shrinkCounterexample repeatedly replaces values with the first simpler
+// variant that still makes property fail, until none does.
+func (in *Interpreter) shrinkCounterexample(ctx context.Context, property object.Object, gens []*Generator, values []object.Object, reason string) ([]object.Object, string, *object.Error) {
+	for shrinks := 0; shrinks < maxShrinks; shrinks++ {
+		shrunk := false
+	search:
+		for i, gen := range gens {
+			for _, candidate := range gen.shrink(values[i]) {
+				attempt := append([]object.Object(nil), values...)
+				attempt[i] = candidate
+
+				why, errObj := in.falsify(ctx, property, attempt)
+				if errObj != nil {
+					return nil, "", errObj
+				}
+				if why != "" {
+					values, reason, shrunk = attempt, why, true
+					break search
+				}
+			}
+		}
+		if !shrunk {
+			break
+		}
+	}
+	return values, reason, nil
+}
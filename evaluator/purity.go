@@ -0,0 +1,114 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/diagnostic"
+	"monkey/object"
+)
+
+// IsPure reports whether calling fn can have no effect beyond computing its
+// result, so that reusing an earlier result or calling it from several
+// places at once is safe. The analysis is conservative: a function is pure
+// only if it assigns to no variable of its own scopes' surroundings and
+// only calls builtins marked Pure, functions defined inside it, and global
+// functions that are pure themselves. Calls to functions it receives as
+// arguments make it impure, since they cannot be checked.
+func (in *Interpreter) IsPure(fn object.Object) bool {
+	return in.isPure(fn, make(map[*object.Function]bool))
+}
+
+// isPure is IsPure, with checking holding the functions whose analysis is
+// under way. A recursive call assumes the function being checked is pure;
+// if it is not, the outermost analysis finds out.
+func (in *Interpreter) isPure(fn object.Object, checking map[*object.Function]bool) bool {
+	switch fn := fn.(type) {
+	case *object.Builtin:
+		return fn.Pure
+	case *object.Function:
+		if checking[fn] {
+			return true
+		}
+		checking[fn] = true
+		return in.pureBody(fn, fn.Body, 0, make(map[string]bool), checking)
+	default:
+		return false
+	}
+}
+
+// pureBody checks node, found at nesting function literals inside fn.
+// Identifiers resolved to fewer than nesting+1 scopes up are locals of fn
+// or of a function literal inside it. letFunctions holds the locals bound
+// to function literals, whose bodies are checked where they are defined.
+func (in *Interpreter) pureBody(fn *object.Function, node ast.Node, nesting int, letFunctions map[string]bool, checking map[*object.Function]bool) bool {
+	local := func(ident *ast.Identifier) bool {
+		return ident.Resolved && ident.Depth <= nesting
+	}
+
+	pure := true
+	ast.Inspect(node, func(n ast.Node) bool {
+		if !pure {
+			return false
+		}
+
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			if n.Body != nil {
+				pure = in.pureBody(fn, n.Body, nesting+1, letFunctions, checking)
+			}
+			return false
+		case *ast.LetStatement:
+			if _, ok := n.Value.(*ast.FunctionLiteral); ok && n.Name != nil {
+				letFunctions[n.Name.Value] = true
+			}
+		case *ast.AssignExpression:
+			pure = local(n.Name)
+		case *ast.CallExpression:
+			pure = in.pureCallee(fn, n.Function, local, letFunctions, checking)
+		}
+		return pure
+	})
+
+	return pure
+}
+
+func (in *Interpreter) pureCallee(fn *object.Function, callee ast.Expression, local func(*ast.Identifier) bool, letFunctions map[string]bool, checking map[*object.Function]bool) bool {
+	switch callee := callee.(type) {
+	case *ast.FunctionLiteral:
+		// Checked when the walk reaches it.
+		return true
+	case *ast.Identifier:
+		if local(callee) {
+			return letFunctions[callee.Value]
+		}
+		if callee.Resolved {
+			// A local of a function enclosing fn; its value depends on the
+			// call that created fn.
+			return false
+		}
+
+		if value, ok := fn.Env.Get(callee.Value); ok {
+			return in.isPure(value, checking)
+		}
+		if builtin, ok := in.builtins[callee.Value]; ok {
+			return builtin.Pure
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// checkPure warns through the interpreter's diagnostics that builtin was
+// handed fn, which may not be pure.
+func (in *Interpreter) checkPure(builtin string, fn object.Object) {
+	if in.diagnose == nil || in.IsPure(fn) {
+		return
+	}
+
+	message := diagnostic.Sprintf(in.catalog, "`%s` was given a function that may have side effects", builtin)
+	if f, ok := fn.(*object.Function); ok {
+		in.report(f.Body, diagnostic.Warning, diagnostic.ImpureCallback, message)
+		return
+	}
+	in.diagnose(diagnostic.Diagnostic{Severity: diagnostic.Warning, Code: diagnostic.ImpureCallback, Message: message})
+}
@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+	"monkey/token"
+)
+
+// builtinSum implements sum(array) and sum(array, start). The sum of an
+// empty array is start, which defaults to 0.
+func builtinSum(ctx context.Context, args ...object.Object) object.Object {
+	return fold("sum", token.PLUS, object.NewInteger(0), args)
+}
+
+// builtinProduct implements product(array) and product(array, start). The
+// product of an empty array is start, which defaults to 1.
+func builtinProduct(ctx context.Context, args ...object.Object) object.Object {
+	return fold("product", token.ASTERISK, object.NewInteger(1), args)
+}
+
+func fold(builtin, operator string, start object.Object, args []object.Object) object.Object {
+	arr, errObj := reduceArguments(builtin, args)
+	if errObj != nil {
+		return errObj
+	}
+
+	result := start
+	if len(args) == 2 {
+		result = args[1]
+	}
+	if !isNumber(result) {
+		return newError(object.TypeError, "start passed to `%s` must be INTEGER or FLOAT, got %s", builtin, result.Type())
+	}
+
+	for i, element := range arr.Elements {
+		if !isNumber(element) {
+			return newError(object.TypeError, "element %d passed to `%s` must be INTEGER or FLOAT, got %s", i, builtin, element.Type())
+		}
+		result = evalInfixExpression(nil, result, element, operator)
+	}
+
+	return result
+}
+
+// builtinMin implements min(array) and min(array, default), returning the
+// smallest of an array of numbers or of strings. An empty array is an
+// error unless default is given.
+func builtinMin(ctx context.Context, args ...object.Object) object.Object {
+	return extreme("min", token.LT, args)
+}
+
+// builtinMax is min's counterpart returning the largest element.
+func builtinMax(ctx context.Context, args ...object.Object) object.Object {
+	return extreme("max", token.GT, args)
+}
+
+func extreme(builtin, operator string, args []object.Object) object.Object {
+	arr, errObj := reduceArguments(builtin, args)
+	if errObj != nil {
+		return errObj
+	}
+
+	if len(arr.Elements) == 0 {
+		if len(args) == 2 {
+			return args[1]
+		}
+		return newError(object.ArgumentError, "`%s` of an empty array", builtin)
+	}
+
+	best := arr.Elements[0]
+	for i, element := range arr.Elements {
+		if !isNumber(element) && element.Type() != object.STRING_OBJ {
+			return newError(object.TypeError, "element %d passed to `%s` must be INTEGER, FLOAT or STRING, got %s", i, builtin, element.Type())
+		}
+		if isNumber(element) != isNumber(best) {
+			return newError(object.TypeError, "element %d passed to `%s` cannot be compared with %s: %s", i, builtin, best.Type(), element.Type())
+		}
+		if compare(element, best, operator) {
+			best = element
+		}
+	}
+
+	return best
+}
+
+func reduceArguments(builtin string, args []object.Object) (*object.Array, *object.Error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, newError(object.TypeError, "argument to `%s` must be ARRAY, got %s", builtin, args[0].Type())
+	}
+
+	return arr, nil
+}
+
+func isNumber(obj object.Object) bool {
+	_, ok := toFloat(obj)
+	return ok
+}
+
+// compare applies < or > to two numbers or two strings.
+func compare(left, right object.Object, operator string) bool {
+	if l, ok := left.(*object.String); ok {
+		r := right.(*object.String)
+		if operator == token.LT {
+			return l.Value < r.Value
+		}
+		return l.Value > r.Value
+	}
+
+	return evalInfixExpression(nil, left, right, operator) == TRUE
+}
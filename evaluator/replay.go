@@ -0,0 +1,79 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"io"
+	"monkey/object"
+)
+
+// ReplayLog holds the values that nondeterministic builtins such as now
+// produced during a run, in order, so the run can be repeated exactly.
+type ReplayLog struct {
+	Entries []ReplayEntry `json:"entries"`
+}
+
+// ReplayEntry is one value produced by a builtin, in a form specific to the
+// builtin.
+type ReplayEntry struct {
+	Builtin string `json:"builtin"`
+	Value   string `json:"value"`
+}
+
+// Encode writes the log to w as JSON.
+func (l *ReplayLog) Encode(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(l)
+}
+
+// DecodeReplayLog reads a log written by Encode.
+func DecodeReplayLog(r io.Reader) (*ReplayLog, error) {
+	var log ReplayLog
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// WithRecording appends the values of nondeterministic builtins to log.
+func WithRecording(log *ReplayLog) Option {
+	return func(in *Interpreter) {
+		in.recording = log
+	}
+}
+
+// WithReplay makes nondeterministic builtins return the values in log
+// instead of computing new ones. A builtin called out of the recorded order,
+// or after the log is used up, fails with a RuntimeError.
+func WithReplay(log *ReplayLog) Option {
+	return func(in *Interpreter) {
+		in.replay = log
+		in.replayed = 0
+	}
+}
+
+// nondeterministic returns the next value of builtin: the recorded one when
+// replaying, and otherwise the result of produce, which is recorded if
+// recording and produce did not fail.
+func (in *Interpreter) nondeterministic(builtin string, produce func() (string, *object.Error)) (string, *object.Error) {
+	if in.replay != nil {
+		if in.replayed >= len(in.replay.Entries) {
+			return "", newError(object.RuntimeError, "replay: %s called after the log ran out", builtin)
+		}
+		entry := in.replay.Entries[in.replayed]
+		if entry.Builtin != builtin {
+			return "", newError(object.RuntimeError, "replay: %s called where the log has %s", builtin, entry.Builtin)
+		}
+		in.replayed++
+		return entry.Value, nil
+	}
+
+	value, errObj := produce()
+	if errObj != nil {
+		return "", errObj
+	}
+	if in.recording != nil {
+		in.recording.Entries = append(in.recording.Entries, ReplayEntry{Builtin: builtin, Value: value})
+	}
+	return value, nil
+}
@@ -0,0 +1,72 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"monkey/object"
+	"strings"
+	"time"
+)
+
+// builtinRetry implements retry(n, backoff, fn), which calls fn with no
+// arguments up to n times until it returns something other than an error.
+// It waits backoff milliseconds before the second attempt and doubles the
+// wait before each one after that. If every attempt fails, the error lists
+// each attempt's message.
+func (in *Interpreter) builtinRetry(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=3", len(args))
+	}
+
+	n, ok := args[0].(*object.Integer)
+	if !ok {
+		return newError(object.TypeError, "attempts passed to `retry` must be INTEGER, got %s", args[0].Type())
+	}
+	if n.Value < 1 {
+		return newError(object.ArgumentError, "attempts passed to `retry` must be positive, got %d", n.Value)
+	}
+
+	var backoff time.Duration
+	switch arg := args[1].(type) {
+	case *object.Integer:
+		backoff = time.Duration(arg.Value) * time.Millisecond
+	case *object.Duration:
+		backoff = arg.Value
+	default:
+		return newError(object.TypeError, "backoff passed to `retry` must be INTEGER or DURATION, got %s", args[1].Type())
+	}
+
+	if !isCallable(args[2]) {
+		return newError(object.TypeError, "function passed to `retry` must be FUNCTION, got %s", args[2].Type())
+	}
+
+	var failures []error
+	var messages []string
+	for attempt := int64(0); attempt < n.Value; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return newCancelledError("retry interrupted", ctx.Err())
+			}
+			backoff *= 2
+		}
+
+		result := in.applyFunction(ctx, args[2], nil)
+		errObj, ok := result.(*object.Error)
+		if !ok {
+			return result
+		}
+		// Cancellation ends every later attempt too, so it is not retried.
+		if errObj.Category == object.CancelledError {
+			return errObj
+		}
+
+		failures = append(failures, errObj)
+		messages = append(messages, errObj.Message)
+	}
+
+	return newCausedError(object.RuntimeError, errors.Join(failures...), "retry: all attempts failed: %s", strings.Join(messages, "; "))
+}
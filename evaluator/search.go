@@ -0,0 +1,77 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+)
+
+// builtinAny implements any(array, predicate): whether predicate is truthy
+// for some element. It stops at the first such element.
+func (in *Interpreter) builtinAny(ctx context.Context, args ...object.Object) object.Object {
+	index, errObj := in.search(ctx, "any", args, true)
+	if errObj != nil {
+		return errObj
+	}
+	return nativeBoolToBooleanObject(index >= 0)
+}
+
+// builtinAll implements all(array, predicate): whether predicate is truthy
+// for every element. It stops at the first element for which it is not.
+func (in *Interpreter) builtinAll(ctx context.Context, args ...object.Object) object.Object {
+	index, errObj := in.search(ctx, "all", args, false)
+	if errObj != nil {
+		return errObj
+	}
+	return nativeBoolToBooleanObject(index < 0)
+}
+
+// builtinFind implements find(array, predicate), returning the first element
+// for which predicate is truthy, or null.
+func (in *Interpreter) builtinFind(ctx context.Context, args ...object.Object) object.Object {
+	index, errObj := in.search(ctx, "find", args, true)
+	if errObj != nil {
+		return errObj
+	}
+	if index < 0 {
+		return NULL
+	}
+	return args[0].(*object.Array).Elements[index]
+}
+
+// builtinFindIndex implements find_index(array, predicate), returning the
+// index of the first element for which predicate is truthy, or -1.
+func (in *Interpreter) builtinFindIndex(ctx context.Context, args ...object.Object) object.Object {
+	index, errObj := in.search(ctx, "find_index", args, true)
+	if errObj != nil {
+		return errObj
+	}
+	return object.NewInteger(int64(index))
+}
+
+// search returns the index of the first element of args[0] whose truthiness
+// under the predicate args[1] is want, or -1 if there is none.
+func (in *Interpreter) search(ctx context.Context, builtin string, args []object.Object, want bool) (int, *object.Error) {
+	if len(args) != 2 {
+		return 0, newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return 0, newError(object.TypeError, "argument to `%s` must be ARRAY, got %s", builtin, args[0].Type())
+	}
+	if !isCallable(args[1]) {
+		return 0, newError(object.TypeError, "predicate passed to `%s` must be FUNCTION, got %s", builtin, args[1].Type())
+	}
+
+	for i, element := range arr.Elements {
+		result := in.applyFunction(ctx, args[1], []object.Object{element})
+		if errObj, ok := result.(*object.Error); ok {
+			return 0, errObj
+		}
+		if IsTruthy(result) == want {
+			return i, nil
+		}
+	}
+
+	return -1, nil
+}
@@ -0,0 +1,204 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"monkey/object"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ErrInterrupted is the cause of the CancelledError that ends evaluation
+// after a signal trapped with on_signal has been handled.
+var ErrInterrupted = errors.New("interrupted by signal")
+
+// signalsByName lists the signals scripts may trap. Platforms add their own
+// in init functions.
+var signalsByName = map[string]os.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGQUIT": syscall.SIGQUIT,
+}
+
+// signalState holds the handlers registered with on_signal. Signals arrive
+// on their own goroutine, which relays them to received and cancels the
+// context of the evaluation in progress, so builtins blocked reading input
+// or waiting on the network return. Handlers run on the interpreter's
+// goroutine, at the points where cancellation is checked or once the
+// evaluation has returned.
+type signalState struct {
+	notify   chan os.Signal
+	received chan os.Signal
+	handlers map[os.Signal]object.Object
+	names    map[os.Signal]string
+	running  bool
+
+	mu        sync.Mutex
+	interrupt context.CancelCauseFunc
+}
+
+// WithSignalHandling enables the on_signal builtin, which lets scripts trap
+// process signals. Handlers stay registered, across calls to Eval, Call and
+// RunTimers, until the interpreter is closed. Without this option on_signal
+// fails, so scripts embedded in a host cannot take over its signals.
+func WithSignalHandling() Option {
+	return func(in *Interpreter) {
+		in.signals = &signalState{
+			received: make(chan os.Signal, 1),
+			handlers: make(map[os.Signal]object.Object),
+			names:    make(map[os.Signal]string),
+		}
+	}
+}
+
+// relay passes the signals arriving on notify to received, dropping those
+// that arrive while one is pending, and interrupts the evaluation in
+// progress. It returns once notify is closed.
+func (s *signalState) relay(notify chan os.Signal) {
+	for sig := range notify {
+		select {
+		case s.received <- sig:
+		default:
+		}
+
+		s.mu.Lock()
+		if s.interrupt != nil {
+			s.interrupt(ErrInterrupted)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// trapSignals returns a context derived from ctx that is cancelled when a
+// trapped signal arrives, for an entry point to evaluate with. finish runs
+// the handler of a signal that arrived during the evaluation, if checking
+// for signals did not already, and returns the evaluation's outcome in
+// place of result; release must be deferred. Entry points called during an
+// evaluation leave signals to the outermost one.
+func (in *Interpreter) trapSignals(ctx context.Context) (trapped context.Context, finish func(result object.Object) object.Object, release func()) {
+	unchanged := func(result object.Object) object.Object { return result }
+	if in.signals == nil {
+		return ctx, unchanged, func() {}
+	}
+
+	s := in.signals
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.interrupt != nil || s.running {
+		return ctx, unchanged, func() {}
+	}
+
+	trapped, cancel := context.WithCancelCause(ctx)
+	if len(s.received) != 0 {
+		cancel(ErrInterrupted)
+	}
+	s.interrupt = cancel
+
+	finish = func(result object.Object) object.Object {
+		select {
+		case sig := <-s.received:
+			return in.handleSignal(ctx, sig)
+		default:
+			return result
+		}
+	}
+	release = func() {
+		s.mu.Lock()
+		s.interrupt = nil
+		s.mu.Unlock()
+		cancel(nil)
+	}
+	return trapped, finish, release
+}
+
+// onSignal implements on_signal(name, handler).
+func (in *Interpreter) onSignal(ctx context.Context, args ...object.Object) object.Object {
+	if in.signals == nil {
+		return newError(object.RuntimeError, "signal handling is not enabled")
+	}
+
+	if len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "signal passed to `on_signal` must be STRING, got %s", args[0].Type())
+	}
+
+	sig, ok := signalsByName[name.Value]
+	if !ok {
+		return newError(object.ArgumentError, "unsupported signal %q", name.Value)
+	}
+
+	switch args[1].(type) {
+	case *object.Function, *object.Builtin:
+	default:
+		return newError(object.TypeError, "handler passed to `on_signal` must be FUNCTION, got %s", args[1].Type())
+	}
+
+	if in.signals.notify == nil {
+		in.signals.notify = make(chan os.Signal, 1)
+		go in.signals.relay(in.signals.notify)
+	}
+	in.signals.handlers[sig] = args[1]
+	in.signals.names[sig] = name.Value
+	signal.Notify(in.signals.notify, sig)
+
+	return NULL
+}
+
+// checkSignals runs the handler of a pending signal, if any, and returns the
+// error that ends evaluation. It returns nil when no signal is pending.
+func (in *Interpreter) checkSignals(ctx context.Context) object.Object {
+	if in.signals == nil || in.signals.running {
+		return nil
+	}
+
+	select {
+	case sig := <-in.signals.received:
+		return in.handleSignal(ctx, sig)
+	default:
+		return nil
+	}
+}
+
+// handleSignal calls the handler for sig. Evaluation then stops with the
+// handler's error if it failed, or with a CancelledError caused by
+// ErrInterrupted.
+func (in *Interpreter) handleSignal(ctx context.Context, sig os.Signal) object.Object {
+	in.signals.running = true
+	result := in.applyFunction(ctx, in.signals.handlers[sig], nil)
+	in.signals.running = false
+
+	if isError(result) {
+		return result
+	}
+
+	return newCausedError(object.CancelledError, ErrInterrupted, "interrupted by %s", in.signals.names[sig])
+}
+
+// resetSignals unregisters every handler, restoring the signals' default
+// behaviour.
+func (in *Interpreter) resetSignals() {
+	if in.signals == nil || in.signals.notify == nil {
+		return
+	}
+
+	signal.Stop(in.signals.notify)
+	close(in.signals.notify)
+	in.signals.notify = nil
+	clear(in.signals.handlers)
+	clear(in.signals.names)
+
+	for {
+		select {
+		case <-in.signals.received:
+		default:
+			return
+		}
+	}
+}
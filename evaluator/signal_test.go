@@ -0,0 +1,156 @@
+//go:build unix
+
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestOnSignal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		cleaned  bool
+	}{
+		{
+			`on_signal("SIGUSR1", fn() { cleanup() }); raise(); let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) + f(n - 1) } }; f(40)`,
+			"ERROR: interrupted by SIGUSR1",
+			true,
+		},
+		{
+			`on_signal("SIGUSR1", fn() { cleanup() }); raise(); sleep(60000)`,
+			"ERROR: interrupted by SIGUSR1",
+			true,
+		},
+		{
+			`on_signal("SIGUSR1", fn() { missing }); raise(); sleep(60000)`,
+			"ERROR: identifier not found: missing",
+			false,
+		},
+		{`on_signal("SIGUSR1", fn() { cleanup() }); 1`, "1", false},
+		{`on_signal("SIGNOPE", fn() { 1 })`, `ERROR: unsupported signal "SIGNOPE"`, false},
+		{`on_signal("SIGUSR1", 1)`, "ERROR: handler passed to `on_signal` must be FUNCTION, got INTEGER", false},
+	}
+
+	for _, tt := range tests {
+		cleaned := false
+		in := New(WithSignalHandling(), WithBuiltins(map[string]*object.Builtin{
+			"raise": {Fn: func(ctx context.Context, args ...object.Object) object.Object {
+				syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+				return NULL
+			}},
+			"cleanup": {Fn: func(ctx context.Context, args ...object.Object) object.Object {
+				cleaned = true
+				return NULL
+			}},
+		}))
+
+		evaluated := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment())
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+
+		if cleaned != tt.cleaned {
+			t.Errorf("%s: handler ran=%t, want %t", tt.input, cleaned, tt.cleaned)
+		}
+
+		in.Close()
+		if len(in.signals.handlers) != 0 || in.signals.notify != nil {
+			t.Errorf("%s: handlers still registered after Close", tt.input)
+		}
+	}
+}
+
+// signalTestBuiltins returns raise, which sends the process SIGUSR1 after
+// delay, and cleanup, which records that it was called in *cleaned.
+func signalTestBuiltins(delay time.Duration, cleaned *int) map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"raise": {Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			time.AfterFunc(delay, func() { syscall.Kill(syscall.Getpid(), syscall.SIGUSR1) })
+			return NULL
+		}},
+		"cleanup": {Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			*cleaned++
+			return NULL
+		}},
+	}
+}
+
+func TestOnSignalOutlivesEval(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(in *Interpreter, env *object.Environment) object.Object
+	}{
+		{"Eval", func(in *Interpreter, env *object.Environment) object.Object {
+			return in.Eval(parser.New(lexer.New(`raise(); sleep(60000)`)).ParseProgram(), env)
+		}},
+		{"Call", func(in *Interpreter, env *object.Environment) object.Object {
+			main, _ := env.Get("main")
+			return in.Call(main)
+		}},
+		{"RunTimers", func(in *Interpreter, env *object.Environment) object.Object {
+			in.Eval(parser.New(lexer.New(`raise(); every(5, fn() { 1 })`)).ParseProgram(), env)
+			return in.RunTimers(context.Background())
+		}},
+	}
+
+	for _, tt := range tests {
+		cleaned := 0
+		in := New(WithSignalHandling(), WithBuiltins(signalTestBuiltins(10*time.Millisecond, &cleaned)))
+		env := object.NewEnvironment()
+		in.Eval(parser.New(lexer.New(`on_signal("SIGUSR1", fn() { cleanup() }); let main = fn() { raise(); while (true) { 1 } }`)).ParseProgram(), env)
+
+		if result := tt.run(in, env); result.Inspect() != "ERROR: interrupted by SIGUSR1" {
+			t.Errorf("%s: wrong result. got=%q", tt.name, result.Inspect())
+		}
+		if cleaned != 1 {
+			t.Errorf("%s: handler ran %d times, want 1", tt.name, cleaned)
+		}
+		in.Close()
+	}
+}
+
+func TestOnSignalInterruptsBlockedBuiltins(t *testing.T) {
+	stdin, _ := io.Pipe()
+	cleaned := 0
+	in := New(WithSignalHandling(), WithStdin(stdin), WithStdout(io.Discard), WithBuiltins(signalTestBuiltins(20*time.Millisecond, &cleaned)))
+	defer in.Close()
+
+	program := parser.New(lexer.New(`on_signal("SIGUSR1", fn() { cleanup() }); raise(); prompt("Name? ")`)).ParseProgram()
+	if result := in.Eval(program, object.NewEnvironment()); result.Inspect() != "ERROR: interrupted by SIGUSR1" {
+		t.Errorf("wrong result. got=%q", result.Inspect())
+	}
+	if cleaned != 1 {
+		t.Errorf("handler ran %d times, want 1", cleaned)
+	}
+}
+
+func TestInterruptedErrorCause(t *testing.T) {
+	in := New(WithSignalHandling(), WithBuiltins(map[string]*object.Builtin{
+		"raise": {Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+			return NULL
+		}},
+	}))
+
+	program := parser.New(lexer.New(`on_signal("SIGUSR2", fn() { 1 }); raise(); sleep(60000)`)).ParseProgram()
+	errObj, ok := in.Eval(program, object.NewEnvironment()).(*object.Error)
+	if !ok || errObj.Category != object.CancelledError || !errors.Is(errObj, ErrInterrupted) {
+		t.Errorf("wrong error: %v", errObj)
+	}
+}
+
+func TestOnSignalRequiresOption(t *testing.T) {
+	evaluated := testEval(`on_signal("SIGINT", fn() { 1 })`)
+	if evaluated.Inspect() != "ERROR: signal handling is not enabled" {
+		t.Errorf("wrong result. got=%q", evaluated.Inspect())
+	}
+}
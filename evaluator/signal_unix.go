@@ -0,0 +1,10 @@
+//go:build unix
+
+package evaluator
+
+import "syscall"
+
+func init() {
+	signalsByName["SIGUSR1"] = syscall.SIGUSR1
+	signalsByName["SIGUSR2"] = syscall.SIGUSR2
+}
@@ -0,0 +1,53 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"monkey/object"
+	"strings"
+)
+
+// builtinGoSprintf implements gofmt_sprintf(format, args...), formatting
+// args with Go's fmt.Sprintf. Integers become int64, floats float64, arrays
+// []any and hashes map[string]any; times and durations keep their Go types.
+// A verb that does not suit its argument, or a missing or extra argument,
+// is an error rather than fmt's %! marker in the result.
+func builtinGoSprintf(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=at least 1", len(args))
+	}
+
+	format, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "format passed to `gofmt_sprintf` must be STRING, got %s", args[0].Type())
+	}
+
+	// Markers already present in the format or the arguments are not
+	// errors, so only the ones beyond those count.
+	expected := strings.Count(format.Value, "%%!")
+	values := make([]any, 0, len(args)-1)
+	for i, arg := range args[1:] {
+		var value any
+		switch arg := arg.(type) {
+		case *object.Time:
+			value = arg.Value
+		case *object.Duration:
+			value = arg.Value
+		default:
+			converted, err := object.ToGo(arg)
+			if err != nil {
+				return newError(object.TypeError, "argument %d passed to `gofmt_sprintf`: %s", i+1, err)
+			}
+			value = converted
+		}
+		expected += strings.Count(fmt.Sprint(value), "%!")
+		values = append(values, value)
+	}
+
+	result := fmt.Sprintf(format.Value, values...)
+	if strings.Count(result, "%!") > expected {
+		return newError(object.ArgumentError, "gofmt_sprintf: format %q does not match its arguments: %s", format.Value, result)
+	}
+
+	return &object.String{Value: result}
+}
@@ -0,0 +1,46 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// Stats accumulates what the programs an interpreter runs cost it.
+type Stats struct {
+	// Values counts by type the values produced by literals, operators and
+	// builtin calls, leaving out true, false and null, which are never
+	// allocated. A builtin returning a value it was given, such as first,
+	// counts that value again.
+	Values map[object.ObjectType]int
+	// Environments counts the environments created for function calls.
+	Environments int
+	// MaxDepth is the deepest that function calls nested.
+	MaxDepth int
+}
+
+// WithStats accumulates statistics about evaluation in s.
+func WithStats(s *Stats) Option {
+	return func(in *Interpreter) {
+		in.stats = s
+	}
+}
+
+// countNode counts result if node is one that produces new values.
+func (s *Stats) countNode(node ast.Node, result object.Object) {
+	switch node.(type) {
+	case *ast.FloatLiteral, *ast.ArrayLiteral, *ast.HashLiteral, *ast.TupleLiteral,
+		*ast.FunctionLiteral, *ast.PrefixExpression, *ast.InfixExpression:
+		s.countValue(result)
+	}
+}
+
+func (s *Stats) countValue(result object.Object) {
+	switch result {
+	case nil, TRUE, FALSE, NULL, returnSignal, breakSignal, continueSignal:
+		return
+	}
+	if s.Values == nil {
+		s.Values = make(map[object.ObjectType]int)
+	}
+	s.Values[result.Type()]++
+}
@@ -0,0 +1,68 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+	"strings"
+)
+
+// builtinChars implements chars(string), splitting a string into an array of
+// one-character strings. Characters are Unicode code points; invalid UTF-8
+// bytes each become U+FFFD.
+func builtinChars(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "argument to `chars` must be STRING, got %s", args[0].Type())
+	}
+
+	chars := make([]object.Object, 0, len(str.Value))
+	for _, r := range str.Value {
+		chars = append(chars, object.NewString(string(r)))
+	}
+
+	return &object.Array{Elements: chars}
+}
+
+// builtinFromChars implements from_chars(array), the inverse of chars: it
+// joins an array of strings into one.
+func builtinFromChars(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError(object.TypeError, "argument to `from_chars` must be ARRAY, got %s", args[0].Type())
+	}
+
+	var out strings.Builder
+	for i, element := range arr.Elements {
+		str, ok := element.(*object.String)
+		if !ok {
+			return newError(object.TypeError, "element %d passed to `from_chars` must be STRING, got %s", i, element.Type())
+		}
+		out.WriteString(str.Value)
+	}
+
+	return object.NewString(out.String())
+}
+
+// builtinByteValues implements byte_values(string or bytes), returning the
+// bytes as an array of integers. bytes(array) is its inverse.
+func builtinByteValues(ctx context.Context, args ...object.Object) object.Object {
+	data, errObj := binaryArgument("byte_values", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+
+	values := make([]object.Object, len(data))
+	for i, b := range data {
+		values[i] = object.NewInteger(int64(b))
+	}
+
+	return &object.Array{Elements: values}
+}
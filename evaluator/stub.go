@@ -0,0 +1,53 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+)
+
+// stub records a builtin replaced by stub_builtin and what to restore.
+type stub struct {
+	name     string
+	original *object.Builtin
+}
+
+// builtinStubBuiltin implements stub_builtin(name, fn), making every call
+// of the builtin name run fn instead, including calls from functions that
+// were defined before. The stub lasts until the function that called
+// stub_builtin returns, or at the top level until the evaluation ends, so
+// a test function can stub what it needs without affecting the next one.
+func (in *Interpreter) builtinStubBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "name passed to `stub_builtin` must be STRING, got %s", args[0].Type())
+	}
+	original, ok := in.builtins[name.Value]
+	if !ok {
+		return newError(object.NameError, "stub_builtin: no builtin named %s", name.Value)
+	}
+	fn := args[1]
+	if !isCallable(fn) {
+		return newError(object.TypeError, "function passed to `stub_builtin` must be FUNCTION, got %s", fn.Type())
+	}
+
+	in.stubs = append(in.stubs, stub{name: name.Value, original: original})
+	in.builtins[name.Value] = &object.Builtin{
+		Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			return in.applyFunction(ctx, fn, args)
+		},
+	}
+	return NULL
+}
+
+// restoreStubs undoes the stubs made since there were n, latest first.
+func (in *Interpreter) restoreStubs(n int) {
+	for len(in.stubs) > n {
+		s := in.stubs[len(in.stubs)-1]
+		in.builtins[s.name] = s.original
+		in.stubs = in.stubs[:len(in.stubs)-1]
+	}
+}
@@ -0,0 +1,78 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+	"strconv"
+	"strings"
+)
+
+// styleCodes maps the names style accepts to their ANSI SGR parameters.
+var styleCodes = map[string]int{
+	"bold":      1,
+	"dim":       2,
+	"italic":    3,
+	"underline": 4,
+
+	"black":   30,
+	"red":     31,
+	"green":   32,
+	"yellow":  33,
+	"blue":    34,
+	"magenta": 35,
+	"cyan":    36,
+	"white":   37,
+
+	"bg_black":   40,
+	"bg_red":     41,
+	"bg_green":   42,
+	"bg_yellow":  43,
+	"bg_blue":    44,
+	"bg_magenta": 45,
+	"bg_cyan":    46,
+	"bg_white":   47,
+}
+
+// builtinStyle implements style(text, styles...), wrapping text in the ANSI
+// escapes for the named colors and attributes. Text is returned unchanged,
+// though the styles are still checked, when the NO_COLOR environment
+// variable is set or when puts does not write to a terminal and
+// FORCE_COLOR is not set.
+func (in *Interpreter) builtinStyle(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want at least 1", len(args))
+	}
+
+	text, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "text passed to `style` must be STRING, got %s", args[0].Type())
+	}
+
+	codes := make([]string, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		name, ok := arg.(*object.String)
+		if !ok {
+			return newError(object.TypeError, "style passed to `style` must be STRING, got %s", arg.Type())
+		}
+		code, ok := styleCodes[name.Value]
+		if !ok {
+			return newError(object.ArgumentError, "unknown style %q passed to `style`", name.Value)
+		}
+		codes = append(codes, strconv.Itoa(code))
+	}
+
+	if !in.color || len(codes) == 0 {
+		return text
+	}
+	return object.NewString("\x1b[" + strings.Join(codes, ";") + "m" + text.Value + "\x1b[0m")
+}
+
+// builtinIsTTY implements is_tty(), which reports whether puts writes to a
+// terminal.
+func (in *Interpreter) builtinIsTTY(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	return nativeBoolToBooleanObject(isTerminal(in.stdout))
+}
@@ -0,0 +1,170 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"monkey/object"
+	"strings"
+	"unicode/utf8"
+)
+
+// builtinPrintTable implements print_table(rows) and print_table(rows,
+// headers), printing an array of arrays or of hashes as aligned columns.
+// Hash rows are laid out by key, in the order of headers when given and
+// otherwise in the order keys first appear; array rows are laid out by
+// position under optional headers. Numbers are right-aligned.
+func (in *Interpreter) builtinPrintTable(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	rows, ok := args[0].(*object.Array)
+	if !ok {
+		return newError(object.TypeError, "rows passed to `print_table` must be ARRAY, got %s", args[0].Type())
+	}
+
+	var headers []string
+	if len(args) == 2 {
+		arr, ok := args[1].(*object.Array)
+		if !ok {
+			return newError(object.TypeError, "headers passed to `print_table` must be ARRAY, got %s", args[1].Type())
+		}
+		for _, header := range arr.Elements {
+			str, ok := header.(*object.String)
+			if !ok {
+				return newError(object.TypeError, "headers passed to `print_table` must be STRING, got %s", header.Type())
+			}
+			headers = append(headers, str.Value)
+		}
+	}
+
+	cells, headers, errObj := tableCells(rows, headers)
+	if errObj != nil {
+		return errObj
+	}
+
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = utf8.RuneCountInString(header)
+	}
+	for _, row := range cells {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell.text))
+		}
+	}
+
+	var out strings.Builder
+	if len(headers) > 0 {
+		row := make([]tableCell, len(headers))
+		rule := make([]tableCell, len(headers))
+		for i, header := range headers {
+			row[i] = tableCell{text: header}
+			rule[i] = tableCell{text: strings.Repeat("-", widths[i])}
+		}
+		writeTableRow(&out, row, widths)
+		writeTableRow(&out, rule, widths)
+	}
+	for _, row := range cells {
+		writeTableRow(&out, row, widths)
+	}
+
+	fmt.Fprint(in.stdout, out.String())
+	return NULL
+}
+
+type tableCell struct {
+	text    string
+	numeric bool
+}
+
+// tableCells converts rows to cells, returning the headers to print: those
+// given, or for hash rows without them, every key in order of appearance.
+func tableCells(rows *object.Array, headers []string) ([][]tableCell, []string, *object.Error) {
+	var keyed bool
+	cells := make([][]tableCell, 0, len(rows.Elements))
+	for i, row := range rows.Elements {
+		switch row := row.(type) {
+		case *object.Array:
+			if keyed {
+				return nil, nil, newError(object.TypeError, "row %d passed to `print_table` must be HASH like the rows before it, got ARRAY", i)
+			}
+			line := make([]tableCell, len(row.Elements))
+			for j, value := range row.Elements {
+				line[j] = newTableCell(value)
+			}
+			cells = append(cells, line)
+		case *object.Hash:
+			if i > 0 && !keyed {
+				return nil, nil, newError(object.TypeError, "row %d passed to `print_table` must be ARRAY like the rows before it, got HASH", i)
+			}
+			keyed = true
+		default:
+			return nil, nil, newError(object.TypeError, "row %d passed to `print_table` must be ARRAY or HASH, got %s", i, row.Type())
+		}
+	}
+	if !keyed {
+		return cells, headers, nil
+	}
+
+	if headers == nil {
+		seen := make(map[string]bool)
+		for _, row := range rows.Elements {
+			for _, pair := range row.(*object.Hash).Ordered() {
+				if key := pair.Key.Inspect(); !seen[key] {
+					seen[key] = true
+					headers = append(headers, key)
+				}
+			}
+		}
+	}
+
+	for _, row := range rows.Elements {
+		values := make(map[string]object.Object)
+		for _, pair := range row.(*object.Hash).Ordered() {
+			values[pair.Key.Inspect()] = pair.Value
+		}
+		line := make([]tableCell, len(headers))
+		for j, header := range headers {
+			if value, ok := values[header]; ok {
+				line[j] = newTableCell(value)
+			}
+		}
+		cells = append(cells, line)
+	}
+	return cells, headers, nil
+}
+
+func newTableCell(value object.Object) tableCell {
+	switch value.(type) {
+	case *object.Integer, *object.Float:
+		return tableCell{text: value.Inspect(), numeric: true}
+	default:
+		return tableCell{text: value.Inspect()}
+	}
+}
+
+// writeTableRow writes row padded to widths, two spaces between columns
+// and no trailing space.
+func writeTableRow(out *strings.Builder, row []tableCell, widths []int) {
+	var line strings.Builder
+	for i, width := range widths {
+		if i > 0 {
+			line.WriteString("  ")
+		}
+		var cell tableCell
+		if i < len(row) {
+			cell = row[i]
+		}
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(cell.text))
+		if cell.numeric {
+			line.WriteString(pad + cell.text)
+		} else {
+			line.WriteString(cell.text + pad)
+		}
+	}
+	out.WriteString(strings.TrimRight(line.String(), " "))
+	out.WriteString("\n")
+}
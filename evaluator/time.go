@@ -0,0 +1,186 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+	"monkey/token"
+	"time"
+)
+
+func isTemporal(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Time, *object.Duration:
+		return true
+	default:
+		return false
+	}
+}
+
+// evalTemporalInfixExpression implements the arithmetic and comparisons of
+// times and durations:
+//
+//	time ± duration = time       time - time = duration
+//	duration ± duration          duration * integer, integer * duration
+//	duration / integer           duration / duration = integer
+//
+// plus <, >, == and != between two times or two durations.
+func evalTemporalInfixExpression(left, right object.Object, operator string) object.Object {
+	switch left := left.(type) {
+	case *object.Time:
+		switch right := right.(type) {
+		case *object.Duration:
+			switch operator {
+			case token.PLUS:
+				return &object.Time{Value: left.Value.Add(right.Value)}
+			case token.MINUS:
+				return &object.Time{Value: left.Value.Add(-right.Value)}
+			}
+		case *object.Time:
+			switch operator {
+			case token.MINUS:
+				return &object.Duration{Value: left.Value.Sub(right.Value)}
+			case token.LT:
+				return nativeBoolToBooleanObject(left.Value.Before(right.Value))
+			case token.GT:
+				return nativeBoolToBooleanObject(left.Value.After(right.Value))
+			case token.LT_EQ:
+				return nativeBoolToBooleanObject(!left.Value.After(right.Value))
+			case token.GT_EQ:
+				return nativeBoolToBooleanObject(!left.Value.Before(right.Value))
+			case token.EQ:
+				return nativeBoolToBooleanObject(left.Value.Equal(right.Value))
+			case token.NOT_EQ:
+				return nativeBoolToBooleanObject(!left.Value.Equal(right.Value))
+			}
+		}
+	case *object.Duration:
+		switch right := right.(type) {
+		case *object.Time:
+			if operator == token.PLUS {
+				return &object.Time{Value: right.Value.Add(left.Value)}
+			}
+		case *object.Duration:
+			switch operator {
+			case token.PLUS:
+				return &object.Duration{Value: left.Value + right.Value}
+			case token.MINUS:
+				return &object.Duration{Value: left.Value - right.Value}
+			case token.SLASH:
+				if right.Value == 0 {
+					return newError(object.ZeroDivisionError, "division by zero")
+				}
+				return &object.Float{Value: float64(left.Value) / float64(right.Value)}
+			case token.FLOOR_SLASH:
+				if right.Value == 0 {
+					return newError(object.ZeroDivisionError, "division by zero")
+				}
+				return object.NewInteger(floorDivide(int64(left.Value), int64(right.Value)))
+			case token.LT:
+				return nativeBoolToBooleanObject(left.Value < right.Value)
+			case token.GT:
+				return nativeBoolToBooleanObject(left.Value > right.Value)
+			case token.LT_EQ:
+				return nativeBoolToBooleanObject(left.Value <= right.Value)
+			case token.GT_EQ:
+				return nativeBoolToBooleanObject(left.Value >= right.Value)
+			case token.EQ:
+				return nativeBoolToBooleanObject(left.Value == right.Value)
+			case token.NOT_EQ:
+				return nativeBoolToBooleanObject(left.Value != right.Value)
+			}
+		case *object.Integer:
+			switch operator {
+			case token.ASTERISK:
+				return &object.Duration{Value: left.Value * time.Duration(right.Value)}
+			case token.SLASH:
+				if right.Value == 0 {
+					return newError(object.ZeroDivisionError, "division by zero")
+				}
+				return &object.Duration{Value: left.Value / time.Duration(right.Value)}
+			}
+		}
+	case *object.Integer:
+		if right, ok := right.(*object.Duration); ok && operator == token.ASTERISK {
+			return &object.Duration{Value: time.Duration(left.Value) * right.Value}
+		}
+	}
+
+	if left.Type() != right.Type() {
+		return newError(object.TypeError, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	return newError(object.TypeError, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
+// builtinNow implements now(), the current time.
+func (in *Interpreter) builtinNow(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	value, err := in.nondeterministic("now", func() (string, *object.Error) {
+		return time.Now().Format(time.RFC3339Nano), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	now, parseErr := time.Parse(time.RFC3339Nano, value)
+	if parseErr != nil {
+		return newError(object.RuntimeError, "replay: invalid time %q for now", value)
+	}
+	return &object.Time{Value: now}
+}
+
+// builtinTime implements time(seconds), time(text) and time(text, layout).
+// text defaults to RFC 3339 format; layout is a Go time layout.
+func builtinTime(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	if seconds, ok := args[0].(*object.Integer); ok && len(args) == 1 {
+		return &object.Time{Value: time.Unix(seconds.Value, 0).UTC()}
+	}
+
+	text, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "argument to `time` must be INTEGER or STRING, got %s", args[0].Type())
+	}
+
+	layout := time.RFC3339
+	if len(args) == 2 {
+		custom, ok := args[1].(*object.String)
+		if !ok {
+			return newError(object.TypeError, "layout passed to `time` must be STRING, got %s", args[1].Type())
+		}
+		layout = custom.Value
+	}
+
+	t, err := time.Parse(layout, text.Value)
+	if err != nil {
+		return newError(object.ArgumentError, "invalid time %q: %s", text.Value, err)
+	}
+
+	return &object.Time{Value: t}
+}
+
+// builtinDuration implements duration(text) for text such as "1h30m" or
+// "250ms".
+func builtinDuration(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	text, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "argument to `duration` must be STRING, got %s", args[0].Type())
+	}
+
+	d, err := time.ParseDuration(text.Value)
+	if err != nil {
+		return newError(object.ArgumentError, "invalid duration %q", text.Value)
+	}
+
+	return &object.Duration{Value: d}
+}
@@ -0,0 +1,42 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+	"time"
+)
+
+// builtinWithTimeout implements with_timeout(limit, fn), which calls fn with
+// no arguments and fails with a CancelledError if the call takes longer than
+// limit, given in milliseconds or as a duration.
+func (in *Interpreter) builtinWithTimeout(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	var limit time.Duration
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		limit = time.Duration(arg.Value) * time.Millisecond
+	case *object.Duration:
+		limit = arg.Value
+	default:
+		return newError(object.TypeError, "limit passed to `with_timeout` must be INTEGER or DURATION, got %s", args[0].Type())
+	}
+
+	if !isCallable(args[1]) {
+		return newError(object.TypeError, "function passed to `with_timeout` must be FUNCTION, got %s", args[1].Type())
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, limit)
+	defer cancel()
+
+	result := in.applyFunction(callCtx, args[1], nil)
+	// Only the budget set here is reported as a timeout; if the caller's
+	// context ended too, its error stands.
+	if isError(result) && ctx.Err() == nil && callCtx.Err() != nil {
+		return newCausedError(object.CancelledError, context.DeadlineExceeded, "with_timeout: call took longer than %s", limit)
+	}
+
+	return result
+}
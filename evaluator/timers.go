@@ -0,0 +1,135 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"monkey/object"
+	"slices"
+	"time"
+)
+
+// TIMER_OBJ is the type of the handles returned by every and after.
+const TIMER_OBJ = "TIMER"
+
+// Timer is a function scheduled to run once after a delay, or repeatedly at
+// an interval, when the host runs the interpreter's timers.
+type Timer struct {
+	fn       object.Object
+	due      time.Time
+	delay    time.Duration
+	interval time.Duration
+}
+
+func (t *Timer) Type() object.ObjectType { return TIMER_OBJ }
+
+func (t *Timer) Inspect() string {
+	if t.interval > 0 {
+		return fmt.Sprintf("<timer every %s>", t.interval)
+	}
+	return fmt.Sprintf("<timer after %s>", t.delay)
+}
+
+// scheduleBuiltin returns the every builtin if repeat is set, and the after
+// builtin otherwise.
+func (in *Interpreter) scheduleBuiltin(name string, repeat bool) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			var d time.Duration
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				d = time.Duration(arg.Value) * time.Millisecond
+			case *object.Duration:
+				d = arg.Value
+			default:
+				return newError(object.TypeError, "delay passed to `%s` must be INTEGER or DURATION, got %s", name, args[0].Type())
+			}
+			if repeat && d <= 0 {
+				return newError(object.ArgumentError, "interval passed to `%s` must be positive, got %s", name, d)
+			}
+
+			if !isCallable(args[1]) {
+				return newError(object.TypeError, "function passed to `%s` must be FUNCTION, got %s", name, args[1].Type())
+			}
+
+			timer := &Timer{fn: args[1], due: time.Now().Add(d), delay: d}
+			if repeat {
+				timer.interval = d
+			}
+			in.timers = append(in.timers, timer)
+			return timer
+		},
+	}
+}
+
+// builtinCancel implements cancel(timer), which stops a timer from running
+// again. Cancelling a timer that has already finished does nothing.
+func (in *Interpreter) builtinCancel(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	timer, ok := args[0].(*Timer)
+	if !ok {
+		return newError(object.TypeError, "argument to `cancel` must be TIMER, got %s", args[0].Type())
+	}
+
+	in.timers = slices.DeleteFunc(in.timers, func(t *Timer) bool { return t == timer })
+	return NULL
+}
+
+// RunTimers runs the functions scheduled with every and after as they fall
+// due, one at a time, until no timers remain. It stops with the error of a
+// failing function, or with a CancelledError once ctx is cancelled, which
+// is the only way to stop a timer made with every that is never cancelled.
+func (in *Interpreter) RunTimers(ctx context.Context) (result object.Object) {
+	defer in.recoverPanic(&result, in.nesting)
+
+	trapped, finish, release := in.trapSignals(ctx)
+	defer release()
+	return in.localize(finish(in.runTimers(trapped)))
+}
+
+// runTimers runs the timers for RunTimers, which handles panics and
+// signals around it.
+func (in *Interpreter) runTimers(ctx context.Context) object.Object {
+	for len(in.timers) != 0 {
+		next := in.timers[0]
+		for _, t := range in.timers[1:] {
+			if t.due.Before(next.due) {
+				next = t
+			}
+		}
+
+		wait := time.NewTimer(time.Until(next.due))
+		select {
+		case <-wait.C:
+		case <-ctx.Done():
+			wait.Stop()
+			return newCancelledError("timers stopped", ctx.Err())
+		}
+
+		if next.interval > 0 {
+			// A timer that fell behind skips the runs it missed.
+			next.due = next.due.Add(next.interval)
+			if now := time.Now(); next.due.Before(now) {
+				next.due = now
+			}
+		} else {
+			in.timers = slices.DeleteFunc(in.timers, func(t *Timer) bool { return t == next })
+		}
+
+		if result := in.applyFunction(ctx, next.fn, nil); isError(result) {
+			// The function was cut short by ctx rather than failing.
+			if result.(*object.Error).Category == object.CancelledError && ctx.Err() != nil {
+				return newCancelledError("timers stopped", ctx.Err())
+			}
+			return result
+		}
+	}
+
+	return NULL
+}
@@ -0,0 +1,43 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// TraceEvent describes one step of evaluation. Every node produces an event
+// when evaluation of it starts and another when it finishes.
+type TraceEvent struct {
+	Node ast.Node
+	Env  *object.Environment
+
+	// Exit is false when the node is entered and true once it is done.
+	Exit bool
+	// Result is the node's value on exit. It is nil for statements without
+	// a value, such as let.
+	Result object.Object
+	// Returned is set on exit when the node completed by executing a return
+	// statement; Result then holds the value being returned.
+	Returned bool
+}
+
+// WithTrace calls fn for every step of evaluation, for debuggers and
+// teaching tools. It slows evaluation down considerably.
+func WithTrace(fn func(TraceEvent)) Option {
+	return func(in *Interpreter) {
+		in.trace = fn
+	}
+}
+
+func (in *Interpreter) traceExit(node ast.Node, env *object.Environment, result object.Object) {
+	event := TraceEvent{Node: node, Env: env, Exit: true, Result: result}
+	switch result {
+	case returnSignal:
+		event.Result = in.returnValue
+		event.Returned = true
+	case breakSignal, continueSignal:
+		event.Result = nil
+	}
+
+	in.trace(event)
+}
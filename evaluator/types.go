@@ -0,0 +1,16 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+)
+
+// builtinType returns the name of a value's type, the same name the is
+// operator tests for: type(1) is "INTEGER", so 1 is INTEGER is true.
+func builtinType(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	return object.NewString(string(args[0].Type()))
+}
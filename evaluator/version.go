@@ -0,0 +1,78 @@
+package evaluator
+
+import (
+	"context"
+	"monkey/object"
+	"sort"
+)
+
+// Version is the interpreter's version. Release builds set it with
+// -ldflags "-X monkey/evaluator.Version=v1.2.3".
+var Version = "dev"
+
+// Engine names how programs are run. Evaluating the syntax tree directly
+// is the only engine there is.
+const Engine = "eval"
+
+// Capabilities lists every capability a host can grant.
+var Capabilities = []Capability{CapabilityDatabase, CapabilityNetwork}
+
+// Features describes what an interpreter has enabled, so hosts and scripts
+// can adapt to it.
+type Features struct {
+	Engine string
+	// Capabilities are the granted capabilities, sorted by name.
+	Capabilities []Capability
+	// Prelude is whether the standard prelude is loaded.
+	Prelude bool
+	// Signals is whether on_signal may trap process signals.
+	Signals bool
+}
+
+// Features reports what the interpreter has enabled.
+func (in *Interpreter) Features() Features {
+	features := Features{
+		Engine:  Engine,
+		Prelude: in.prelude != nil,
+		Signals: in.signals != nil,
+	}
+	for c, granted := range in.capabilities {
+		if granted {
+			features.Capabilities = append(features.Capabilities, c)
+		}
+	}
+	sort.Slice(features.Capabilities, func(i, j int) bool {
+		return features.Capabilities[i] < features.Capabilities[j]
+	})
+	return features
+}
+
+// builtinVersion implements version(), the interpreter's version.
+func builtinVersion(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+	return object.NewString(Version)
+}
+
+// builtinFeatures implements features(), returning Features as a hash of
+// version, engine, capabilities, prelude and signals.
+func (in *Interpreter) builtinFeatures(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	features := in.Features()
+	capabilities := &object.Array{Elements: make([]object.Object, 0, len(features.Capabilities))}
+	for _, c := range features.Capabilities {
+		capabilities.Elements = append(capabilities.Elements, object.NewString(string(c)))
+	}
+
+	return stringHash(
+		"version", object.NewString(Version),
+		"engine", object.NewString(features.Engine),
+		"capabilities", capabilities,
+		"prelude", nativeBoolToBooleanObject(features.Prelude),
+		"signals", nativeBoolToBooleanObject(features.Signals),
+	)
+}
@@ -0,0 +1,150 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"monkey/object"
+	"monkey/websocket"
+	"time"
+)
+
+// WEBSOCKET_OBJ is the type of the connections returned by ws_connect.
+const WEBSOCKET_OBJ = "WEBSOCKET"
+
+// WebSocket is a client WebSocket connection.
+type WebSocket struct {
+	Conn *websocket.Conn
+	url  string
+}
+
+func (ws *WebSocket) Type() object.ObjectType { return WEBSOCKET_OBJ }
+
+func (ws *WebSocket) Inspect() string {
+	return "<websocket " + ws.url + ">"
+}
+
+func (ws *WebSocket) Close() error { return ws.Conn.Close() }
+
+// builtinWSConnect implements ws_connect(url) for ws:// and wss:// URLs.
+func (in *Interpreter) builtinWSConnect(ctx context.Context, args ...object.Object) object.Object {
+	if err := in.require(CapabilityNetwork, "ws_connect"); err != nil {
+		return err
+	}
+
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	url, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "argument to `ws_connect` must be STRING, got %s", args[0].Type())
+	}
+
+	in.audit(CapabilityNetwork, "ws_connect", redactURL(url.Value))
+	conn, err := websocket.Dial(ctx, url.Value)
+	if err != nil {
+		if ctx.Err() != nil {
+			return newCancelledError("ws_connect interrupted", ctx.Err())
+		}
+		return newError(object.RuntimeError, "ws_connect: %s", err)
+	}
+
+	return in.open(&WebSocket{Conn: conn, url: url.Value})
+}
+
+// builtinWSSend implements ws_send(ws, message), sending message as text.
+func builtinWSSend(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	ws, errObj := webSocketArgument("ws_send", args[0])
+	if errObj != nil {
+		return errObj
+	}
+
+	message, ok := args[1].(*object.String)
+	if !ok {
+		return newError(object.TypeError, "message passed to `ws_send` must be STRING, got %s", args[1].Type())
+	}
+
+	err := withConnContext(ctx, ws.Conn, func() error {
+		return ws.Conn.Write(websocket.TextMessage, []byte(message.Value))
+	})
+	if err != nil {
+		return webSocketError(ctx, "ws_send", err)
+	}
+
+	return NULL
+}
+
+// builtinWSRecv implements ws_recv(ws), which waits for the next message
+// and returns it as a string, or null once the server has closed the
+// connection.
+func builtinWSRecv(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	ws, errObj := webSocketArgument("ws_recv", args[0])
+	if errObj != nil {
+		return errObj
+	}
+
+	var data []byte
+	err := withConnContext(ctx, ws.Conn, func() (err error) {
+		_, data, err = ws.Conn.Read()
+		return err
+	})
+	if errors.Is(err, websocket.ErrClosed) {
+		return NULL
+	}
+	if err != nil {
+		return webSocketError(ctx, "ws_recv", err)
+	}
+
+	return object.NewString(string(data))
+}
+
+// builtinWSClose implements ws_close(ws).
+func (in *Interpreter) builtinWSClose(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ArgumentError, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	ws, errObj := webSocketArgument("ws_close", args[0])
+	if errObj != nil {
+		return errObj
+	}
+
+	return in.closeHandle("ws_close", ws)
+}
+
+func webSocketArgument(builtin string, arg object.Object) (*WebSocket, *object.Error) {
+	ws, ok := arg.(*WebSocket)
+	if !ok {
+		return nil, newError(object.TypeError, "first argument to `%s` must be WEBSOCKET, got %s", builtin, arg.Type())
+	}
+
+	return ws, nil
+}
+
+// withConnContext runs io, interrupting it by expiring the connection's
+// deadline if ctx is cancelled first.
+func withConnContext(ctx context.Context, conn *websocket.Conn, io func() error) error {
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	err := io()
+	if !stop() {
+		conn.SetDeadline(time.Time{})
+	}
+
+	return err
+}
+
+func webSocketError(ctx context.Context, builtin string, err error) *object.Error {
+	if ctx.Err() != nil {
+		return newCancelledError(builtin+" interrupted", ctx.Err())
+	}
+
+	return newError(object.RuntimeError, "%s: %s", builtin, err)
+}
@@ -0,0 +1,94 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"monkey/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// webSocketServer answers each message with its upper-cased text, stays
+// silent on "wait" and closes on "bye".
+func webSocketServer(t *testing.T) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			_, data, err := conn.Read()
+			if err != nil || string(data) == "bye" {
+				return
+			}
+			if string(data) == "wait" {
+				continue
+			}
+			conn.Write(websocket.TextMessage, []byte(strings.ToUpper(string(data))))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestWebSocketBuiltins(t *testing.T) {
+	url := webSocketServer(t)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let ws = ws_connect(URL); ws_send(ws, "hi"); let reply = ws_recv(ws); ws_close(ws); reply`, "HI"},
+		{`let ws = ws_connect(URL); ws_send(ws, "bye"); ws_recv(ws)`, "null"},
+		{`let ws = ws_connect(URL); ws_close(ws); ws_send(ws, "x")`, "ERROR: ws_send: websocket: connection closed"},
+		{`ws_connect("http://localhost")`, `ERROR: ws_connect: websocket: unsupported scheme "http"`},
+		{`ws_send(1, "x")`, "ERROR: first argument to `ws_send` must be WEBSOCKET, got INTEGER"},
+		{`ws_send(ws_connect(URL), 1)`, "ERROR: message passed to `ws_send` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		in := New(WithCapabilities(CapabilityNetwork))
+		env := object.NewEnvironment()
+		env.Set("URL", object.NewString(url))
+
+		evaluated := in.Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestWebSocketRecvCancellation(t *testing.T) {
+	in := New(WithCapabilities(CapabilityNetwork))
+	env := object.NewEnvironment()
+	env.Set("URL", object.NewString(webSocketServer(t)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	program := parser.New(lexer.New(`let ws = ws_connect(URL); ws_send(ws, "wait"); ws_recv(ws)`)).ParseProgram()
+	evaluated := in.EvalContext(ctx, program, env)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || !errors.Is(errObj, context.DeadlineExceeded) {
+		t.Errorf("expected a cancelled error, got=%s", evaluated.Inspect())
+	}
+}
+
+func TestWebSocketRequiresCapability(t *testing.T) {
+	evaluated := testEval(`ws_connect("ws://localhost")`)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Category != object.PermissionError {
+		t.Errorf("expected a PermissionError, got=%s", evaluated.Inspect())
+	}
+}
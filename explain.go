@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"monkey/evaluator"
+	"monkey/explain"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/optimizer"
+	"monkey/parser"
+	"os"
+)
+
+func runExplain(args []string) int {
+	flags := flag.NewFlagSet("explain", flag.ContinueOnError)
+	verbosity := flags.Int("v", 1, "narrate at `level` 1 (statements), 2 (expressions) or 3 (everything)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey explain [-v level] script.mky")
+		fmt.Fprintln(flags.Output(), "Runs the script, explaining each step of its evaluation.")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	level := explain.Level(*verbosity)
+	if flags.NArg() != 1 || level < explain.Statements || level > explain.Everything {
+		flags.Usage()
+		return 2
+	}
+
+	source, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey explain: %s\n", err)
+		return 1
+	}
+
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(os.Stderr, "monkey explain: %s\n", msg)
+		}
+		return 1
+	}
+	optimizer.Fold(program)
+
+	explainer := explain.New(os.Stdout, level)
+	interpreter := evaluator.New(evaluator.WithTrace(explainer.Trace))
+	result := interpreter.Eval(program, object.NewEnvironment())
+	if err, ok := result.(*object.Error); ok {
+		fmt.Fprintf(os.Stderr, "monkey explain: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
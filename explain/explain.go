@@ -0,0 +1,269 @@
+// Package explain narrates how the evaluator runs a program, step by step,
+// for people learning how an interpreter works.
+package explain
+
+import (
+	"fmt"
+	"io"
+	"monkey/ast"
+	"monkey/evaluator"
+	"monkey/object"
+	"strings"
+)
+
+// Level selects how much of the evaluation is narrated. Each level includes
+// everything the levels below it report.
+type Level int
+
+const (
+	// Statements reports bindings, function calls and returns, and the value
+	// of each top-level expression.
+	Statements Level = iota + 1
+	// Expressions adds conditionals, operators and index expressions.
+	Expressions
+	// Everything adds literals and variable lookups.
+	Everything
+)
+
+// maxValueWidth bounds how much of a value is shown before it is elided.
+const maxValueWidth = 60
+
+// frame is a node being evaluated and the results of the children it has
+// evaluated so far, in order.
+type frame struct {
+	node    ast.Node
+	results []object.Object
+}
+
+// Explainer turns the evaluator's trace events into narration. Pass its
+// Trace method to evaluator.WithTrace.
+type Explainer struct {
+	w     io.Writer
+	level Level
+
+	stack    []frame
+	calls    int
+	reported *object.Error
+}
+
+// New returns an Explainer writing to w at the given level.
+func New(w io.Writer, level Level) *Explainer {
+	return &Explainer{w: w, level: level}
+}
+
+// Trace handles one trace event.
+func (e *Explainer) Trace(ev evaluator.TraceEvent) {
+	if !ev.Exit {
+		e.enter(ev)
+		e.stack = append(e.stack, frame{node: ev.Node})
+		return
+	}
+
+	top := e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+	if len(e.stack) > 0 {
+		parent := &e.stack[len(e.stack)-1]
+		parent.results = append(parent.results, ev.Result)
+	}
+
+	e.exit(ev, top.results)
+}
+
+func (e *Explainer) enter(ev evaluator.TraceEvent) {
+	switch node := ev.Node.(type) {
+	case *ast.CallExpression:
+		e.say(Statements, "call %s", node)
+		e.calls++
+	case *ast.BlockStatement:
+		if len(e.stack) == 0 {
+			return
+		}
+		parent := e.stack[len(e.stack)-1]
+		call, ok := parent.node.(*ast.CallExpression)
+		if !ok || len(parent.results) == 0 {
+			return
+		}
+		fn, ok := parent.results[0].(*object.Function)
+		if !ok {
+			return
+		}
+		e.say(Statements, "new scope for %s: %s", call.Function, describeBindings(fn.Parameters, ev.Env))
+	}
+}
+
+func (e *Explainer) exit(ev evaluator.TraceEvent, children []object.Object) {
+	if call, ok := ev.Node.(*ast.CallExpression); ok {
+		e.calls--
+		if !e.failed(ev) {
+			e.say(Statements, "%s returned %s", call, show(ev.Result))
+		}
+		return
+	}
+	if e.failed(ev) {
+		return
+	}
+
+	switch node := ev.Node.(type) {
+	case *ast.LetStatement:
+		var bound []string
+		for _, name := range append([]*ast.Identifier{node.Name}, node.Rest...) {
+			value, _ := ev.Env.Get(name.Value)
+			bound = append(bound, name.Value+" to "+show(value))
+		}
+		e.say(Statements, "let: bound %s", strings.Join(bound, ", "))
+	case *ast.AssignExpression:
+		e.say(Statements, "assign: set %s to %s", node.Name.Value, show(ev.Result))
+	case *ast.ReturnStatement:
+		e.say(Statements, "return: leaving the function with %s", show(ev.Result))
+	case *ast.ExpressionStatement:
+		if len(e.stack) == 1 {
+			e.say(Statements, "%s evaluated to %s", summarize(node.Expression), show(ev.Result))
+		}
+	case *ast.IfExpression:
+		e.explainIf(node, children, ev.Result)
+	case *ast.InfixExpression:
+		if len(children) == 2 {
+			e.say(Expressions, "%s → %s (%s)", node, show(ev.Result), infixRule(node.Operator, children[0], children[1]))
+		}
+	case *ast.PrefixExpression:
+		e.say(Expressions, "%s → %s (%s)", node, show(ev.Result), prefixRule(node.Operator))
+	case *ast.IndexExpression:
+		if len(children) > 0 {
+			e.say(Expressions, "%s → %s (%s index)", node, show(ev.Result), strings.ToLower(string(children[0].Type())))
+		}
+	case *ast.Identifier:
+		rule := "variable lookup"
+		if _, ok := ev.Result.(*object.Builtin); ok {
+			rule = "builtin"
+		}
+		e.say(Everything, "%s → %s (%s)", node, show(ev.Result), rule)
+	case *ast.FunctionLiteral:
+		e.say(Everything, "%s → function closing over the current scope", summarize(node))
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean, *ast.ArrayLiteral, *ast.HashLiteral:
+		e.say(Everything, "%s (literal)", show(ev.Result))
+	}
+}
+
+func (e *Explainer) explainIf(node *ast.IfExpression, children []object.Object, result object.Object) {
+	if len(children) == 0 {
+		return
+	}
+
+	cond := show(children[0])
+	switch {
+	case evaluator.IsTruthy(children[0]):
+		e.say(Expressions, "if: condition %s is %s, which is truthy, so the consequence runs", node.Condition, cond)
+	case node.Alternative != nil:
+		e.say(Expressions, "if: condition %s is %s, which is falsy, so the alternative runs", node.Condition, cond)
+	default:
+		e.say(Expressions, "if: condition %s is %s, which is falsy, and there is no alternative, so the result is %s", node.Condition, cond, show(result))
+	}
+}
+
+// failed reports whether ev ended in an error, narrating the error at the
+// node that first produced it.
+func (e *Explainer) failed(ev evaluator.TraceEvent) bool {
+	err, ok := ev.Result.(*object.Error)
+	if !ok {
+		return false
+	}
+
+	if err != e.reported {
+		e.reported = err
+		e.say(Statements, "%s failed: %s", summarize(ev.Node), err.Error())
+	}
+
+	return true
+}
+
+func (e *Explainer) say(level Level, format string, args ...any) {
+	if level > e.level {
+		return
+	}
+
+	fmt.Fprintf(e.w, "%s%s\n", strings.Repeat("  ", e.calls), fmt.Sprintf(format, args...))
+}
+
+// summarize shortens nodes whose source form would be too long to read in
+// a single line of narration.
+func summarize(node ast.Node) string {
+	switch node := node.(type) {
+	case *ast.IfExpression:
+		return "if " + node.Condition.String()
+	case *ast.FunctionLiteral:
+		params := make([]string, len(node.Parameters))
+		for i, param := range node.Parameters {
+			params[i] = param.Value
+		}
+		return "fn(" + strings.Join(params, ", ") + ")"
+	case *ast.BlockStatement:
+		return "block"
+	default:
+		return node.String()
+	}
+}
+
+func describeBindings(params []*ast.Identifier, env *object.Environment) string {
+	if len(params) == 0 {
+		return "no parameters"
+	}
+
+	bindings := make([]string, len(params))
+	for i, param := range params {
+		value, _ := env.Get(param.Value)
+		bindings[i] = param.Value + " = " + show(value)
+	}
+
+	return strings.Join(bindings, ", ")
+}
+
+func infixRule(operator string, left, right object.Object) string {
+	switch {
+	case operator == "==" || operator == "!=":
+		if left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ {
+			return "integer equality"
+		}
+		return "equality"
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		if operator == "<" || operator == ">" || operator == "<=" || operator == ">=" {
+			return "integer comparison"
+		}
+		return "integer arithmetic"
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ && operator == "+":
+		return "string concatenation"
+	default:
+		return fmt.Sprintf("%s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func prefixRule(operator string) string {
+	switch operator {
+	case "!":
+		return "logical not"
+	case "-":
+		return "negation"
+	default:
+		return "prefix " + operator
+	}
+}
+
+// show renders a value for narration: strings are quoted and long values
+// are cut short.
+func show(obj object.Object) string {
+	if obj == nil {
+		return "nothing"
+	}
+
+	var s string
+	if str, ok := obj.(*object.String); ok {
+		s = fmt.Sprintf("%q", str.Value)
+	} else {
+		s = object.Format(obj, object.FormatOptions{MaxDepth: 3})
+	}
+
+	if len(s) > maxValueWidth {
+		s = s[:maxValueWidth-3] + "..."
+	}
+
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,88 @@
+package explain
+
+import (
+	"bytes"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func TestExplainer(t *testing.T) {
+	tests := []struct {
+		input    string
+		level    Level
+		expected string
+	}{
+		{
+			"let add = fn(a, b) { a + b }; add(1, 2);",
+			Statements,
+			`let: bound add to fn(a, b) { (a + b) }
+call add(1, 2)
+  new scope for add: a = 1, b = 2
+add(1, 2) returned 3
+add(1, 2) evaluated to 3
+`,
+		},
+		{
+			`if (1 > 2) { "yes" }`,
+			Expressions,
+			`(1 > 2) → false (integer comparison)
+if: condition (1 > 2) is false, which is falsy, and there is no alternative, so the result is null
+if (1 > 2) evaluated to null
+`,
+		},
+		{
+			`let s = "a"; s + "b"`,
+			Everything,
+			`"a" (literal)
+let: bound s to "a"
+s → "a" (variable lookup)
+"b" (literal)
+(s + b) → "ab" (string concatenation)
+(s + b) evaluated to "ab"
+`,
+		},
+		{
+			"let f = fn() { return 1, 2 }; let a, b = f();",
+			Statements,
+			`let: bound f to fn() { return 1, 2; }
+call f()
+  new scope for f: no parameters
+  return: leaving the function with (1, 2)
+f() returned (1, 2)
+let: bound a to 1, b to 2
+`,
+		},
+		{
+			"let n = 2; n **= 3; n",
+			Statements,
+			`let: bound n to 2
+assign: set n to 8
+(n **= 3) evaluated to 8
+n evaluated to 8
+`,
+		},
+		{
+			"let f = fn(x) { x / 0 }; f(1) + 1;",
+			Statements,
+			`let: bound f to fn(x) { (x / 0) }
+call f(1)
+  new scope for f: x = 1
+  (x / 0) failed: ZeroDivisionError: division by zero
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		explainer := New(&out, tt.level)
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		evaluator.New(evaluator.WithTrace(explainer.Trace)).Eval(program, object.NewEnvironment())
+
+		if out.String() != tt.expected {
+			t.Errorf("wrong narration for %q.\nwant:\n%s\ngot:\n%s", tt.input, tt.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"monkey/config"
+	"monkey/evaluator"
+	"os"
+)
+
+func runExport(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "json", "output format: json or yaml")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey export [-format json|yaml] config.mky")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || (*format != "json" && *format != "yaml") {
+		flags.Usage()
+		return 2
+	}
+
+	path := flags.Arg(0)
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey export: %s\n", err)
+		return 1
+	}
+
+	value, err := config.Evaluate(path, string(source), evaluator.WithStdout(os.Stderr))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey export: %s\n", err)
+		return 1
+	}
+
+	if *format == "yaml" {
+		err = config.EncodeYAML(os.Stdout, value)
+	} else {
+		err = config.EncodeJSON(os.Stdout, value)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey export: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,193 @@
+// Package fuzz provides entry points for fuzzing Monkey and the Go programs
+// that embed it. They accept any input, bound the work it can cause, and
+// report a panic anywhere in the lexer, parser or evaluator as an error
+// instead of crashing, so a fuzz target can fail with the offending input.
+package fuzz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"monkey/ast"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/optimizer"
+	"monkey/parser"
+	"runtime/debug"
+	"runtime/metrics"
+	"strings"
+	"time"
+)
+
+// Limits bounds the resources one call to EvalSource may use.
+type Limits struct {
+	// MaxSourceLength is the longest source accepted, in bytes.
+	MaxSourceLength int
+	// MaxDepth limits how deeply function calls may nest.
+	MaxDepth int
+	// Timeout limits how long evaluation may run.
+	Timeout time.Duration
+	// MaxAllocBytes limits how many bytes evaluation may allocate on the
+	// heap. A goroutine checks every millisecond, so with few processors a
+	// run of large allocations may overshoot before it gets to. The Go
+	// runtime only counts allocations per process, so the bytes of
+	// anything else running meanwhile are included.
+	MaxAllocBytes uint64
+}
+
+// DefaultLimits are small enough for a fuzzer to run many inputs a second.
+var DefaultLimits = Limits{
+	MaxSourceLength: 64 << 10,
+	MaxDepth:        200,
+	Timeout:         100 * time.Millisecond,
+	MaxAllocBytes:   16 << 20,
+}
+
+// Seeds are sources exercising most of the language, to seed a fuzzer's
+// corpus.
+var Seeds = []string{
+	``,
+	`let x = 5; x * 2 + 1`,
+	`let add = fn(a, b) { a + b }; add(1, 2)`,
+	`let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(10)`,
+	`let f = fn() { let a, b = g(); a + b }; let g = fn() { return 1, 2 }; f()`,
+	`[1, 2, 3][1] + {"a": 1, true: 2}["a"]`,
+	`"hello" + " " + "world"`,
+	`let total = 0; for (x in [1, 2, 3]) { total += x }; total`,
+	`let i = 0; outer: while (i < 10) { i += 1; if (i == 5) { break outer } }; i`,
+	`do { 1 } while (false)`,
+	`unless (false) { 2 ** 10 } else { 7 // 2 }`,
+	`1 is INTEGER && type("a") == type("b")`,
+	`1 / 0`,
+	`-true`,
+	`len([1, 2]) + len("abc")`,
+	`with_timeout(10, fn() { retry(2, 1, fn() { 1 }) })`,
+	`let e = events(); e["on"]("x", fn(v) { v }); e["emit"]("x", 1)`,
+	`if (1.5 > 1) { 2.5 * 2 } else { 0 }`,
+}
+
+// ParseSource parses source and, if it parsed cleanly, folds its constants
+// as monkey run does; the parser resolves its variables. errs holds the
+// parser's error messages; err is set only if parsing or folding panicked.
+func ParseSource(source string) (program *ast.Program, errs []string, err error) {
+	defer recoverPanic("parsing", &err)
+
+	p := parser.New(lexer.New(source))
+	program = p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		optimizer.Fold(program)
+	}
+	return program, p.Errors(), nil
+}
+
+// EvalSource parses and evaluates source within limits, in a fresh
+// interpreter without capabilities whose input is empty and whose output is
+// discarded. The result is the first parse error or the evaluation error as
+// an error object, or the program's value, null if it has none; err is set
+// only if parsing or evaluation panicked.
+func EvalSource(source string, limits Limits) (result object.Object, err error) {
+	if limits.MaxSourceLength > 0 && len(source) > limits.MaxSourceLength {
+		return &object.Error{
+			Category: object.ArgumentError,
+			Message:  fmt.Sprintf("source is %d bytes, longer than %d", len(source), limits.MaxSourceLength),
+		}, nil
+	}
+
+	program, errs, err := ParseSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) != 0 {
+		return &object.Error{Category: object.RuntimeError, Message: "parse error: " + errs[0]}, nil
+	}
+
+	defer recoverPanic("evaluating", &err)
+
+	ctx := context.Background()
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+	if limits.MaxAllocBytes > 0 {
+		var stop func()
+		ctx, stop = watchAllocations(ctx, limits.MaxAllocBytes)
+		defer stop()
+	}
+
+	in := evaluator.New(
+		evaluator.WithMaxDepth(limits.MaxDepth),
+		evaluator.WithStreams(evaluator.Streams{
+			Stdin:  strings.NewReader(""),
+			Stdout: io.Discard,
+			Stderr: io.Discard,
+		}),
+	)
+	defer in.Close()
+
+	result = in.EvalContext(ctx, program, object.NewEnvironment())
+	if cancelled, ok := result.(*object.Error); ok && cancelled.Category == object.CancelledError && context.Cause(ctx) == errAllocations {
+		result = &object.Error{
+			Category: object.CancelledError,
+			Message:  fmt.Sprintf("evaluation stopped: allocated more than %d bytes", limits.MaxAllocBytes),
+		}
+	}
+	if internal, ok := result.(*object.Error); ok && internal.Category == object.InternalError {
+		// The interpreter recovers its own panics; they are still bugs.
+		return nil, fmt.Errorf("panic while evaluating: %s\n%s", internal.Message, internal.Stack)
+	}
+	if result == nil {
+		// Programs without a value, such as one ending in a let statement.
+		result = object.NULL
+	}
+	return result, nil
+}
+
+// errAllocations is the cause of contexts cancelled by watchAllocations.
+var errAllocations = errors.New("allocation limit exceeded")
+
+// watchAllocations returns a context cancelled with errAllocations once the
+// process has allocated more than limit bytes on the heap. stop ends the
+// watch.
+func watchAllocations(ctx context.Context, limit uint64) (watched context.Context, stop func()) {
+	watched, cancel := context.WithCancelCause(ctx)
+	sample := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(sample)
+	start := sample[0].Value.Uint64()
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-watched.Done():
+				return
+			case <-ticker.C:
+				metrics.Read(sample)
+				if sample[0].Value.Uint64()-start > limit {
+					cancel(errAllocations)
+					return
+				}
+			}
+		}
+	}()
+
+	return watched, func() {
+		close(done)
+		<-finished
+		cancel(nil)
+	}
+}
+
+func recoverPanic(stage string, err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("panic while %s: %v\n%s", stage, r, debug.Stack())
+	}
+}
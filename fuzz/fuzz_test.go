@@ -0,0 +1,70 @@
+package fuzz
+
+import (
+	"strings"
+	"testing"
+)
+
+func FuzzParseSource(f *testing.F) {
+	for _, seed := range Seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		if _, _, err := ParseSource(source); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func FuzzEvalSource(f *testing.F) {
+	for _, seed := range Seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		result, err := EvalSource(source, DefaultLimits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result == nil {
+			t.Fatal("no result")
+		}
+	})
+}
+
+func TestEvalSourceLimits(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{`let f = fn(n) { f(n + 1) }; f(0)`, "ERROR: maximum call depth exceeded (200)"},
+		{`while (true) { 1 }`, "ERROR: evaluation stopped: context deadline exceeded"},
+		{`let s = "ab"; for (i in range(0, 19)) { s = s + s }; while (true) { let t = s + s }`, "ERROR: evaluation stopped: allocated more than 16777216 bytes"},
+		{`let a = []; while (true) { a = push(a, pack("1000000x")) }`, "ERROR: evaluation stopped: allocated more than 16777216 bytes"},
+		{`prompt("x")`, "null"},
+		{`confirm("x", true)`, "true"},
+		{`let x = ;`, "ERROR: parse error: no prefix parse function for ; found"},
+		{strings.Repeat(" ", DefaultLimits.MaxSourceLength+1), "ERROR: source is 65537 bytes, longer than 65536"},
+	}
+
+	for _, tt := range tests {
+		result, err := EvalSource(tt.source, DefaultLimits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Inspect() != tt.expected {
+			t.Errorf("%.40s: wrong result. want=%q, got=%q", tt.source, tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestParseSourceFolds(t *testing.T) {
+	program, errs, err := ParseSource(`let x = 1 + 2 * 3; x`)
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("ParseSource failed: %v %v", errs, err)
+	}
+	if got := program.String(); got != "let x = 7;x" {
+		t.Errorf("program not folded. got=%q", got)
+	}
+}
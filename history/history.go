@@ -0,0 +1,133 @@
+// Package history records how a program's variables change as it runs, so
+// debuggers and teaching tools can step backwards through recent
+// evaluation.
+package history
+
+import (
+	"monkey/ast"
+	"monkey/evaluator"
+	"monkey/object"
+	"sort"
+)
+
+// Change is one binding created or updated while a statement ran.
+type Change struct {
+	Env  *object.Environment
+	Name string
+	// Old is the previous value, or nil if the binding is new.
+	Old object.Object
+	New object.Object
+}
+
+// Step is a statement that finished running. Changes holds the bindings
+// created or updated since the previous step finished, so changes made by
+// statements nested in it, such as those in the body of a function it
+// calls, belong to the nested statements' steps, and a call's parameters
+// are bound in the step of the first statement of its body.
+type Step struct {
+	Statement ast.Statement
+	Changes   []Change
+}
+
+// location identifies a binding: a name in a particular environment.
+type location struct {
+	env  *object.Environment
+	name string
+}
+
+// Recorder keeps the most recent steps of an evaluation. Pass its Trace
+// method to evaluator.WithTrace. Recording compares every visible binding
+// at each statement boundary, so it slows evaluation down considerably.
+type Recorder struct {
+	limit   int
+	steps   []Step
+	values  map[location]object.Object
+	pending []Change
+}
+
+// New returns a Recorder keeping the last limit steps, or all of them if
+// limit is zero or less.
+func New(limit int) *Recorder {
+	return &Recorder{limit: limit, values: make(map[location]object.Object)}
+}
+
+// Trace handles one trace event.
+func (r *Recorder) Trace(ev evaluator.TraceEvent) {
+	stmt, ok := ev.Node.(ast.Statement)
+	if !ok {
+		return
+	}
+	if _, ok := stmt.(*ast.BlockStatement); ok {
+		return
+	}
+
+	r.observe(ev.Env)
+	if !ev.Exit {
+		return
+	}
+
+	r.steps = append(r.steps, Step{Statement: stmt, Changes: r.pending})
+	r.pending = nil
+	if r.limit > 0 && len(r.steps) > r.limit {
+		r.steps = r.steps[len(r.steps)-r.limit:]
+	}
+}
+
+// observe records the bindings visible from env that changed since they
+// were last seen.
+func (r *Recorder) observe(env *object.Environment) {
+	for ; env != nil; env = env.Outer() {
+		var changes []Change
+		env.Each(func(name string, obj object.Object) {
+			loc := location{env, name}
+			old, seen := r.values[loc]
+			if seen && old == obj {
+				return
+			}
+			r.values[loc] = obj
+			changes = append(changes, Change{Env: env, Name: name, Old: old, New: obj})
+		})
+
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+		r.pending = append(r.pending, changes...)
+	}
+}
+
+// Steps returns the recorded steps, oldest first.
+func (r *Recorder) Steps() []Step {
+	return r.steps
+}
+
+// Bindings returns the bindings held directly in env as they were right
+// after Steps()[step] ran, undoing the changes of every later step. Pass
+// len(Steps()) - 1 for the current state. Bindings changed before the
+// oldest kept step are as the oldest step left them.
+func (r *Recorder) Bindings(env *object.Environment, step int) map[string]object.Object {
+	bindings := make(map[string]object.Object)
+	for loc, obj := range r.values {
+		if loc.env == env {
+			bindings[loc.name] = obj
+		}
+	}
+
+	undo := func(changes []Change) {
+		for i := len(changes) - 1; i >= 0; i-- {
+			change := changes[i]
+			if change.Env != env {
+				continue
+			}
+			if change.Old == nil {
+				delete(bindings, change.Name)
+			} else {
+				bindings[change.Name] = change.Old
+			}
+		}
+	}
+
+	undo(r.pending)
+	for i := len(r.steps) - 1; i > step; i-- {
+		undo(r.steps[i].Changes)
+	}
+
+	return bindings
+}
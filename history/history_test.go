@@ -0,0 +1,93 @@
+package history
+
+import (
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"strings"
+	"testing"
+)
+
+func record(t *testing.T, input string, limit int) (*Recorder, *object.Environment) {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	recorder := New(limit)
+	env := object.NewEnvironment()
+	evaluator.New(evaluator.WithTrace(recorder.Trace)).Eval(program, env)
+	return recorder, env
+}
+
+// describe renders steps as "statement: name=value ..." lines.
+func describe(steps []Step) string {
+	var lines []string
+	for _, step := range steps {
+		line := step.Statement.String()
+		for _, change := range step.Changes {
+			line += " " + change.Name + "=" + change.New.Inspect()
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestRecorderSteps(t *testing.T) {
+	input := `let x = 1; let f = fn(n) { x = x + n; }; f(2); x`
+	recorder, _ := record(t, input, 0)
+
+	expected := strings.Join([]string{
+		"let x = 1; x=1",
+		"let f = fn (n) (x = (x + n)); f=fn(n) {\n(x = (x + n))\n}",
+		"(x = (x + n)) n=2 x=3",
+		"f(2)",
+		"x",
+	}, "\n")
+	if got := describe(recorder.Steps()); got != expected {
+		t.Errorf("wrong steps.\nwant:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestRecorderLimit(t *testing.T) {
+	recorder, _ := record(t, `let a = 1; let b = 2; let c = 3;`, 2)
+
+	expected := "let b = 2; b=2\nlet c = 3; c=3"
+	if got := describe(recorder.Steps()); got != expected {
+		t.Errorf("wrong steps.\nwant:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestRecorderBindings(t *testing.T) {
+	recorder, env := record(t, `let i = 0; let s = 0; while (i < 3) { i += 1; s += i; }; let done = true;`, 0)
+
+	tests := []struct {
+		step     int
+		expected string
+	}{
+		{-1, ""},
+		{0, "i=0"},
+		{1, "i=0 s=0"},
+		{4, "i=2 s=1"},
+		{len(recorder.Steps()) - 2, "i=3 s=6"},
+		{len(recorder.Steps()) - 1, "done=true i=3 s=6"},
+	}
+
+	for _, tt := range tests {
+		bindings := recorder.Bindings(env, tt.step)
+
+		var got []string
+		for _, name := range []string{"done", "i", "s"} {
+			if obj, ok := bindings[name]; ok {
+				got = append(got, name+"="+obj.Inspect())
+			}
+		}
+		if strings.Join(got, " ") != tt.expected {
+			t.Errorf("step %d: want=%q, got=%q", tt.step, tt.expected, strings.Join(got, " "))
+		}
+	}
+}
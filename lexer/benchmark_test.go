@@ -0,0 +1,24 @@
+package lexer
+
+import (
+	"monkey/token"
+	"strings"
+	"testing"
+)
+
+// benchmarkSource resembles a large generated configuration script.
+var benchmarkSource = strings.Repeat(`let service_42 = {"name": "api-gateway", "port": 8080, "replicas": 3 * 2, "enabled": true};
+let scale = fn(x, factor) { if (x > 100) { return x / factor; } else { x * factor } };
+let hosts = ["alpha", "beta", "gamma"]; scale(service_42["port"], 2) != 16160 == false;
+`, 200)
+
+func BenchmarkNextToken(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkSource)))
+
+	for i := 0; i < b.N; i++ {
+		l := New(benchmarkSource)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+	}
+}
@@ -2,6 +2,7 @@ package lexer
 
 import (
 	"monkey/token"
+	"strings"
 )
 
 type Lexer struct {
@@ -9,17 +10,29 @@ type Lexer struct {
 	position     int
 	readPosition int
 	ch           byte
+
+	// line and column locate ch in the input.
+	line   int
+	column int
 }
 
 func New(input string) *Lexer {
 	l := &Lexer{
 		input: input,
+		line:  1,
 	}
 	l.readChar()
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -32,7 +45,7 @@ func (l *Lexer) readChar() {
 
 func (l *Lexer) peekChar() byte {
 	if l.readPosition >= len(l.input) {
-		l.ch = 0
+		return 0
 	}
 	return l.input[l.readPosition]
 }
@@ -72,57 +85,84 @@ func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
+	tok.Line, tok.Column = l.line, l.column
 
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
-			tok = token.Token{token.EQ, l.input[l.position : l.position+2]}
+			tok = l.newToken(token.EQ, 2)
 			l.readChar()
 		} else {
-			tok = newToken(token.ASSIGN, l.ch)
+			tok = l.newToken(token.ASSIGN, 1)
 		}
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		tok = l.operator(token.PLUS_ASSIGN, token.PLUS)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		tok = l.operator(token.MINUS_ASSIGN, token.MINUS)
 	case '!':
 		if l.peekChar() == '=' {
-			tok = token.Token{token.NOT_EQ, l.input[l.position : l.position+2]}
+			tok = l.newToken(token.NOT_EQ, 2)
 			l.readChar()
 		} else {
-			tok = newToken(token.BANG, l.ch)
+			tok = l.newToken(token.BANG, 1)
 		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		tok = l.operator(token.POWER_ASSIGN, token.POWER, token.ASTERISK_ASSIGN, token.ASTERISK)
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		tok = l.operator(token.FLOOR_SLASH_ASSIGN, token.FLOOR_SLASH, token.SLASH_ASSIGN, token.SLASH)
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newToken(token.LT_EQ, 2)
+			l.readChar()
+		} else {
+			tok = l.newToken(token.LT, 1)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.newToken(token.GT_EQ, 2)
+			l.readChar()
+		} else {
+			tok = l.newToken(token.GT, 1)
+		}
+	case '&':
+		if l.peekChar() == '&' {
+			tok = l.newToken(token.AND, 2)
+			l.readChar()
+		} else {
+			tok = l.newToken(token.ILLEGAL, 1)
+		}
+	case '|':
+		if l.peekChar() == '|' {
+			tok = l.newToken(token.OR, 2)
+			l.readChar()
+		} else {
+			tok = l.newToken(token.ILLEGAL, 1)
+		}
 	case ',':
-		tok = newToken(token.COMMA, l.ch)
+		tok = l.newToken(token.COMMA, 1)
 	case ';':
-		tok = newToken(token.SEMICOLON, l.ch)
+		tok = l.newToken(token.SEMICOLON, 1)
 	case '(':
-		tok = newToken(token.LPAREN, l.ch)
+		tok = l.newToken(token.LPAREN, 1)
 	case ')':
-		tok = newToken(token.RPAREN, l.ch)
+		tok = l.newToken(token.RPAREN, 1)
 	case '{':
-		tok = newToken(token.LBRACE, l.ch)
+		tok = l.newToken(token.LBRACE, 1)
 	case '}':
-		tok = newToken(token.RBRACE, l.ch)
+		tok = l.newToken(token.RBRACE, 1)
 	case '[':
-		tok = newToken(token.LBRACKET, l.ch)
+		tok = l.newToken(token.LBRACKET, 1)
 	case ']':
-		tok = newToken(token.RBRACKET, l.ch)
+		tok = l.newToken(token.RBRACKET, 1)
 	case ':':
-		tok = newToken(token.COLON, l.ch)
+		tok = l.newToken(token.COLON, 1)
 	case '"':
+		start := l.position
 		stringValue, ok := l.readString()
 		if !ok {
-			tok = newToken(token.ILLEGAL, l.ch)
-			return tok
+			// The literal of an unterminated string is all of it, opening
+			// quote included.
+			return token.Token{Type: token.ILLEGAL, Literal: l.input[start:], Line: tok.Line, Column: tok.Column}
 		}
 		tok.Literal = stringValue
 		tok.Type = token.STRING
@@ -135,11 +175,10 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.LookUpIdentifierType(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
+			tok.Literal, tok.Type = l.readNumber()
 			return tok
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			tok = l.newToken(token.ILLEGAL, 1)
 		}
 	}
 
@@ -147,14 +186,25 @@ func (l *Lexer) NextToken() token.Token {
 	return tok
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads an integer, or a float if the digits are followed by a
+// dot and more digits.
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	startPosition := l.position
+	var tokenType token.TokenType = token.INT
 
 	for isDigit(l.ch) {
 		l.readChar()
 	}
 
-	return l.input[startPosition:l.position]
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+	}
+
+	return l.input[startPosition:l.position], tokenType
 }
 
 func isDigit(ch byte) bool {
@@ -165,9 +215,31 @@ func isLetter(ch byte) bool {
 	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '_'
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+// operator returns a token for the first of candidates spelled out at the
+// current position, consuming all but its last character like the other
+// multi-character tokens. Longer operators must come first.
+func (l *Lexer) operator(candidates ...token.TokenType) token.Token {
+	for _, candidate := range candidates {
+		if strings.HasPrefix(l.input[l.position:], string(candidate)) {
+			tok := l.newToken(candidate, len(candidate))
+			for i := 1; i < len(candidate); i++ {
+				l.readChar()
+			}
+			return tok
+		}
+	}
+
+	return l.newToken(token.ILLEGAL, 1)
+}
+
+// newToken returns a token whose literal is the next n bytes of input.
+// Literals are slices of the input rather than copies, so lexing does not
+// allocate.
+func (l *Lexer) newToken(tokenType token.TokenType, n int) token.Token {
 	return token.Token{
 		Type:    tokenType,
-		Literal: string(ch),
+		Literal: l.input[l.position : l.position+n],
+		Line:    l.line,
+		Column:  l.column,
 	}
 }
@@ -143,3 +143,156 @@ if (5 < 10) {
 		}
 	}
 }
+
+func TestNextTokenAtEndOfInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.Token
+	}{
+		{"=", []token.Token{{Type: token.ASSIGN, Literal: "=", Line: 1, Column: 1}}},
+		{"!", []token.Token{{Type: token.BANG, Literal: "!", Line: 1, Column: 1}}},
+		{"x ==", []token.Token{{Type: token.IDENT, Literal: "x", Line: 1, Column: 1}, {Type: token.EQ, Literal: "==", Line: 1, Column: 3}}},
+		{`"open`, []token.Token{{Type: token.ILLEGAL, Literal: `"open`, Line: 1, Column: 1}}},
+		{`x "`, []token.Token{{Type: token.IDENT, Literal: "x", Line: 1, Column: 1}, {Type: token.ILLEGAL, Literal: `"`, Line: 1, Column: 3}}},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for i, expected := range append(tt.expected, token.Token{Type: token.EOF, Line: 1, Column: len(tt.input) + 1}) {
+			tok := l.NextToken()
+			if tok != expected {
+				t.Errorf("%q: token %d wrong. want=%+v, got=%+v", tt.input, i, expected, tok)
+				break
+			}
+		}
+	}
+}
+
+func TestNextTokenDoesNotAllocate(t *testing.T) {
+	l := New(benchmarkSource)
+	allocs := testing.AllocsPerRun(1000, func() {
+		if l.NextToken().Type == token.EOF {
+			l = New(benchmarkSource)
+		}
+	})
+
+	// Only restarting at EOF allocates a Lexer.
+	if allocs >= 1 {
+		t.Errorf("NextToken allocated %.2f times per call", allocs)
+	}
+}
+
+func TestNextTokenNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.Token
+	}{
+		{"3.14", []token.Token{{Type: token.FLOAT, Literal: "3.14", Line: 1, Column: 1}}},
+		{"10", []token.Token{{Type: token.INT, Literal: "10", Line: 1, Column: 1}}},
+		{"1.5+2", []token.Token{{Type: token.FLOAT, Literal: "1.5", Line: 1, Column: 1}, {Type: token.PLUS, Literal: "+", Line: 1, Column: 4}, {Type: token.INT, Literal: "2", Line: 1, Column: 5}}},
+		{"1.", []token.Token{{Type: token.INT, Literal: "1", Line: 1, Column: 1}, {Type: token.ILLEGAL, Literal: ".", Line: 1, Column: 2}}},
+		{"1.2.3", []token.Token{{Type: token.FLOAT, Literal: "1.2", Line: 1, Column: 1}, {Type: token.ILLEGAL, Literal: ".", Line: 1, Column: 4}, {Type: token.INT, Literal: "3", Line: 1, Column: 5}}},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for i, expected := range append(tt.expected, token.Token{Type: token.EOF, Line: 1, Column: len(tt.input) + 1}) {
+			tok := l.NextToken()
+			if tok != expected {
+				t.Errorf("%q: token %d wrong. want=%+v, got=%+v", tt.input, i, expected, tok)
+				break
+			}
+		}
+	}
+}
+
+func TestNextTokenArithmeticOperators(t *testing.T) {
+	input := "a ** b **= c += d -= e *= f /= g * h // i //= j / k"
+	expected := []token.Token{
+		{Type: token.IDENT, Literal: "a", Line: 1, Column: 1},
+		{Type: token.POWER, Literal: "**", Line: 1, Column: 3},
+		{Type: token.IDENT, Literal: "b", Line: 1, Column: 6},
+		{Type: token.POWER_ASSIGN, Literal: "**=", Line: 1, Column: 8},
+		{Type: token.IDENT, Literal: "c", Line: 1, Column: 12},
+		{Type: token.PLUS_ASSIGN, Literal: "+=", Line: 1, Column: 14},
+		{Type: token.IDENT, Literal: "d", Line: 1, Column: 17},
+		{Type: token.MINUS_ASSIGN, Literal: "-=", Line: 1, Column: 19},
+		{Type: token.IDENT, Literal: "e", Line: 1, Column: 22},
+		{Type: token.ASTERISK_ASSIGN, Literal: "*=", Line: 1, Column: 24},
+		{Type: token.IDENT, Literal: "f", Line: 1, Column: 27},
+		{Type: token.SLASH_ASSIGN, Literal: "/=", Line: 1, Column: 29},
+		{Type: token.IDENT, Literal: "g", Line: 1, Column: 32},
+		{Type: token.ASTERISK, Literal: "*", Line: 1, Column: 34},
+		{Type: token.IDENT, Literal: "h", Line: 1, Column: 36},
+		{Type: token.FLOOR_SLASH, Literal: "//", Line: 1, Column: 38},
+		{Type: token.IDENT, Literal: "i", Line: 1, Column: 41},
+		{Type: token.FLOOR_SLASH_ASSIGN, Literal: "//=", Line: 1, Column: 43},
+		{Type: token.IDENT, Literal: "j", Line: 1, Column: 47},
+		{Type: token.SLASH, Literal: "/", Line: 1, Column: 49},
+		{Type: token.IDENT, Literal: "k", Line: 1, Column: 51},
+		{Type: token.EOF, Line: 1, Column: 52},
+	}
+
+	l := New(input)
+	for i, want := range expected {
+		if tok := l.NextToken(); tok != want {
+			t.Fatalf("token %d wrong. want=%+v, got=%+v", i, want, tok)
+		}
+	}
+}
+
+func TestNextTokenLogicalOperators(t *testing.T) {
+	input := "a <= b >= c && d || e & |"
+	expected := []token.Token{
+		{Type: token.IDENT, Literal: "a", Line: 1, Column: 1},
+		{Type: token.LT_EQ, Literal: "<=", Line: 1, Column: 3},
+		{Type: token.IDENT, Literal: "b", Line: 1, Column: 6},
+		{Type: token.GT_EQ, Literal: ">=", Line: 1, Column: 8},
+		{Type: token.IDENT, Literal: "c", Line: 1, Column: 11},
+		{Type: token.AND, Literal: "&&", Line: 1, Column: 13},
+		{Type: token.IDENT, Literal: "d", Line: 1, Column: 16},
+		{Type: token.OR, Literal: "||", Line: 1, Column: 18},
+		{Type: token.IDENT, Literal: "e", Line: 1, Column: 21},
+		{Type: token.ILLEGAL, Literal: "&", Line: 1, Column: 23},
+		{Type: token.ILLEGAL, Literal: "|", Line: 1, Column: 25},
+		{Type: token.EOF, Line: 1, Column: 26},
+	}
+
+	l := New(input)
+	for i, want := range expected {
+		if tok := l.NextToken(); tok != want {
+			t.Fatalf("token %d wrong. want=%+v, got=%+v", i, want, tok)
+		}
+	}
+}
+
+func TestNextTokenPositions(t *testing.T) {
+	input := "let x = 5;\n\n  \"two\nlines\" +\tfoo(1.5)\n"
+	expected := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"let", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"5", 1, 9},
+		{";", 1, 10},
+		{"two\nlines", 3, 3},
+		{"+", 4, 8},
+		{"foo", 4, 10},
+		{"(", 4, 13},
+		{"1.5", 4, 14},
+		{")", 4, 17},
+		{"", 5, 1},
+	}
+
+	l := New(input)
+	for i, want := range expected {
+		tok := l.NextToken()
+		if tok.Literal != want.literal || tok.Line != want.line || tok.Column != want.column {
+			t.Fatalf("token %d wrong. want=%q at %d:%d, got=%q at %d:%d",
+				i, want.literal, want.line, want.column, tok.Literal, tok.Line, tok.Column)
+		}
+	}
+}
@@ -7,7 +7,27 @@ import (
 	"os/user"
 )
 
+// commands maps each CLI subcommand to its implementation. A command gets
+// the arguments following its name and returns the process exit code.
+var commands = map[string]func(args []string) int{
+	"bench":   runBench,
+	"explain": runExplain,
+	"export":  runExport,
+	"render":  runRender,
+	"run":     runScript,
+	"version": runVersion,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		command, ok := commands[os.Args[1]]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "monkey: unknown command %q\n", os.Args[1])
+			os.Exit(2)
+		}
+		os.Exit(command(os.Args[2:]))
+	}
+
 	usr, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -0,0 +1,29 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+)
+
+// Bytes is an immutable byte string, for binary data that is not
+// necessarily valid text. It is shown as a quoted literal prefixed by b.
+type Bytes struct {
+	Value []byte
+}
+
+func (b *Bytes) Type() ObjectType { return BYTES_OBJ }
+
+func (b *Bytes) Inspect() string {
+	return fmt.Sprintf("b%q", b.Value)
+}
+
+func (b *Bytes) Equal(other Object) bool {
+	return bytes.Equal(b.Value, other.(*Bytes).Value)
+}
+
+func (b *Bytes) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write(b.Value)
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
@@ -0,0 +1,50 @@
+package object
+
+// Canonical returns obj with every boolean and null in it, including those
+// nested in arrays, tuples and hashes, replaced by TRUE, FALSE and NULL.
+// Arrays, tuples and hashes are updated in place. Objects built outside the
+// evaluator, such as those decoded from storage or returned by host
+// builtins, must pass through Canonical before the evaluator sees them.
+func Canonical(obj Object) Object {
+	return canonical(obj, make(map[Object]bool))
+}
+
+// canonical does the work of Canonical, using seen to visit each container
+// once even if it contains itself.
+func canonical(obj Object, seen map[Object]bool) Object {
+	switch obj := obj.(type) {
+	case *Boolean:
+		if obj.Value {
+			return TRUE
+		}
+		return FALSE
+	case *Null:
+		return NULL
+	case *Array:
+		if !seen[obj] {
+			seen[obj] = true
+			canonicalElements(obj.Elements, seen)
+		}
+	case *Tuple:
+		if !seen[obj] {
+			seen[obj] = true
+			canonicalElements(obj.Elements, seen)
+		}
+	case *Hash:
+		if !seen[obj] {
+			seen[obj] = true
+			for key, pair := range obj.Pairs {
+				obj.Pairs[key] = HashPair{Key: canonical(pair.Key, seen), Value: canonical(pair.Value, seen)}
+			}
+			obj.Default = canonical(obj.Default, seen)
+		}
+	}
+
+	return obj
+}
+
+func canonicalElements(elements []Object, seen map[Object]bool) {
+	for i, element := range elements {
+		elements[i] = canonical(element, seen)
+	}
+}
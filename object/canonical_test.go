@@ -0,0 +1,26 @@
+package object
+
+import "testing"
+
+func TestCanonical(t *testing.T) {
+	if Canonical(&Boolean{Value: true}) != TRUE || Canonical(&Boolean{Value: false}) != FALSE || Canonical(&Null{}) != NULL {
+		t.Fatalf("booleans and null are not canonicalized")
+	}
+
+	key := &Boolean{Value: true}
+	hash := &Hash{Default: &Null{}}
+	hash.Set(key.HashKey(), HashPair{Key: key, Value: &Null{}})
+	array := &Array{Elements: []Object{&Boolean{Value: false}, hash, &Tuple{Elements: []Object{&Null{}}}}}
+	array.Elements = append(array.Elements, array)
+
+	if Canonical(array) != array {
+		t.Fatalf("array was replaced")
+	}
+	pair := hash.Pairs[key.HashKey()]
+	if array.Elements[0] != FALSE || pair.Key != TRUE || pair.Value != NULL || hash.Default != NULL {
+		t.Errorf("nested values are not canonicalized: %s", array.Elements[:2])
+	}
+	if array.Elements[2].(*Tuple).Elements[0] != NULL {
+		t.Errorf("tuple elements are not canonicalized")
+	}
+}
@@ -0,0 +1,114 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// FromGo converts plain Go data, as produced by encoding/json, into Monkey
+// objects. Supported values are nil, bools, integers, floats, which become
+// integers when integral, strings, []byte, time.Time, time.Duration, []any and map[string]any.
+func FromGo(value any) (Object, error) {
+	switch value := value.(type) {
+	case nil:
+		return NULL, nil
+	case bool:
+		if value {
+			return TRUE, nil
+		}
+		return FALSE, nil
+	case int:
+		return &Integer{Value: int64(value)}, nil
+	case int64:
+		return &Integer{Value: value}, nil
+	case float64:
+		if value != math.Trunc(value) || math.Abs(value) >= 1<<63 {
+			return &Float{Value: value}, nil
+		}
+		return &Integer{Value: int64(value)}, nil
+	case string:
+		return &String{Value: value}, nil
+	case []byte:
+		return &Bytes{Value: value}, nil
+	case time.Time:
+		return &Time{Value: value}, nil
+	case time.Duration:
+		return &Duration{Value: value}, nil
+	case []any:
+		elements := make([]Object, 0, len(value))
+		for _, element := range value {
+			converted, err := FromGo(element)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, converted)
+		}
+		return &Array{Elements: elements}, nil
+	case map[string]any:
+		pairs := make(map[HashKey]HashPair, len(value))
+		for key, element := range value {
+			converted, err := FromGo(element)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			hashKey := &String{Value: key}
+			pairs[hashKey.HashKey()] = HashPair{Key: hashKey, Value: converted}
+		}
+		return &Hash{Pairs: pairs}, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %T to a Monkey object", value)
+	}
+}
+
+// ToGo converts a Monkey value into plain Go data suitable for
+// encoding/json: nil, bool, int64, float64, string, []byte, []any and map[string]any.
+// Times, durations and hash keys that are not strings are converted with
+// Inspect, and two keys converting to the same string are an error.
+// Functions, builtins and errors cannot be converted.
+func ToGo(obj Object) (any, error) {
+	switch obj := obj.(type) {
+	case *Null:
+		return nil, nil
+	case *Boolean:
+		return obj.Value, nil
+	case *Integer:
+		return obj.Value, nil
+	case *Float:
+		return obj.Value, nil
+	case *String:
+		return obj.Value, nil
+	case *Bytes:
+		return obj.Value, nil
+	case *Time, *Duration:
+		return obj.Inspect(), nil
+	case *Array:
+		elements := make([]any, 0, len(obj.Elements))
+		for _, element := range obj.Elements {
+			converted, err := ToGo(element)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, converted)
+		}
+		return elements, nil
+	case *Tuple:
+		return ToGo(&Array{Elements: obj.Elements})
+	case *Hash:
+		pairs := make(map[string]any, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			key := pair.Key.Inspect()
+			if _, ok := pairs[key]; ok {
+				return nil, fmt.Errorf("more than one key converts to %q", key)
+			}
+			converted, err := ToGo(pair.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			pairs[key] = converted
+		}
+		return pairs, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %s to a Go value", obj.Type())
+	}
+}
@@ -0,0 +1,100 @@
+package object
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFromGo(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected string
+	}{
+		{`null`, "null"},
+		{`true`, "true"},
+		{`42`, "42"},
+		{`"monkey"`, "monkey"},
+		{`[1, "two", false]`, "[1, two, false]"},
+		{`{"port": 8080}`, "{port: 8080}"},
+	}
+
+	for _, tt := range tests {
+		var value any
+		if err := json.Unmarshal([]byte(tt.json), &value); err != nil {
+			t.Fatalf("bad test input %q: %s", tt.json, err)
+		}
+
+		obj, err := FromGo(value)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.json, err)
+			continue
+		}
+
+		if obj.Inspect() != tt.expected {
+			t.Errorf("%s: wrong object. want=%q, got=%q", tt.json, tt.expected, obj.Inspect())
+		}
+	}
+
+	if obj, _ := FromGo(true); obj != TRUE {
+		t.Errorf("converted boolean is not the canonical TRUE")
+	}
+
+	if obj, err := FromGo(1.5); err != nil || obj.Type() != FLOAT_OBJ {
+		t.Errorf("non-integral number not converted to a float. got=%v, %v", obj, err)
+	}
+}
+
+func TestToGo(t *testing.T) {
+	key := &String{Value: "ports"}
+	one := &Integer{Value: 1}
+	hash := &Hash{Pairs: map[HashKey]HashPair{
+		key.HashKey(): {Key: key, Value: &Array{Elements: []Object{&Integer{Value: 80}, NULL}}},
+		one.HashKey(): {Key: one, Value: TRUE},
+	}}
+
+	value, err := ToGo(hash)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("could not encode: %s", err)
+	}
+
+	if string(encoded) != `{"1":true,"ports":[80,null]}` {
+		t.Errorf("wrong conversion. got=%s", encoded)
+	}
+
+	if _, err := ToGo(&Array{Elements: []Object{&Builtin{}}}); err == nil {
+		t.Errorf("expected an error converting a builtin")
+	}
+
+	clash := &String{Value: "1"}
+	hash.Pairs[clash.HashKey()] = HashPair{Key: clash, Value: FALSE}
+	if _, err := ToGo(hash); err == nil || err.Error() != `more than one key converts to "1"` {
+		t.Errorf("wrong error for keys converting to the same string. got=%v", err)
+	}
+}
+
+func TestBytesConversion(t *testing.T) {
+	obj, err := FromGo([]byte{0, 1})
+	if err != nil || obj.Inspect() != `b"\x00\x01"` {
+		t.Errorf("wrong conversion from []byte: %v, %v", obj, err)
+	}
+
+	encoded, err := json.Marshal(mustToGo(t, &Bytes{Value: []byte("hi")}))
+	if err != nil || string(encoded) != `"aGk="` {
+		t.Errorf("wrong JSON for BYTES: %s, %v", encoded, err)
+	}
+}
+
+func mustToGo(t *testing.T, obj Object) any {
+	t.Helper()
+
+	value, err := ToGo(obj)
+	if err != nil {
+		t.Fatalf("ToGo(%s): %s", obj.Inspect(), err)
+	}
+	return value
+}
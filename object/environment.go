@@ -0,0 +1,171 @@
+package object
+
+// Environment holds variable bindings. It is not safe for concurrent use;
+// goroutines evaluating in parallel need environments of their own.
+//
+// Global environments keep bindings in a map. Calls of functions processed
+// by the resolver get slot environments instead, where each local has a
+// fixed index and lookups are plain slice indexing.
+type Environment struct {
+	store map[string]Object
+	outer *Environment
+
+	slots []Object
+	names []string
+
+	frozen bool
+}
+
+func ExtendEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{
+		store: make(map[string]Object),
+		outer: nil,
+	}
+}
+
+// NewFunctionEnvironment returns a slot environment for a call of a
+// resolved function, with one empty slot per name in locals.
+func NewFunctionEnvironment(outer *Environment, locals []string) *Environment {
+	return &Environment{
+		outer: outer,
+		slots: make([]Object, len(locals)),
+		names: locals,
+	}
+}
+
+func (e *Environment) Get(name string) (Object, bool) {
+	for env := e; env != nil; env = env.outer {
+		if obj, ok := env.store[name]; ok {
+			return obj, true
+		}
+
+		for i, local := range env.names {
+			if local == name && env.slots[i] != nil {
+				return env.slots[i], true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (e *Environment) Set(name string, val Object) Object {
+	for i, local := range e.names {
+		if local == name {
+			e.slots[i] = val
+			return val
+		}
+	}
+
+	if e.store == nil {
+		e.store = make(map[string]Object)
+	}
+	e.store[name] = val
+	return val
+}
+
+// GetAt returns the value in slot index of the environment depth levels
+// up. It reports false if that slot has not been assigned yet.
+func (e *Environment) GetAt(depth, index int) (Object, bool) {
+	env := e
+	for ; depth > 0; depth-- {
+		env = env.outer
+	}
+
+	obj := env.slots[index]
+	return obj, obj != nil
+}
+
+// SetAt assigns slot index of this environment.
+func (e *Environment) SetAt(index int, val Object) Object {
+	e.slots[index] = val
+	return val
+}
+
+// Assign rebinds name in the nearest environment that already binds it and
+// reports whether there was one. It reports false without rebinding if that
+// environment is frozen.
+func (e *Environment) Assign(name string, val Object) bool {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			if env.frozen {
+				return false
+			}
+			env.store[name] = val
+			return true
+		}
+
+		for i, local := range env.names {
+			if local == name && env.slots[i] != nil {
+				env.slots[i] = val
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AssignAt rebinds slot index of the environment depth levels up. Like
+// GetAt, it reports false if that slot has not been assigned yet.
+func (e *Environment) AssignAt(depth, index int, val Object) bool {
+	env := e
+	for ; depth > 0; depth-- {
+		env = env.outer
+	}
+
+	if env.slots[index] == nil {
+		return false
+	}
+	env.slots[index] = val
+	return true
+}
+
+// Release clears the slots for which keep is false, letting their values be
+// collected while closures still hold on to the environment.
+func (e *Environment) Release(keep []bool) {
+	for i, kept := range keep {
+		if !kept {
+			e.slots[i] = nil
+		}
+	}
+}
+
+// Freeze makes e read-only so it can serve as a prelude of bindings shared
+// by many programs, each evaluated in its own ExtendEnvironment(e) with
+// isolated globals. Programs can then neither define nor reassign names in
+// e itself. Several goroutines may evaluate in children of a frozen
+// environment at once, as long as the values bound in it are not changed.
+func (e *Environment) Freeze() {
+	e.frozen = true
+}
+
+// Frozen reports whether e has been frozen.
+func (e *Environment) Frozen() bool {
+	return e.frozen
+}
+
+// Outer returns the enclosing environment, or nil for a global one.
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// Each calls fn for every binding held directly in e, not its outer chain,
+// in no particular order.
+func (e *Environment) Each(fn func(name string, obj Object)) {
+	for name, obj := range e.store {
+		fn(name, obj)
+	}
+
+	for i, name := range e.names {
+		if e.slots[i] != nil {
+			fn(name, e.slots[i])
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package object
+
+// Host applications can add their own object kinds, such as a database
+// handle, by implementing Object with a Type name of their choosing. The
+// optional interfaces below let such objects take part in the language like
+// the built-in types do. Implementing Hashable makes them usable as hash keys.
+
+// Equaler is implemented by objects that define their own == and !=. Equal
+// is only called with an other of the same Type.
+type Equaler interface {
+	Equal(other Object) bool
+}
+
+// Truther is implemented by objects that decide their own truthiness in
+// conditions and with the ! operator. Objects without it are truthy.
+type Truther interface {
+	Truthy() bool
+}
+
+// MethodProvider is implemented by objects that expose methods, called from
+// Monkey as obj["name"](args). The returned function is already bound to
+// the receiver.
+type MethodProvider interface {
+	Method(name string) (BuiltinFunction, bool)
+}
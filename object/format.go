@@ -0,0 +1,121 @@
+package object
+
+import (
+	"strings"
+)
+
+// FormatOptions controls how Format lays out arrays and hashes.
+type FormatOptions struct {
+	// Indent is repeated once per nesting level when an array or hash is
+	// broken over several lines. If empty, everything stays on one line.
+	Indent string
+	// Width is the longest single-line form an array or hash may have to be
+	// kept on one line when Indent is set.
+	Width int
+	// MaxDepth is how deeply arrays and hashes are shown before they are
+	// abbreviated as [...] and {...}. Zero means no limit.
+	MaxDepth int
+}
+
+// Format renders obj like Inspect, laid out according to opts. Hash pairs
+// keep their insertion order, and an array or hash that contains itself is
+// shown as <cycle> where it recurs.
+func Format(obj Object, opts FormatOptions) string {
+	f := &formatter{opts: opts, ancestors: make(map[Object]bool)}
+
+	var out strings.Builder
+	f.write(&out, obj, 0)
+	return out.String()
+}
+
+type formatter struct {
+	opts      FormatOptions
+	ancestors map[Object]bool
+}
+
+// entry is an element of an array, which has no key, or a pair of a hash.
+type entry struct {
+	key   Object
+	value Object
+}
+
+func (f *formatter) write(out *strings.Builder, obj Object, depth int) {
+	var open, close string
+	var entries []entry
+
+	switch obj := obj.(type) {
+	case *Array:
+		open, close = "[", "]"
+		for _, element := range obj.Elements {
+			entries = append(entries, entry{value: element})
+		}
+	case *Tuple:
+		open, close = "(", ")"
+		for _, element := range obj.Elements {
+			entries = append(entries, entry{value: element})
+		}
+	case *Hash:
+		open, close = "{", "}"
+		for _, pair := range obj.Ordered() {
+			entries = append(entries, entry{key: pair.Key, value: pair.Value})
+		}
+	default:
+		out.WriteString(obj.Inspect())
+		return
+	}
+
+	switch {
+	case f.ancestors[obj]:
+		out.WriteString("<cycle>")
+		return
+	case f.opts.MaxDepth > 0 && depth >= f.opts.MaxDepth && len(entries) > 0:
+		out.WriteString(open + "..." + close)
+		return
+	}
+
+	f.ancestors[obj] = true
+	defer delete(f.ancestors, obj)
+
+	if f.opts.Indent == "" || len(entries) == 0 {
+		f.writeEntries(out, open, close, entries, depth)
+		return
+	}
+
+	single := &formatter{opts: FormatOptions{MaxDepth: f.opts.MaxDepth}, ancestors: f.ancestors}
+	var line strings.Builder
+	single.writeEntries(&line, open, close, entries, depth)
+	if line.Len() <= f.opts.Width {
+		out.WriteString(line.String())
+		return
+	}
+
+	out.WriteString(open + "\n")
+	for i, e := range entries {
+		out.WriteString(strings.Repeat(f.opts.Indent, depth+1))
+		f.writeEntry(out, e, depth+1)
+		if i < len(entries)-1 {
+			out.WriteString(",")
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString(strings.Repeat(f.opts.Indent, depth) + close)
+}
+
+func (f *formatter) writeEntries(out *strings.Builder, open, close string, entries []entry, depth int) {
+	out.WriteString(open)
+	for i, e := range entries {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		f.writeEntry(out, e, depth+1)
+	}
+	out.WriteString(close)
+}
+
+func (f *formatter) writeEntry(out *strings.Builder, e entry, depth int) {
+	if e.key != nil {
+		f.write(out, e.key, depth)
+		out.WriteString(": ")
+	}
+	f.write(out, e.value, depth)
+}
@@ -0,0 +1,55 @@
+package object
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	one, two := &Integer{Value: 1}, &Integer{Value: 2}
+	nested := &Array{Elements: []Object{one, &Array{Elements: []Object{two, &Array{Elements: []Object{one}}}}}}
+	hash := testHash(map[string]Object{
+		"name":  &String{Value: "web"},
+		"ports": &Array{Elements: []Object{&Integer{Value: 80}, &Integer{Value: 443}}},
+		"tls":   testHash(map[string]Object{"enabled": TRUE}),
+	})
+
+	cyclic := &Array{Elements: []Object{one}}
+	cyclic.Elements = append(cyclic.Elements, cyclic)
+	shared := &Array{Elements: []Object{two}}
+
+	tests := []struct {
+		obj      Object
+		opts     FormatOptions
+		expected string
+	}{
+		{nested, FormatOptions{}, "[1, [2, [1]]]"},
+		{nested, FormatOptions{MaxDepth: 2}, "[1, [2, [...]]]"},
+		{&Array{}, FormatOptions{MaxDepth: 1}, "[]"},
+		{hash, FormatOptions{}, "{name: web, ports: [80, 443], tls: {enabled: true}}"},
+		{hash, FormatOptions{Indent: "  ", Width: 80}, "{name: web, ports: [80, 443], tls: {enabled: true}}"},
+		{hash, FormatOptions{Indent: "  ", Width: 20}, "{\n  name: web,\n  ports: [80, 443],\n  tls: {enabled: true}\n}"},
+		{hash, FormatOptions{Indent: "\t"}, "{\n\tname: web,\n\tports: [\n\t\t80,\n\t\t443\n\t],\n\ttls: {\n\t\tenabled: true\n\t}\n}"},
+		{hash, FormatOptions{Indent: "  ", MaxDepth: 1}, "{\n  name: web,\n  ports: [...],\n  tls: {...}\n}"},
+		{cyclic, FormatOptions{}, "[1, <cycle>]"},
+		{cyclic, FormatOptions{Indent: " "}, "[\n 1,\n <cycle>\n]"},
+		{&Array{Elements: []Object{shared, shared}}, FormatOptions{}, "[[2], [2]]"},
+		{one, FormatOptions{Indent: "  "}, "1"},
+	}
+
+	for _, tt := range tests {
+		if got := Format(tt.obj, tt.opts); got != tt.expected {
+			t.Errorf("wrong format with %+v.\nwant=%q\ngot= %q", tt.opts, tt.expected, got)
+		}
+	}
+
+	if cyclic.Inspect() != "[1, <cycle>]" {
+		t.Errorf("Inspect is not cycle-safe. got=%q", cyclic.Inspect())
+	}
+}
+
+func testHash(values map[string]Object) *Hash {
+	pairs := make(map[HashKey]HashPair, len(values))
+	for key, value := range values {
+		k := &String{Value: key}
+		pairs[k.HashKey()] = HashPair{Key: k, Value: value}
+	}
+	return &Hash{Pairs: pairs}
+}
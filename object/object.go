@@ -2,9 +2,12 @@ package object
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"hash/fnv"
 	"monkey/ast"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -12,8 +15,9 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
-	ARRAY_OBJ        = "BOOLEAN"
+	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
@@ -21,6 +25,10 @@ const (
 	FUNCTION_OBJ     = "FUNCTION"
 	STRING_OBJ       = "STRING"
 	BUILTIN_OBJ      = "BUILTIN"
+	TIME_OBJ         = "TIME"
+	DURATION_OBJ     = "DURATION"
+	BYTES_OBJ        = "BYTES"
+	TUPLE_OBJ        = "TUPLE"
 )
 
 type Object interface {
@@ -28,6 +36,16 @@ type Object interface {
 	Inspect() string
 }
 
+// TRUE, FALSE and NULL are the canonical boolean and null objects. The
+// evaluator compares them by identity, so anything producing booleans or
+// null outside the evaluator must hand out these instances. They are shared
+// by every interpreter in the process and must never be modified.
+var (
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+	NULL  = &Null{}
+)
+
 type Array struct {
 	Elements []Object
 }
@@ -37,24 +55,85 @@ func (ar *Array) Type() ObjectType {
 }
 
 func (ar *Array) Inspect() string {
-	var out = bytes.Buffer{}
-	elems := []string{}
+	return Format(ar, FormatOptions{})
+}
 
-	for _, element := range ar.Elements {
-		elems = append(elems, element.Inspect())
-	}
+// Tuple holds the values of a return statement with several results, as in
+// "return a, b". Unlike an array it cannot grow.
+type Tuple struct {
+	Elements []Object
+}
 
-	out.WriteString("[")
-	out.WriteString(strings.Join(elems, ", "))
-	out.WriteString("]")
+func (t *Tuple) Type() ObjectType {
+	return TUPLE_OBJ
+}
 
-	return out.String()
+func (t *Tuple) Inspect() string {
+	return Format(t, FormatOptions{})
 }
 
 type Integer struct {
 	Value int64
 }
 
+const (
+	minCachedInteger = -128
+	maxCachedInteger = 255
+)
+
+var smallIntegers = func() []*Integer {
+	integers := make([]*Integer, maxCachedInteger-minCachedInteger+1)
+	for i := range integers {
+		integers[i] = &Integer{Value: int64(i + minCachedInteger)}
+	}
+	return integers
+}()
+
+// NewInteger returns an Integer holding value. Small values share
+// preallocated objects, so the result must never be modified.
+func NewInteger(value int64) *Integer {
+	if value >= minCachedInteger && value <= maxCachedInteger {
+		return smallIntegers[value-minCachedInteger]
+	}
+
+	return &Integer{Value: value}
+}
+
+// integerSlabSize is how many Integers an IntegerArena allocates at once.
+// It is kept small because a reachable Integer keeps its whole slab in
+// memory: at 16 Integers, a long-lived value pins at most 256 bytes, while
+// arithmetic still allocates a sixteenth as often.
+const integerSlabSize = 16
+
+// IntegerArena hands out Integers carved from slabs allocated together, so
+// arithmetic producing many short-lived results costs one allocation per
+// slab rather than one per result. A slab stays in memory while any of its
+// Integers is reachable. The zero value is ready to use, and a nil arena
+// allocates each Integer on its own. An arena must not be used by several
+// goroutines at once.
+type IntegerArena struct {
+	free []Integer
+}
+
+// New returns an Integer holding value, like NewInteger. The result must
+// never be modified.
+func (a *IntegerArena) New(value int64) *Integer {
+	if value >= minCachedInteger && value <= maxCachedInteger {
+		return smallIntegers[value-minCachedInteger]
+	}
+	if a == nil {
+		return &Integer{Value: value}
+	}
+
+	if len(a.free) == 0 {
+		a.free = make([]Integer, integerSlabSize)
+	}
+	i := &a.free[0]
+	a.free = a.free[1:]
+	i.Value = value
+	return i
+}
+
 func (i *Integer) Type() ObjectType {
 	return INTEGER_OBJ
 }
@@ -63,10 +142,52 @@ func (i *Integer) Inspect() string {
 	return fmt.Sprintf("%d", i.Value)
 }
 
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+// Inspect prints the shortest representation that reads back as the same
+// value, keeping a decimal point so floats are told apart from integers.
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if strings.ContainsAny(s, ".eIN") {
+		return s
+	}
+	return s + ".0"
+}
+
 type String struct {
 	Value string
 }
 
+var shortStrings = func() []*String {
+	strings := make([]*String, 128)
+	for i := range strings {
+		strings[i] = &String{Value: string(rune(i))}
+	}
+	return strings
+}()
+
+var emptyString = &String{Value: ""}
+
+// NewString returns a String holding value. The empty string and single
+// ASCII characters share preallocated objects, so the result must never be
+// modified.
+func NewString(value string) *String {
+	switch {
+	case len(value) == 0:
+		return emptyString
+	case len(value) == 1 && value[0] < 128:
+		return shortStrings[value[0]]
+	default:
+		return &String{Value: value}
+	}
+}
+
 func (s *String) Type() ObjectType {
 	return STRING_OBJ
 }
@@ -105,8 +226,48 @@ func (rv *ReturnValue) Inspect() string {
 	return rv.Value.Inspect()
 }
 
+// ErrorCategory classifies an Error so Go hosts can branch on the kind of
+// failure with errors.Is instead of matching on messages.
+type ErrorCategory string
+
+const (
+	RuntimeError      ErrorCategory = "RuntimeError"
+	TypeError         ErrorCategory = "TypeError"
+	NameError         ErrorCategory = "NameError"
+	ArgumentError     ErrorCategory = "ArgumentError"
+	ZeroDivisionError ErrorCategory = "ZeroDivisionError"
+	CancelledError    ErrorCategory = "CancelledError"
+	RecursionError    ErrorCategory = "RecursionError"
+	PermissionError   ErrorCategory = "PermissionError"
+
+	// InternalError reports a bug in the interpreter: a Go panic during
+	// evaluation, with its stack in the error's Stack.
+	InternalError ErrorCategory = "InternalError"
+)
+
+func (c ErrorCategory) Error() string {
+	return string(c)
+}
+
+// Error is both the Monkey error value and a Go error. errors.Is matches it
+// against its ErrorCategory and, if set, its Go cause.
 type Error struct {
-	Message string
+	Category ErrorCategory
+	Message  string
+	Cause    error
+
+	// Format and Args are what Message was formatted from, if it was made
+	// by Errorf, so it can be reworded with a diagnostic.Catalog.
+	Format string
+	Args   []any
+
+	// Stack is the Go stack of an InternalError, for bug reports.
+	Stack string
+}
+
+// Errorf returns an error whose message is format applied to args.
+func Errorf(category ErrorCategory, format string, args ...any) *Error {
+	return &Error{Category: category, Message: fmt.Sprintf(format, args...), Format: format, Args: args}
 }
 
 func (e *Error) Type() ObjectType {
@@ -117,42 +278,46 @@ func (e *Error) Inspect() string {
 	return "ERROR: " + e.Message
 }
 
-type Environment struct {
-	store map[string]Object
-	outer *Environment
-}
-
-func ExtendEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outer
-	return env
+func (e *Error) Error() string {
+	return string(e.EffectiveCategory()) + ": " + e.Message
 }
 
-func NewEnvironment() *Environment {
-	return &Environment{
-		store: make(map[string]Object),
-		outer: nil,
+func (e *Error) Is(target error) bool {
+	switch target := target.(type) {
+	case ErrorCategory:
+		return e.EffectiveCategory() == target
+	case *Error:
+		return e.EffectiveCategory() == target.EffectiveCategory() && e.Message == target.Message
+	default:
+		return false
 	}
 }
 
-func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
 
-	if !ok && e.outer != nil {
-		obj, ok = e.outer.store[name]
+// EffectiveCategory returns e's category, which is RuntimeError if
+// Category was left empty.
+func (e *Error) EffectiveCategory() ErrorCategory {
+	if e.Category == "" {
+		return RuntimeError
 	}
-
-	return obj, ok
-}
-func (e *Environment) Set(name string, val Object) Object {
-	e.store[name] = val
-	return val
+	return e.Category
 }
 
 type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	// Name is the name the function literal was let-bound to, or empty for
+	// an anonymous function.
+	Name string
+	// Locals are the slot names the resolver assigned to the function's
+	// environment, or nil if it was not resolved.
+	Locals []string
+	// Captured marks the Locals that closures may refer to after a call.
+	Captured []bool
 }
 
 func (f *Function) Type() ObjectType {
@@ -178,10 +343,15 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 
-type BuiltinFunction func(args ...Object) Object
+// BuiltinFunction implements a builtin. ctx is the context the evaluation
+// was started with; long-running builtins must stop when it is done.
+type BuiltinFunction func(ctx context.Context, args ...Object) Object
 
 type Builtin struct {
 	Fn BuiltinFunction
+	// Pure marks builtins whose calls have no effect beyond computing their
+	// result from their arguments, and call no functions passed to them.
+	Pure bool
 }
 
 func (b *Builtin) Type() ObjectType {
@@ -222,28 +392,73 @@ type HashPair struct {
 	Value Object
 }
 
+// Hash maps keys to values and remembers the order keys were added with
+// Set. Pairs may also be filled in directly; such keys are ordered after
+// the others, by their Inspect form.
 type Hash struct {
 	Pairs map[HashKey]HashPair
-}
+	// Default is what indexing returns for a missing key, or nil for null.
+	// If it is a function, it is called without arguments instead.
+	Default Object
 
-func (h *Hash) Type() ObjectType {
-	return HASH_OBJ
+	order []HashKey
 }
 
-func (h *Hash) Inspect() string {
-	var out bytes.Buffer
-	pairs := []string{}
+// Set adds pair under key, or replaces the pair already there without
+// changing its position.
+func (h *Hash) Set(key HashKey, pair HashPair) {
+	if h.Pairs == nil {
+		h.Pairs = make(map[HashKey]HashPair)
+	}
+	if _, ok := h.Pairs[key]; !ok {
+		h.order = append(h.order, key)
+	}
+	h.Pairs[key] = pair
+}
+
+// Ordered returns the pairs in insertion order.
+func (h *Hash) Ordered() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	seen := make(map[HashKey]bool, len(h.order))
+	for _, key := range h.order {
+		if pair, ok := h.Pairs[key]; ok && !seen[key] {
+			seen[key] = true
+			pairs = append(pairs, pair)
+		}
+	}
 
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s",
-			pair.Key.Inspect(), pair.Value.Inspect()))
+	if len(pairs) == len(h.Pairs) {
+		return pairs
 	}
 
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
+	var rest []HashPair
+	for key, pair := range h.Pairs {
+		if !seen[key] {
+			rest = append(rest, pair)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		return rest[i].Key.Inspect() < rest[j].Key.Inspect()
+	})
 
-	return out.String()
+	return append(pairs, rest...)
+}
+
+// Clone returns a copy of h with the same pairs, order and default.
+func (h *Hash) Clone() *Hash {
+	clone := &Hash{Pairs: make(map[HashKey]HashPair, len(h.Pairs)), Default: h.Default}
+	for _, pair := range h.Ordered() {
+		clone.Set(pair.Key.(Hashable).HashKey(), pair)
+	}
+	return clone
+}
+
+func (h *Hash) Type() ObjectType {
+	return HASH_OBJ
+}
+
+func (h *Hash) Inspect() string {
+	return Format(h, FormatOptions{})
 }
 
 type Hashable interface {
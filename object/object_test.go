@@ -1,6 +1,10 @@
 package object
 
-import "testing"
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -58,3 +62,141 @@ func TestIntegerHashKey(t *testing.T) {
 		t.Errorf("integers with twoerent content have same hash keys")
 	}
 }
+
+func TestErrorImplementsError(t *testing.T) {
+	var err error = &Error{Category: TypeError, Message: "type mismatch: INTEGER + BOOLEAN"}
+
+	if err.Error() != "TypeError: type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong error string. got=%q", err.Error())
+	}
+
+	if !errors.Is(err, TypeError) {
+		t.Errorf("errors.Is does not match the error's category")
+	}
+
+	if errors.Is(err, NameError) {
+		t.Errorf("errors.Is matches a different category")
+	}
+
+	if !errors.Is(&Error{Message: "boom"}, RuntimeError) {
+		t.Errorf("uncategorized errors should be RuntimeErrors")
+	}
+}
+
+func TestNewIntegerSharesSmallValues(t *testing.T) {
+	if NewInteger(-128) != NewInteger(-128) || NewInteger(255) != NewInteger(255) {
+		t.Errorf("small integers are not shared")
+	}
+
+	if NewInteger(256) == NewInteger(256) || NewInteger(-129) == NewInteger(-129) {
+		t.Errorf("integers outside the cached range are shared")
+	}
+
+	for _, value := range []int64{-129, -128, 0, 42, 255, 256} {
+		if NewInteger(value).Value != value {
+			t.Errorf("NewInteger(%d) has wrong value %d", value, NewInteger(value).Value)
+		}
+	}
+}
+
+func TestIntegerArena(t *testing.T) {
+	var arena IntegerArena
+	if arena.New(7) != NewInteger(7) {
+		t.Errorf("arena does not share small integers")
+	}
+
+	seen := make(map[*Integer]bool)
+	for value := int64(1000); value < 1000+3*integerSlabSize; value++ {
+		i := arena.New(value)
+		if i.Value != value || seen[i] {
+			t.Fatalf("arena.New(%d) gave %d, reused=%t", value, i.Value, seen[i])
+		}
+		seen[i] = true
+	}
+
+	var none *IntegerArena
+	if i := none.New(1000); i.Value != 1000 {
+		t.Errorf("nil arena gave %d", i.Value)
+	}
+}
+
+func TestIntegerArenaRetention(t *testing.T) {
+	// The worst case keeps one Integer of every slab reachable, pinning
+	// every slab.
+	const slabs = 1 << 14
+	kept := make([]*Integer, 0, slabs)
+	before := heapAlloc()
+
+	var arena IntegerArena
+	for n := 0; n < slabs*integerSlabSize; n++ {
+		i := arena.New(int64(1000 + n))
+		if n%integerSlabSize == 0 {
+			kept = append(kept, i)
+		}
+	}
+	arena = IntegerArena{}
+
+	// A slab is 256 bytes; allow for the kept slice and other noise.
+	retained := (heapAlloc() - before) / slabs
+	if retained > 320 {
+		t.Errorf("each kept Integer retains %d bytes, want at most 256", retained)
+	}
+	runtime.KeepAlive(kept)
+}
+
+// heapAlloc returns the bytes of live heap objects after a collection.
+func heapAlloc() uint64 {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func TestNewStringSharesShortValues(t *testing.T) {
+	if NewString("") != NewString("") || NewString("a") != NewString("a") {
+		t.Errorf("short strings are not shared")
+	}
+
+	if NewString("ab") == NewString("ab") || NewString("é") == NewString("é") {
+		t.Errorf("longer strings are shared")
+	}
+
+	for _, value := range []string{"", "a", "ab", "é"} {
+		if NewString(value).Value != value {
+			t.Errorf("NewString(%q) has wrong value %q", value, NewString(value).Value)
+		}
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	set := func(h *Hash, key string, value int64) {
+		k := &String{Value: key}
+		h.Set(k.HashKey(), HashPair{Key: k, Value: &Integer{Value: value}})
+	}
+
+	hash := &Hash{}
+	set(hash, "b", 1)
+	set(hash, "c", 2)
+	set(hash, "a", 3)
+	set(hash, "c", 4)
+
+	if got := hash.Inspect(); got != "{b: 1, c: 4, a: 3}" {
+		t.Errorf("wrong order. got=%q", got)
+	}
+
+	clone := hash.Clone()
+	set(clone, "0", 5)
+	if got := clone.Inspect(); got != "{b: 1, c: 4, a: 3, 0: 5}" {
+		t.Errorf("wrong order in clone. got=%q", got)
+	}
+	if len(hash.Pairs) != 3 {
+		t.Errorf("setting a key in the clone changed the original")
+	}
+
+	y, x := &String{Value: "y"}, &String{Value: "x"}
+	hash.Pairs[y.HashKey()] = HashPair{Key: y, Value: NULL}
+	hash.Pairs[x.HashKey()] = HashPair{Key: x, Value: NULL}
+	if got := hash.Inspect(); got != "{b: 1, c: 4, a: 3, x: null, y: null}" {
+		t.Errorf("keys added to Pairs directly not sorted last. got=%q", got)
+	}
+}
@@ -0,0 +1,185 @@
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const snapshotVersion = 1
+
+// Snapshot is a portable copy of the serializable bindings of an
+// Environment. Functions and builtins cannot be serialized; their names are
+// listed in Skipped so callers can tell the user what was left behind.
+type Snapshot struct {
+	Version  int                       `json:"version"`
+	Bindings map[string]*snapshotValue `json:"bindings"`
+	Skipped  []string                  `json:"-"`
+}
+
+type snapshotValue struct {
+	Type     ObjectType       `json:"type"`
+	Integer  int64            `json:"integer,omitempty"`
+	String   string           `json:"string,omitempty"`
+	Boolean  bool             `json:"boolean,omitempty"`
+	Elements []*snapshotValue `json:"elements,omitempty"`
+	Pairs    []snapshotPair   `json:"pairs,omitempty"`
+}
+
+type snapshotPair struct {
+	Key   *snapshotValue `json:"key"`
+	Value *snapshotValue `json:"value"`
+}
+
+// Snapshot captures the bindings visible from the environment, with inner
+// bindings shadowing outer ones.
+func (e *Environment) Snapshot() *Snapshot {
+	snapshot := &Snapshot{
+		Version:  snapshotVersion,
+		Bindings: make(map[string]*snapshotValue),
+	}
+
+	seen := make(map[string]bool)
+	for env := e; env != nil; env = env.outer {
+		env.Each(func(name string, obj Object) {
+			if seen[name] {
+				return
+			}
+			seen[name] = true
+
+			value, ok := encodeSnapshotValue(obj)
+			if !ok {
+				snapshot.Skipped = append(snapshot.Skipped, name)
+				return
+			}
+			snapshot.Bindings[name] = value
+		})
+	}
+
+	sort.Strings(snapshot.Skipped)
+
+	return snapshot
+}
+
+// Encode writes the snapshot to w as JSON.
+func (s *Snapshot) Encode(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// DecodeSnapshot reads a snapshot previously written by Encode.
+func DecodeSnapshot(r io.Reader) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	if err := json.NewDecoder(r).Decode(snapshot); err != nil {
+		return nil, err
+	}
+
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	return snapshot, nil
+}
+
+// Restore creates a fresh environment holding the snapshot's bindings.
+func (s *Snapshot) Restore() (*Environment, error) {
+	env := NewEnvironment()
+
+	for name, value := range s.Bindings {
+		obj, err := decodeSnapshotValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("binding %s: %w", name, err)
+		}
+		env.Set(name, Canonical(obj))
+	}
+
+	return env, nil
+}
+
+func encodeSnapshotValue(obj Object) (*snapshotValue, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return &snapshotValue{Type: INTEGER_OBJ, Integer: obj.Value}, true
+	case *String:
+		return &snapshotValue{Type: STRING_OBJ, String: obj.Value}, true
+	case *Boolean:
+		return &snapshotValue{Type: BOOLEAN_OBJ, Boolean: obj.Value}, true
+	case *Null:
+		return &snapshotValue{Type: NULL_OBJ}, true
+	case *Array:
+		value := &snapshotValue{Type: ARRAY_OBJ}
+		for _, element := range obj.Elements {
+			encoded, ok := encodeSnapshotValue(element)
+			if !ok {
+				return nil, false
+			}
+			value.Elements = append(value.Elements, encoded)
+		}
+		return value, true
+	case *Hash:
+		value := &snapshotValue{Type: HASH_OBJ}
+		for _, pair := range obj.Ordered() {
+			key, ok := encodeSnapshotValue(pair.Key)
+			if !ok {
+				return nil, false
+			}
+			val, ok := encodeSnapshotValue(pair.Value)
+			if !ok {
+				return nil, false
+			}
+			value.Pairs = append(value.Pairs, snapshotPair{Key: key, Value: val})
+		}
+		return value, true
+	default:
+		return nil, false
+	}
+}
+
+func decodeSnapshotValue(value *snapshotValue) (Object, error) {
+	if value == nil {
+		return nil, fmt.Errorf("missing value")
+	}
+
+	switch value.Type {
+	case INTEGER_OBJ:
+		return &Integer{Value: value.Integer}, nil
+	case STRING_OBJ:
+		return &String{Value: value.String}, nil
+	case BOOLEAN_OBJ:
+		return &Boolean{Value: value.Boolean}, nil
+	case NULL_OBJ:
+		return &Null{}, nil
+	case ARRAY_OBJ:
+		elements := make([]Object, 0, len(value.Elements))
+		for _, element := range value.Elements {
+			decoded, err := decodeSnapshotValue(element)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, decoded)
+		}
+		return &Array{Elements: elements}, nil
+	case HASH_OBJ:
+		hash := &Hash{Pairs: make(map[HashKey]HashPair, len(value.Pairs))}
+		for _, pair := range value.Pairs {
+			key, err := decodeSnapshotValue(pair.Key)
+			if err != nil {
+				return nil, err
+			}
+			hashable, ok := key.(Hashable)
+			if !ok {
+				return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+			}
+			val, err := decodeSnapshotValue(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(hashable.HashKey(), HashPair{Key: key, Value: val})
+		}
+		return hash, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", value.Type)
+	}
+}
@@ -0,0 +1,84 @@
+package object
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("shadowed", &Integer{Value: 1})
+	outer.Set("name", &String{Value: "monkey"})
+
+	env := ExtendEnvironment(outer)
+	env.Set("shadowed", &Integer{Value: 2})
+	env.Set("flag", TRUE)
+	env.Set("nothing", NULL)
+	env.Set("list", &Array{Elements: []Object{&Integer{Value: 1}, FALSE}})
+	table := &Hash{}
+	for i, name := range []string{"key", "a"} {
+		key := &String{Value: name}
+		table.Set(key.HashKey(), HashPair{Key: key, Value: &Integer{Value: int64(42 + i)}})
+	}
+	env.Set("table", table)
+	env.Set("fn", &Builtin{})
+
+	var buf bytes.Buffer
+	if err := env.Snapshot().Encode(&buf); err != nil {
+		t.Fatalf("Encode failed: %s", err)
+	}
+
+	snapshot, err := DecodeSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("DecodeSnapshot failed: %s", err)
+	}
+
+	restored, err := snapshot.Restore()
+	if err != nil {
+		t.Fatalf("Restore failed: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"shadowed", "2"},
+		{"name", "monkey"},
+		{"flag", "true"},
+		{"nothing", "null"},
+		{"list", "[1, false]"},
+		{"table", "{key: 42, a: 43}"},
+	}
+
+	for _, tt := range tests {
+		obj, ok := restored.Get(tt.name)
+		if !ok {
+			t.Errorf("binding %s missing after restore", tt.name)
+			continue
+		}
+		if obj.Inspect() != tt.expected {
+			t.Errorf("binding %s wrong. want=%q, got=%q", tt.name, tt.expected, obj.Inspect())
+		}
+	}
+
+	if flag, _ := restored.Get("flag"); flag != TRUE {
+		t.Errorf("restored boolean is not the canonical TRUE")
+	}
+
+	if _, ok := restored.Get("fn"); ok {
+		t.Errorf("builtin should not have been restored")
+	}
+}
+
+func TestSnapshotSkipped(t *testing.T) {
+	env := NewEnvironment()
+	env.Set("ok", &Integer{Value: 1})
+	env.Set("fn", &Builtin{})
+	env.Set("nested", &Array{Elements: []Object{&Builtin{}}})
+
+	snapshot := env.Snapshot()
+
+	if len(snapshot.Skipped) != 2 || snapshot.Skipped[0] != "fn" || snapshot.Skipped[1] != "nested" {
+		t.Errorf("wrong skipped bindings. got=%v", snapshot.Skipped)
+	}
+}
@@ -0,0 +1,134 @@
+package object
+
+import (
+	"context"
+	"time"
+)
+
+// Time is an instant with a location, shown in RFC 3339 format.
+type Time struct {
+	Value time.Time
+}
+
+func (t *Time) Type() ObjectType { return TIME_OBJ }
+
+func (t *Time) Inspect() string {
+	return t.Value.Format(time.RFC3339Nano)
+}
+
+// Equal reports whether both times are the same instant, whatever their
+// locations.
+func (t *Time) Equal(other Object) bool {
+	return t.Value.Equal(other.(*Time).Value)
+}
+
+func (t *Time) HashKey() HashKey {
+	return HashKey{Type: t.Type(), Value: uint64(t.Value.UnixNano())}
+}
+
+// Method implements MethodProvider:
+//
+//	t["format"](layout)   formats t with a Go time layout
+//	t["unix"]()           seconds since the Unix epoch
+//	t["utc"]()            the same instant in UTC
+//	t["year"](), t["month"](), t["day"](), t["hour"](), t["minute"](),
+//	t["second"](), t["weekday"]()   calendar fields; weekday 0 is Sunday
+func (t *Time) Method(name string) (BuiltinFunction, bool) {
+	switch name {
+	case "format":
+		return func(ctx context.Context, args ...Object) Object {
+			if len(args) != 1 {
+				return wrongArguments(len(args), 1)
+			}
+			layout, ok := args[0].(*String)
+			if !ok {
+				return Errorf(TypeError, "layout must be STRING, got %s", args[0].Type())
+			}
+			return &String{Value: t.Value.Format(layout.Value)}
+		}, true
+	case "utc":
+		return func(ctx context.Context, args ...Object) Object {
+			if len(args) != 0 {
+				return wrongArguments(len(args), 0)
+			}
+			return &Time{Value: t.Value.UTC()}
+		}, true
+	}
+
+	var field func() int64
+	switch name {
+	case "unix":
+		field = t.Value.Unix
+	case "year":
+		field = func() int64 { return int64(t.Value.Year()) }
+	case "month":
+		field = func() int64 { return int64(t.Value.Month()) }
+	case "day":
+		field = func() int64 { return int64(t.Value.Day()) }
+	case "hour":
+		field = func() int64 { return int64(t.Value.Hour()) }
+	case "minute":
+		field = func() int64 { return int64(t.Value.Minute()) }
+	case "second":
+		field = func() int64 { return int64(t.Value.Second()) }
+	case "weekday":
+		field = func() int64 { return int64(t.Value.Weekday()) }
+	default:
+		return nil, false
+	}
+
+	return integerMethod(field), true
+}
+
+// Duration is a span of time, shown like "1h30m0s".
+type Duration struct {
+	Value time.Duration
+}
+
+func (d *Duration) Type() ObjectType { return DURATION_OBJ }
+
+func (d *Duration) Inspect() string {
+	return d.Value.String()
+}
+
+func (d *Duration) Equal(other Object) bool {
+	return d.Value == other.(*Duration).Value
+}
+
+func (d *Duration) HashKey() HashKey {
+	return HashKey{Type: d.Type(), Value: uint64(d.Value)}
+}
+
+// Method implements MethodProvider. d["hours"](), d["minutes"](),
+// d["seconds"]() and d["milliseconds"]() return the whole number of each
+// unit in d.
+func (d *Duration) Method(name string) (BuiltinFunction, bool) {
+	var unit time.Duration
+	switch name {
+	case "hours":
+		unit = time.Hour
+	case "minutes":
+		unit = time.Minute
+	case "seconds":
+		unit = time.Second
+	case "milliseconds":
+		unit = time.Millisecond
+	default:
+		return nil, false
+	}
+
+	return integerMethod(func() int64 { return int64(d.Value / unit) }), true
+}
+
+func integerMethod(field func() int64) BuiltinFunction {
+	return func(ctx context.Context, args ...Object) Object {
+		if len(args) != 0 {
+			return wrongArguments(len(args), 0)
+		}
+		return NewInteger(field())
+	}
+}
+
+func wrongArguments(got, want int) *Error {
+	return Errorf(ArgumentError, "wrong number of arguments. got=%d, want=%d", got, want)
+}
@@ -0,0 +1,63 @@
+package object
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeMethods(t *testing.T) {
+	moment := &Time{Value: time.Date(2024, time.March, 1, 12, 30, 15, 0, time.UTC)}
+	span := &Duration{Value: 90 * time.Minute}
+
+	tests := []struct {
+		receiver MethodProvider
+		method   string
+		args     []Object
+		expected string
+	}{
+		{moment, "year", nil, "2024"},
+		{moment, "month", nil, "3"},
+		{moment, "day", nil, "1"},
+		{moment, "minute", nil, "30"},
+		{moment, "second", nil, "15"},
+		{moment, "unix", nil, "1709296215"},
+		{moment, "format", []Object{&String{Value: "15:04"}}, "12:30"},
+		{moment, "format", []Object{&Integer{Value: 1}}, "ERROR: layout must be STRING, got INTEGER"},
+		{moment, "year", []Object{NULL}, "ERROR: wrong number of arguments. got=1, want=0"},
+		{span, "hours", nil, "1"},
+		{span, "seconds", nil, "5400"},
+		{span, "milliseconds", nil, "5400000"},
+	}
+
+	for _, tt := range tests {
+		method, ok := tt.receiver.Method(tt.method)
+		if !ok {
+			t.Errorf("%s: method not found", tt.method)
+			continue
+		}
+
+		result := method(context.Background(), tt.args...)
+		if result.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. want=%q, got=%q", tt.method, tt.expected, result.Inspect())
+		}
+	}
+
+	if _, ok := moment.Method("hours"); ok {
+		t.Errorf("TIME has a method it should not")
+	}
+}
+
+func TestTimeConversion(t *testing.T) {
+	moment := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	obj, err := FromGo(moment)
+	if err != nil || obj.Inspect() != "2024-03-01T12:00:00Z" {
+		t.Errorf("wrong conversion from time.Time: %v, %v", obj, err)
+	}
+
+	value, err := ToGo(&Duration{Value: 2 * time.Second})
+	if err != nil || value != "2s" {
+		t.Errorf("wrong conversion of DURATION: %v, %v", value, err)
+	}
+}
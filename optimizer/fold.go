@@ -0,0 +1,234 @@
+// Package optimizer rewrites parsed programs ahead of evaluation.
+package optimizer
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"strconv"
+)
+
+// Fold replaces operations on literals with their result, so "60 * 60 * 24"
+// is computed once instead of on every evaluation, and marks array and hash
+// literals built only from literals as constant, which lets the evaluator
+// build their elements once and reuse them. Operations that would fail at
+// run time, such as division by zero, are left for the evaluator to report.
+// Folding is not observable: each evaluation of a constant literal is still
+// a new array or hash, as == compares them by identity.
+//
+// Fold modifies program in place. Run it before sharing the program between
+// goroutines.
+func Fold(program *ast.Program) {
+	for _, stmt := range program.Statements {
+		foldStatement(stmt)
+	}
+}
+
+func foldStatement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		if stmt != nil {
+			stmt.Value = foldExpression(stmt.Value)
+		}
+	case *ast.ReturnStatement:
+		if stmt != nil {
+			stmt.ReturnValue = foldExpression(stmt.ReturnValue)
+		}
+	case *ast.ExpressionStatement:
+		if stmt != nil {
+			stmt.Expression = foldExpression(stmt.Expression)
+		}
+	case *ast.BlockStatement:
+		foldBlock(stmt)
+	}
+}
+
+func foldBlock(block *ast.BlockStatement) {
+	if block == nil {
+		return
+	}
+
+	for _, stmt := range block.Statements {
+		foldStatement(stmt)
+	}
+}
+
+func foldExpression(expr ast.Expression) ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.PrefixExpression:
+		expr.Right = foldExpression(expr.Right)
+		return foldPrefix(expr)
+	case *ast.InfixExpression:
+		expr.Left = foldExpression(expr.Left)
+		expr.Right = foldExpression(expr.Right)
+		return foldInfix(expr)
+	case *ast.IsExpression:
+		expr.Left = foldExpression(expr.Left)
+	case *ast.AssignExpression:
+		expr.Value = foldExpression(expr.Value)
+	case *ast.IfExpression:
+		expr.Condition = foldExpression(expr.Condition)
+		foldBlock(expr.Consequence)
+		foldBlock(expr.Alternative)
+	case *ast.WhileExpression:
+		expr.Condition = foldExpression(expr.Condition)
+		foldBlock(expr.Body)
+	case *ast.ForExpression:
+		expr.Iterable = foldExpression(expr.Iterable)
+		foldBlock(expr.Body)
+	case *ast.FunctionLiteral:
+		foldBlock(expr.Body)
+	case *ast.CallExpression:
+		expr.Function = foldExpression(expr.Function)
+		for i, arg := range expr.Arguments {
+			expr.Arguments[i] = foldExpression(arg)
+		}
+	case *ast.IndexExpression:
+		expr.Left = foldExpression(expr.Left)
+		expr.Index = foldExpression(expr.Index)
+	case *ast.TupleLiteral:
+		for i, element := range expr.Elements {
+			expr.Elements[i] = foldExpression(element)
+		}
+	case *ast.ArrayLiteral:
+		constant := true
+		for i, element := range expr.Elements {
+			expr.Elements[i] = foldExpression(element)
+			constant = constant && isConstant(expr.Elements[i])
+		}
+		expr.Constant = constant
+	case *ast.HashLiteral:
+		pairs := make(map[ast.Expression]ast.Expression, len(expr.Pairs))
+		constant := true
+		for i, key := range expr.Keys {
+			value := foldExpression(expr.Pairs[key])
+			key = foldExpression(key)
+			pairs[key] = value
+			expr.Keys[i] = key
+			constant = constant && isConstant(key) && isConstant(value)
+		}
+		expr.Pairs = pairs
+		expr.Constant = constant
+	}
+
+	return expr
+}
+
+func isConstant(expr ast.Expression) bool {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean:
+		return true
+	case *ast.ArrayLiteral:
+		return expr.Constant
+	case *ast.HashLiteral:
+		return expr.Constant
+	default:
+		return false
+	}
+}
+
+func foldPrefix(expr *ast.PrefixExpression) ast.Expression {
+	switch right := expr.Right.(type) {
+	case *ast.IntegerLiteral:
+		switch expr.Operator {
+		case token.MINUS:
+			return integerLiteral(-right.Value)
+		case token.BANG:
+			return booleanLiteral(false)
+		}
+	case *ast.StringLiteral:
+		if expr.Operator == token.BANG {
+			return booleanLiteral(false)
+		}
+	case *ast.Boolean:
+		if expr.Operator == token.BANG {
+			return booleanLiteral(!right.Value)
+		}
+	}
+
+	return expr
+}
+
+func foldInfix(expr *ast.InfixExpression) ast.Expression {
+	switch left := expr.Left.(type) {
+	case *ast.IntegerLiteral:
+		if right, ok := expr.Right.(*ast.IntegerLiteral); ok {
+			if folded := foldIntegers(expr.Operator, left.Value, right.Value); folded != nil {
+				return folded
+			}
+		}
+	case *ast.StringLiteral:
+		if right, ok := expr.Right.(*ast.StringLiteral); ok && expr.Operator == token.PLUS {
+			return stringLiteral(left.Value + right.Value)
+		}
+	case *ast.Boolean:
+		if right, ok := expr.Right.(*ast.Boolean); ok {
+			switch expr.Operator {
+			case token.EQ:
+				return booleanLiteral(left.Value == right.Value)
+			case token.NOT_EQ:
+				return booleanLiteral(left.Value != right.Value)
+			case token.AND:
+				return booleanLiteral(left.Value && right.Value)
+			case token.OR:
+				return booleanLiteral(left.Value || right.Value)
+			}
+		}
+	}
+
+	return expr
+}
+
+func foldIntegers(operator string, left, right int64) ast.Expression {
+	switch operator {
+	case token.PLUS:
+		return integerLiteral(left + right)
+	case token.MINUS:
+		return integerLiteral(left - right)
+	case token.ASTERISK:
+		return integerLiteral(left * right)
+	case token.FLOOR_SLASH:
+		if right == 0 {
+			return nil
+		}
+		q := left / right
+		if left%right != 0 && (left < 0) != (right < 0) {
+			q--
+		}
+		return integerLiteral(q)
+	case token.LT:
+		return booleanLiteral(left < right)
+	case token.GT:
+		return booleanLiteral(left > right)
+	case token.LT_EQ:
+		return booleanLiteral(left <= right)
+	case token.GT_EQ:
+		return booleanLiteral(left >= right)
+	case token.EQ:
+		return booleanLiteral(left == right)
+	case token.NOT_EQ:
+		return booleanLiteral(left != right)
+	default:
+		return nil
+	}
+}
+
+func integerLiteral(value int64) *ast.IntegerLiteral {
+	return &ast.IntegerLiteral{
+		Token: token.Token{Type: token.INT, Literal: strconv.FormatInt(value, 10)},
+		Value: value,
+	}
+}
+
+func stringLiteral(value string) *ast.StringLiteral {
+	return &ast.StringLiteral{
+		Token: token.Token{Type: token.STRING, Literal: value},
+		Value: value,
+	}
+}
+
+func booleanLiteral(value bool) *ast.Boolean {
+	if value {
+		return &ast.Boolean{Token: token.Token{Type: token.TRUE, Literal: "true"}, Value: true}
+	}
+	return &ast.Boolean{Token: token.Token{Type: token.FALSE, Literal: "false"}, Value: false}
+}
@@ -0,0 +1,91 @@
+package optimizer_test
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/optimizer"
+	"monkey/parser"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"60 * 60 * 24", "86400"},
+		{"-(2 + 3)", "-5"},
+		{"1 + 2 * 3 == 7", "true"},
+		{"!true != false", "false"},
+		{"!5", "false"},
+		{"2 <= 2 && 3 >= 4", "false"},
+		{"0 <= 5 < 10 || false", "true"},
+		{"\"foo\" + \"bar\"", "foobar"},
+		{"\"a\" \"b\" + \"c\" + x", "(abc + x)"},
+		{"10 // 0", "(10 // 0)"},
+		{"-7 // 2", "-4"},
+		{"7 / 2", "(7 / 2)"},
+		{"x + 1 * 2", "(x + 2)"},
+		{"1 + x + 2", "((1 + x) + 2)"},
+		{"\"a\" + 1", "(a + 1)"},
+		{"let f = fn(a) { return a * (4 - 1); }", "let f = fn (a) return (a * 3);;"},
+		{"if (1 < 2) { 3 + 4 }", "iftrue 7"},
+		{"f(1 + 1)[0 + 1]", "(f(2)[1])"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		optimizer.Fold(program)
+
+		if program.String() != tt.expected {
+			t.Errorf("%q: want=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestFoldMarksConstantLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		constant bool
+	}{
+		{"[1, 2 * 3, \"x\", true]", true},
+		{"[[1], {\"a\": [2]}]", true},
+		{"[]", true},
+		{"{\"port\": 80 + 8000}", true},
+		{"[1, x]", false},
+		{"[1, [x]]", false},
+		{"{\"a\": fn() { 1 }}", false},
+		{"{x: 1}", false},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		optimizer.Fold(program)
+
+		var constant bool
+		switch expr := program.Statements[0].(*ast.ExpressionStatement).Expression.(type) {
+		case *ast.ArrayLiteral:
+			constant = expr.Constant
+		case *ast.HashLiteral:
+			constant = expr.Constant
+		default:
+			t.Fatalf("%q: unexpected expression %T", tt.input, expr)
+		}
+
+		if constant != tt.constant {
+			t.Errorf("%q: wrong Constant. want=%t, got=%t", tt.input, tt.constant, constant)
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: parser errors: %v", input, p.Errors())
+	}
+
+	return program
+}
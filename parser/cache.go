@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/optimizer"
+	"sync"
+)
+
+// Cache memoizes parse results keyed by a hash of the source text, so hosts
+// that evaluate the same scripts over and over only lex and parse them once.
+// Programs that parse cleanly are also constant folded before being cached.
+// Cached programs are shared between callers and must not be modified.
+// A Cache is safe for concurrent use.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[[sha256.Size]byte]*list.Element
+	order    *list.List
+	stats    CacheStats
+}
+
+// CacheStats reports how effective a Cache has been.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+type cacheEntry struct {
+	key     [sha256.Size]byte
+	program *ast.Program
+	errors  []string
+}
+
+// NewCache returns a cache holding at most capacity programs, evicting the
+// least recently used one when full. A capacity of zero or less means the
+// cache is unbounded.
+func NewCache(capacity int) *Cache {
+	return &Cache{
+		capacity: capacity,
+		entries:  make(map[[sha256.Size]byte]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Parse returns the program and parser errors for source, parsing it only if
+// an identical source has not been seen before.
+func (c *Cache) Parse(source string) (*ast.Program, []string) {
+	key := sha256.Sum256([]byte(source))
+
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		c.stats.Hits++
+		entry := element.Value.(*cacheEntry)
+		c.mu.Unlock()
+		return entry.program, entry.errors
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	p := New(lexer.New(source))
+	program := p.ParseProgram()
+	errors := p.Errors()
+	if len(errors) == 0 {
+		optimizer.Fold(program)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		// Another goroutine parsed the same source in the meantime.
+		entry := element.Value.(*cacheEntry)
+		return entry.program, entry.errors
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, program: program, errors: errors})
+
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.stats.Evictions++
+	}
+
+	return program, errors
+}
+
+// Stats returns a copy of the cache's hit and miss counters.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
@@ -0,0 +1,50 @@
+package parser
+
+import "testing"
+
+func TestCacheHitsAndMisses(t *testing.T) {
+	cache := NewCache(0)
+
+	first, errors := cache.Parse("let x = 1 + 2;")
+	if len(errors) != 0 {
+		t.Fatalf("unexpected parser errors: %v", errors)
+	}
+
+	second, _ := cache.Parse("let x = 1 + 2;")
+	if first != second {
+		t.Errorf("identical source was parsed twice")
+	}
+
+	cache.Parse("let y = 3;")
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 || stats.Entries != 2 {
+		t.Errorf("wrong stats. got=%+v", stats)
+	}
+}
+
+func TestCacheKeepsParserErrors(t *testing.T) {
+	cache := NewCache(0)
+
+	_, first := cache.Parse("let = 5;")
+	_, second := cache.Parse("let = 5;")
+
+	if len(first) == 0 || len(first) != len(second) {
+		t.Errorf("parser errors not cached. first=%v, second=%v", first, second)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	cache := NewCache(2)
+
+	cache.Parse("1")
+	cache.Parse("2")
+	cache.Parse("1")
+	cache.Parse("3")
+	cache.Parse("1")
+
+	stats := cache.Stats()
+	if stats.Evictions != 1 || stats.Entries != 2 || stats.Hits != 2 {
+		t.Errorf("wrong stats. got=%+v", stats)
+	}
+}
@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"monkey/ast"
+	"monkey/diagnostic"
+)
+
+// linter finds legal but suspicious code in a parsed program. scopes holds
+// the names bound by the program and each enclosing function literal.
+type linter struct {
+	scopes      []map[string]bool
+	diagnostics []diagnostic.Diagnostic
+	catalog     diagnostic.Catalog
+}
+
+func lint(program *ast.Program, catalog diagnostic.Catalog) []diagnostic.Diagnostic {
+	l := &linter{catalog: catalog}
+	l.scopes = append(l.scopes, scopeOf(bindings(program, nil)))
+	l.walk(program)
+	return l.diagnostics
+}
+
+// binding is a name bound by a parameter or a let statement.
+type binding struct {
+	name  string
+	param bool
+}
+
+// bindings returns the names bound by params and by let statements in node
+// outside nested function literals, each once and in source order.
+func bindings(node ast.Node, params []*ast.Identifier) []binding {
+	seen := make(map[string]bool)
+	var bound []binding
+	for _, param := range params {
+		if !seen[param.Value] {
+			seen[param.Value] = true
+			bound = append(bound, binding{name: param.Value, param: true})
+		}
+	}
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			return false
+		case *ast.LetStatement:
+			if n.Name == nil {
+				break
+			}
+			for _, name := range append([]*ast.Identifier{n.Name}, n.Rest...) {
+				if !seen[name.Value] {
+					seen[name.Value] = true
+					bound = append(bound, binding{name: name.Value})
+				}
+			}
+		}
+		return true
+	})
+
+	return bound
+}
+
+func scopeOf(bound []binding) map[string]bool {
+	scope := make(map[string]bool, len(bound))
+	for _, b := range bound {
+		scope[b.name] = true
+	}
+	return scope
+}
+
+func (l *linter) walk(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			l.function(n)
+			return false
+		case *ast.Program:
+			l.statements(n.Statements)
+		case *ast.BlockStatement:
+			l.statements(n.Statements)
+		}
+		return true
+	})
+}
+
+func (l *linter) function(fn *ast.FunctionLiteral) {
+	bound := bindings(fn.Body, fn.Parameters)
+	for _, b := range bound {
+		if !l.bound(b.name) {
+			continue
+		}
+		kind := "let"
+		if b.param {
+			kind = "parameter"
+		}
+		l.report(diagnostic.Warning, diagnostic.Shadowing,
+			"%s %s shadows a variable of an enclosing scope", kind, b.name)
+	}
+
+	l.scopes = append(l.scopes, scopeOf(bound))
+	l.walk(fn.Body)
+	l.scopes = l.scopes[:len(l.scopes)-1]
+}
+
+func (l *linter) bound(name string) bool {
+	for _, scope := range l.scopes {
+		if scope[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// statements reports expression statements whose value is discarded even
+// though computing it can have no effect. The last statement of a block or
+// program is its value, so it is never discarded.
+func (l *linter) statements(stmts []ast.Statement) {
+	for i, stmt := range stmts {
+		if i == len(stmts)-1 {
+			break
+		}
+
+		expr, ok := stmt.(*ast.ExpressionStatement)
+		if ok && expr.Expression != nil && isPure(expr.Expression) {
+			l.report(diagnostic.Warning, diagnostic.UnusedResult,
+				"result of %s is unused", expr.Expression)
+		}
+	}
+}
+
+func (l *linter) report(severity diagnostic.Severity, code, format string, args ...any) {
+	l.diagnostics = append(l.diagnostics, diagnostic.Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Message:  diagnostic.Sprintf(l.catalog, format, args...),
+	})
+}
+
+// isPure reports whether evaluating expr cannot have side effects. Calls
+// and conditionals are conservatively treated as impure.
+func isPure(expr ast.Expression) bool {
+	switch expr := expr.(type) {
+	case *ast.Identifier, *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean, *ast.FunctionLiteral:
+		return true
+	case *ast.PrefixExpression:
+		return isPure(expr.Right)
+	case *ast.IsExpression:
+		return isPure(expr.Left)
+	case *ast.InfixExpression:
+		return isPure(expr.Left) && isPure(expr.Right)
+	case *ast.IndexExpression:
+		return isPure(expr.Left) && isPure(expr.Index)
+	case *ast.ArrayLiteral:
+		for _, el := range expr.Elements {
+			if !isPure(el) {
+				return false
+			}
+		}
+		return true
+	case *ast.HashLiteral:
+		for _, key := range expr.Keys {
+			if !isPure(key) || !isPure(expr.Pairs[key]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
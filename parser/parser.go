@@ -1,36 +1,62 @@
 package parser
 
 import (
-	"fmt"
 	"monkey/ast"
+	"monkey/diagnostic"
 	"monkey/lexer"
+	"monkey/resolver"
 	"monkey/token"
+	"slices"
 	"strconv"
 )
 
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // = or +=
+	OR          // ||
+	AND         // &&
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
+	POWER       // **
 	CALL        // myFunction(X)
 	INDEX       // myArray[X]
 )
 
+// maxNesting bounds how deeply expressions may nest, so hostile input
+// cannot exhaust the Go stack while it is parsed or evaluated.
+const maxNesting = 10000
+
 var predecences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.ASTERISK: PRODUCT,
-	token.SLASH:    PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.EQ:          EQUALS,
+	token.NOT_EQ:      EQUALS,
+	token.OR:          OR,
+	token.AND:         AND,
+	token.LT:          LESSGREATER,
+	token.GT:          LESSGREATER,
+	token.LT_EQ:       LESSGREATER,
+	token.GT_EQ:       LESSGREATER,
+	token.IN:          LESSGREATER,
+	token.IS:          EQUALS,
+	token.PLUS:        SUM,
+	token.MINUS:       SUM,
+	token.ASTERISK:    PRODUCT,
+	token.SLASH:       PRODUCT,
+	token.FLOOR_SLASH: PRODUCT,
+	token.POWER:       POWER,
+	token.LPAREN:      CALL,
+	token.LBRACKET:    INDEX,
+
+	token.ASSIGN:             ASSIGN,
+	token.PLUS_ASSIGN:        ASSIGN,
+	token.MINUS_ASSIGN:       ASSIGN,
+	token.ASTERISK_ASSIGN:    ASSIGN,
+	token.SLASH_ASSIGN:       ASSIGN,
+	token.POWER_ASSIGN:       ASSIGN,
+	token.FLOOR_SLASH_ASSIGN: ASSIGN,
 }
 
 type (
@@ -39,14 +65,27 @@ type (
 )
 
 type Parser struct {
-	l      *lexer.Lexer
-	errors []string
+	l        *lexer.Lexer
+	errors   []string
+	warnings []diagnostic.Diagnostic
+	catalog  diagnostic.Catalog
 
 	curToken  token.Token
 	peekToken token.Token
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	// loops holds the labels of the loops enclosing the current position
+	// within the innermost function, "" for unlabelled ones. label is the
+	// label waiting for the loop that follows it.
+	loops []string
+	label string
+
+	nesting int
+	// tooDeep is the number of errors recorded up to and including the
+	// one reporting that maxNesting was exceeded, or zero.
+	tooDeep int
 }
 
 func New(l *lexer.Lexer) *Parser {
@@ -56,38 +95,68 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.UNLESS, p.parseIfExpression)
+	p.registerPrefix(token.WHILE, p.parseWhileExpression)
+	p.registerPrefix(token.DO, p.parseDoWhileExpression)
+	p.registerPrefix(token.FOR, p.parseForExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunction)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.IMPORT, p.parseImportExpression)
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
+	p.registerInfix(token.FLOOR_SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
-	p.registerInfix(token.LT, p.parseInfixExpression)
-	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT, p.parseComparisonExpression)
+	p.registerInfix(token.GT, p.parseComparisonExpression)
+	p.registerInfix(token.LT_EQ, p.parseComparisonExpression)
+	p.registerInfix(token.GT_EQ, p.parseComparisonExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.IN, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseRightAssociative)
+	p.registerInfix(token.IS, p.parseIsExpression)
+	for _, assign := range []token.TokenType{
+		token.ASSIGN, token.PLUS_ASSIGN, token.MINUS_ASSIGN,
+		token.ASTERISK_ASSIGN, token.SLASH_ASSIGN, token.POWER_ASSIGN,
+		token.FLOOR_SLASH_ASSIGN,
+	} {
+		p.registerInfix(assign, p.parseAssignExpression)
+	}
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseArrayExpression)
 	return p
 }
 
+// SetCatalog words the parser's errors and warnings according to c. Call it
+// before ParseProgram.
+func (p *Parser) SetCatalog(c diagnostic.Catalog) {
+	p.catalog = c
+}
+
+// errorf records an error worded by the parser's catalog.
+func (p *Parser) errorf(format string, args ...any) {
+	p.errors = append(p.errors, diagnostic.Sprintf(p.catalog, format, args...))
+}
+
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.errorf("no prefix parse function for %s found", t)
 }
 
 func (p *Parser) noInfixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no infix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.errorf("no infix parse function for %s found", t)
 }
 
 func (p *Parser) parseGroupedExpression() ast.Expression {
@@ -117,6 +186,84 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+// parseRightAssociative parses a binary operator that groups to the right,
+// so "a ** b ** c" is "a ** (b ** c)".
+func (p *Parser) parseRightAssociative(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+
+	precedence := p.curPrecedence()
+
+	p.nextToken()
+
+	expression.Right = p.parseExpression(precedence - 1)
+
+	return expression
+}
+
+func (p *Parser) parseIsExpression(left ast.Expression) ast.Expression {
+	expression := &ast.IsExpression{Token: p.curToken, Left: left}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	expression.Type = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return expression
+}
+
+// parseAssignExpression parses an assignment to the variable on its left.
+// Assignments group to the right, so "a = b = 1" assigns 1 to both.
+func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+	name, ok := left.(*ast.Identifier)
+	if !ok {
+		p.errorf("cannot assign to %s", left)
+		return nil
+	}
+
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Name:     name,
+		Operator: p.curToken.Literal,
+	}
+
+	p.nextToken()
+
+	expression.Value = p.parseExpression(ASSIGN - 1)
+
+	return expression
+}
+
+// parseComparisonExpression parses a comparison and any comparisons chained
+// to it, desugaring "a < b <= c" into "(a < b) && (b <= c)". An operand
+// shared by two comparisons is evaluated for each of them.
+func (p *Parser) parseComparisonExpression(left ast.Expression) ast.Expression {
+	expression := p.parseInfixExpression(left).(*ast.InfixExpression)
+
+	var chain ast.Expression = expression
+	for isComparison(p.peekToken.Type) && expression.Right != nil {
+		p.nextToken()
+		next := p.parseInfixExpression(expression.Right).(*ast.InfixExpression)
+		chain = &ast.InfixExpression{
+			Token:    token.Token{Type: token.AND, Literal: token.AND},
+			Operator: token.AND,
+			Left:     chain,
+			Right:    next,
+		}
+		expression = next
+	}
+
+	return chain
+}
+
+func isComparison(t token.TokenType) bool {
+	return t == token.LT || t == token.GT || t == token.LT_EQ || t == token.GT_EQ
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -133,8 +280,7 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(p.curToken.Literal, 10, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorf("could not parse %q as integer", p.curToken.Literal)
 		return nil
 	}
 
@@ -144,11 +290,42 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	}
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.errorf("could not parse %q as float", p.curToken.Literal)
+		return nil
+	}
+
+	return &ast.FloatLiteral{
+		Token: p.curToken,
+		Value: value,
+	}
+}
+
+// parseStringLiteral parses a string literal, joining any literals that
+// directly follow it into one, so long text can be split up without
+// paying for concatenation at run time.
 func (p *Parser) parseStringLiteral() ast.Expression {
-	return &ast.StringLiteral{
+	lit := &ast.StringLiteral{
 		Token: p.curToken,
 		Value: p.curToken.Literal,
 	}
+	for p.peekTokenIs(token.STRING) {
+		p.nextToken()
+		lit.Value += p.curToken.Literal
+	}
+	lit.Token.Literal = lit.Value
+	return lit
+}
+
+func (p *Parser) parseImportExpression() ast.Expression {
+	expr := &ast.ImportExpression{Token: p.curToken}
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	expr.Path = p.curToken.Literal
+	return expr
 }
 
 func (p *Parser) parseBoolean() ast.Expression {
@@ -174,9 +351,14 @@ func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+// Warnings returns diagnostics about legal but suspicious code in the
+// program. They are only computed for programs that parsed without errors.
+func (p *Parser) Warnings() []diagnostic.Diagnostic {
+	return p.warnings
+}
+
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.errorf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
 }
 
 func (p *Parser) peekPrecedence() int {
@@ -212,6 +394,16 @@ func (p *Parser) ParseProgram() *ast.Program {
 		p.nextToken()
 	}
 
+	if p.tooDeep > 0 {
+		// Everything after giving up is noise from unclosed constructs.
+		p.errors = p.errors[:p.tooDeep]
+	}
+
+	if len(p.errors) == 0 {
+		resolver.Resolve(program)
+		p.warnings = lint(program, p.catalog)
+	}
+
 	return program
 }
 
@@ -221,6 +413,13 @@ func (p *Parser) ParseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.BREAK, token.CONTINUE:
+		return p.parseBranchStatement()
+	case token.IDENT:
+		if p.peekTokenIs(token.COLON) {
+			return p.parseLabeledStatement()
+		}
+		return p.parseExpressionStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -253,6 +452,14 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Rest = append(stmt.Rest, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
@@ -260,6 +467,9 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(LOWEST)
+	if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fn.Name = stmt.Name.Value
+	}
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -275,6 +485,40 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.COMMA) {
+		tuple := &ast.TupleLiteral{Token: p.curToken, Elements: []ast.Expression{stmt.ReturnValue}}
+		for p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+			p.nextToken()
+			tuple.Elements = append(tuple.Elements, p.parseExpression(LOWEST))
+		}
+		stmt.ReturnValue = tuple
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseBranchStatement parses break or continue, checking that it is inside
+// a loop and that its label names one of the loops it is inside.
+func (p *Parser) parseBranchStatement() *ast.BranchStatement {
+	stmt := &ast.BranchStatement{Token: p.curToken}
+
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		stmt.Label = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	switch {
+	case len(p.loops) == 0:
+		p.errorf("%s outside a loop", stmt.TokenLiteral())
+	case stmt.Label != nil && !slices.Contains(p.loops, stmt.Label.Value):
+		p.errorf("%s to unknown label %s", stmt.TokenLiteral(), stmt.Label.Value)
+	}
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -282,6 +526,42 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
+// parseLabeledStatement parses "label: loop".
+func (p *Parser) parseLabeledStatement() ast.Statement {
+	label := p.curToken.Literal
+	p.nextToken()
+
+	if !p.peekTokenIs(token.FOR) && !p.peekTokenIs(token.WHILE) && !p.peekTokenIs(token.DO) {
+		p.errorf("label %s must be followed by a loop, got %s", label, p.peekToken.Type)
+		return nil
+	}
+	if slices.Contains(p.loops, label) {
+		p.errorf("label %s is already in use by an enclosing loop", label)
+	}
+
+	p.nextToken()
+	p.label = label
+
+	return p.parseExpressionStatement()
+}
+
+// parseLoopBody parses the body of a loop labelled label, where break and
+// continue statements may refer to it.
+func (p *Parser) parseLoopBody(label string) *ast.BlockStatement {
+	p.loops = append(p.loops, label)
+	defer func() { p.loops = p.loops[:len(p.loops)-1] }()
+
+	return p.parseBlockStatement()
+}
+
+// takeLabel returns the label preceding the loop being parsed and clears it
+// so nested loops do not see it.
+func (p *Parser) takeLabel() string {
+	label := p.label
+	p.label = ""
+	return label
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{
 		Token:      p.curToken,
@@ -296,6 +576,20 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
+	if p.nesting >= maxNesting {
+		if p.tooDeep == 0 {
+			p.errorf("expression nested more than %d levels deep", maxNesting)
+			p.tooDeep = len(p.errors)
+		}
+		for !p.curTokenIs(token.EOF) {
+			p.nextToken()
+		}
+		return nil
+	}
+
+	p.nesting++
+	defer func() { p.nesting-- }()
+
 	prefix, ok := p.prefixParseFns[p.curToken.Type]
 	if !ok {
 		p.noPrefixParseFnError(p.curToken.Type)
@@ -332,6 +626,15 @@ func (p *Parser) parseIfExpression() ast.Expression {
 		return nil
 	}
 
+	// unless (x) is if (!(x)).
+	if expresion.Token.Type == token.UNLESS {
+		expresion.Condition = &ast.PrefixExpression{
+			Token:    token.Token{Type: token.BANG, Literal: "!"},
+			Operator: "!",
+			Right:    expresion.Condition,
+		}
+	}
+
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
@@ -353,6 +656,96 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expresion
 }
 
+func (p *Parser) parseWhileExpression() ast.Expression {
+	expression := &ast.WhileExpression{Token: p.curToken, Label: p.takeLabel()}
+
+	expression.Condition = p.parseLoopCondition()
+	if expression.Condition == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Body = p.parseLoopBody(expression.Label)
+
+	return expression
+}
+
+func (p *Parser) parseDoWhileExpression() ast.Expression {
+	expression := &ast.WhileExpression{Token: p.curToken, DoWhile: true, Label: p.takeLabel()}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Body = p.parseLoopBody(expression.Label)
+
+	if !p.expectPeek(token.WHILE) {
+		return nil
+	}
+
+	expression.Condition = p.parseLoopCondition()
+	if expression.Condition == nil {
+		return nil
+	}
+
+	return expression
+}
+
+func (p *Parser) parseForExpression() ast.Expression {
+	expression := &ast.ForExpression{Token: p.curToken, Label: p.takeLabel()}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	expression.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+
+	expression.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Body = p.parseLoopBody(expression.Label)
+
+	return expression
+}
+
+// parseLoopCondition parses the parenthesized condition following the
+// current while token.
+func (p *Parser) parseLoopCondition() ast.Expression {
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+
+	condition := p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return condition
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
@@ -383,7 +776,11 @@ func (p *Parser) parseFunction() ast.Expression {
 		return nil
 	}
 
+	// break and continue cannot leave a function.
+	loops := p.loops
+	p.loops = nil
 	function.Body = p.parseBlockStatement()
+	p.loops = loops
 
 	return function
 }
@@ -497,6 +894,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !(p.peekTokenIs(token.RBRACE) || p.expectPeek(token.COMMA)) {
 			return nil
@@ -3,7 +3,9 @@ package parser
 import (
 	"fmt"
 	"monkey/ast"
+	"monkey/diagnostic"
 	"monkey/lexer"
+	"strings"
 	"testing"
 )
 
@@ -41,6 +43,34 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestMultipleValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let q, r = divmod(7, 2);", "let q, r = divmod(7, 2);"},
+		{"let a, b, c = xs", "let a, b, c = xs;"},
+		{"return a, b + 1;", "return a, (b + 1);"},
+		{"fn(a, b) { return b, a }", "fn (a, b) return b, a;"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("%q: want=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New("let a, = 1"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != "expected next token to be IDENT, got = instead" {
+		t.Errorf("wrong errors for a missing name. got=%q", p.Errors())
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input         string
@@ -139,6 +169,24 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	p := New(lexer.New("2.5;"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 2.5 {
+		t.Errorf("literal.Value not %g. got=%g", 2.5, literal.Value)
+	}
+	if literal.String() != "2.5" {
+		t.Errorf("literal.String not %s. got=%s", "2.5", literal.String())
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -269,6 +317,90 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"a + b / c",
 			"(a + (b / c))",
 		},
+		{
+			"a + b in c == d",
+			"(((a + b) in c) == d)",
+		},
+		{
+			"!a in b",
+			"((!a) in b)",
+		},
+		{
+			"a <= b == c >= d",
+			"((a <= b) == (c >= d))",
+		},
+		{
+			"a || b && c == d",
+			"(a || (b && (c == d)))",
+		},
+		{
+			"a && b || c",
+			"((a && b) || c)",
+		},
+		{
+			"0 <= x < 10",
+			"((0 <= x) && (x < 10))",
+		},
+		{
+			"a < b < c < d",
+			"(((a < b) && (b < c)) && (c < d))",
+		},
+		{
+			"a < b + 1 >= c * 2",
+			"((a < (b + 1)) && ((b + 1) >= (c * 2)))",
+		},
+		{
+			"(a < b) < c",
+			"((a < b) < c)",
+		},
+		{
+			"a < b == c < d",
+			"((a < b) == (c < d))",
+		},
+		{
+			"a < b && b < c < d",
+			"((a < b) && ((b < c) && (c < d)))",
+		},
+		{
+			"a // b * c / d",
+			"(((a // b) * c) / d)",
+		},
+		{
+			"a + b // c",
+			"(a + (b // c))",
+		},
+		{
+			"x is INTEGER && y is STRING",
+			"((x is INTEGER) && (y is STRING))",
+		},
+		{
+			"a + 1 is INTEGER == true",
+			"(((a + 1) is INTEGER) == true)",
+		},
+		{
+			"-2 ** 2",
+			"(-(2 ** 2))",
+		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"a * b ** -c",
+			"(a * (b ** (-c)))",
+		},
+		{
+			"a[0] ** f(x)",
+			"((a[0]) ** f(x))",
+		},
+		{
+			"x = y = a + b",
+			"(x = (y = (a + b)))",
+		},
+		{
+			"x **= 2 ** n",
+			"(x **= (2 ** n))",
+		},
 		{
 			"a + b * c + d / e - f",
 			"(((a + (b * c)) + (d / e)) - f)",
@@ -505,6 +637,74 @@ func TestIfElseExpression(t *testing.T) {
 	}
 }
 
+func TestUnlessAndLoopParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"unless (x < y) { x }", "if(!(x < y)) x"},
+		{"unless (x) { x } else { y }", "if(!x) xelse y"},
+		{"while (i < 10) { i += 1 }", "while(i < 10) (i += 1)"},
+		{"do { i += 1 } while (i < 10)", "do (i += 1) while(i < 10)"},
+		{"for (x in xs) { puts(x) }", "for(x in xs) puts(x)"},
+		{"outer: for (x in xs) { for (y in ys) { break outer; } }", "outer: for(x in xs) for(y in ys) break outer;"},
+		{"loop: while (true) { continue loop }", "loop: whiletrue continue loop;"},
+		{"l: do { break } while (true)", "l: do break; whiletrue"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("%q: want=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestLoopParsingErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		error string
+	}{
+		{"while i < 10 { i }", "expected next token to be (, got IDENT instead"},
+		{"do { i } (i < 10)", "expected next token to be WHILE, got ( instead"},
+		{"do i while (true)", "expected next token to be {, got IDENT instead"},
+		{"for x in xs { x }", "expected next token to be (, got IDENT instead"},
+		{"for (x, xs) { x }", "expected next token to be IN, got , instead"},
+		{"break", "break outside a loop"},
+		{"while (true) { fn() { continue } }", "continue outside a loop"},
+		{"outer: for (x in xs) { break inner }", "break to unknown label inner"},
+		{"a: for (x in xs) { } ; for (y in ys) { continue a }", "continue to unknown label a"},
+		{"a: let x = 1", "label a must be followed by a loop, got LET"},
+		{"a: while (true) { a: while (true) { } }", "label a is already in use by an enclosing loop"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.error {
+			t.Errorf("%q: wrong errors. want %q first, got=%q", tt.input, tt.error, errors)
+		}
+	}
+}
+
+func TestImportParsing(t *testing.T) {
+	p := New(lexer.New(`let m = import "go:mylib"; import m`))
+	program := p.ParseProgram()
+
+	if program.Statements[0].String() != `let m = import "go:mylib";` {
+		t.Errorf("wrong import. got=%q", program.Statements[0].String())
+	}
+	errors := p.Errors()
+	if len(errors) == 0 || errors[0] != "expected next token to be STRING, got IDENT instead" {
+		t.Errorf("wrong errors. got=%q", errors)
+	}
+}
+
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := `fn(x, y) { x + y; }`
 
@@ -839,6 +1039,20 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestAdjacentStringLiterals(t *testing.T) {
+	p := New(lexer.New(`let s = "hello, " "world" "!"; puts("a" "b", "c")`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if got := program.String(); got != "let s = hello, world!;puts(ab, c)" {
+		t.Errorf("wrong program. got=%q", got)
+	}
+	lit := program.Statements[0].(*ast.LetStatement).Value.(*ast.StringLiteral)
+	if lit.Value != "hello, world!" {
+		t.Errorf("wrong value. got=%q", lit.Value)
+	}
+}
+
 func TestParsingArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 	l := lexer.New(input)
@@ -1052,3 +1266,146 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		testFunc(value)
 	}
 }
+
+func TestAssignToNonVariable(t *testing.T) {
+	tests := []struct {
+		input string
+		error string
+	}{
+		{"1 = 2", "cannot assign to 1"},
+		{"a[0] += 1", "cannot assign to (a[0])"},
+		{"f() = 1", "cannot assign to f()"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.error {
+			t.Errorf("%q: wrong errors. want %q first, got=%q", tt.input, tt.error, errors)
+		}
+	}
+}
+
+func TestNestingLimit(t *testing.T) {
+	tests := []struct {
+		input  string
+		errors []string
+	}{
+		{strings.Repeat("-", maxNesting-1) + "1", nil},
+		{strings.Repeat("(", maxNesting-1) + "1" + strings.Repeat(")", maxNesting-1), nil},
+		{strings.Repeat("-", maxNesting) + "1", []string{"expression nested more than 10000 levels deep"}},
+		{strings.Repeat("[", 2*maxNesting), []string{"expression nested more than 10000 levels deep"}},
+		{"let = 1; " + strings.Repeat("fn() {", maxNesting) + "1", []string{
+			"expected next token to be IDENT, got = instead",
+			"no prefix parse function for = found",
+			"expression nested more than 10000 levels deep",
+		}},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		if len(p.Errors()) != len(tt.errors) {
+			t.Errorf("wrong number of errors. want=%d, got=%d", len(tt.errors), len(p.Errors()))
+			continue
+		}
+		for i, msg := range tt.errors {
+			if p.Errors()[i] != msg {
+				t.Errorf("wrong error. want=%q, got=%q", msg, p.Errors()[i])
+			}
+		}
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		input    string
+		warnings []string
+	}{
+		{"let x = 1; x", nil},
+		{"let x = 1; x; puts(x); x", []string{"warning: result of x is unused [unused-result]"}},
+		{"1 + 2; [1, {2: -3}]; puts(1); 4", []string{
+			"warning: result of (1 + 2) is unused [unused-result]",
+			"warning: result of [1, {2 : (-3)}] is unused [unused-result]",
+		}},
+		{"f(1); if (true) { 1 }; 2", nil},
+		{"let x = 1; let f = fn(x) { let x = 2; x }", []string{
+			"warning: parameter x shadows a variable of an enclosing scope [shadowing]",
+		}},
+		{"let f = fn(a) { fn() { let a = 1; let b = 2; b } }", []string{
+			"warning: let a shadows a variable of an enclosing scope [shadowing]",
+		}},
+		{"let f = fn(a) { let a = a + 1; a }; let g = fn(a) { a }", nil},
+		{"let f = fn() { x; 1 }", []string{"warning: result of x is unused [unused-result]"}},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		checkParserErrors(t, p)
+
+		var got []string
+		for _, d := range p.Warnings() {
+			got = append(got, d.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.warnings, "\n") {
+			t.Errorf("wrong warnings for %q.\nwant=%q\ngot=%q", tt.input, tt.warnings, got)
+		}
+	}
+}
+
+func TestFunctionLiteralWithName(t *testing.T) {
+	p := New(lexer.New(`let myFunction = fn() { }; let x = fn() { }(); fn() { }`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{"myFunction", "", ""}
+	for i, stmt := range program.Statements {
+		var function *ast.FunctionLiteral
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			if call, ok := stmt.Value.(*ast.CallExpression); ok {
+				function = call.Function.(*ast.FunctionLiteral)
+			} else {
+				function = stmt.Value.(*ast.FunctionLiteral)
+			}
+		case *ast.ExpressionStatement:
+			function = stmt.Expression.(*ast.FunctionLiteral)
+		}
+
+		if function.Name != expected[i] {
+			t.Errorf("statement %d: function literal name wrong. want=%q, got=%q", i, expected[i], function.Name)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	catalog := diagnostic.Messages{
+		"expected next token to be %s, got %s instead": "erwartet %[1]s, nicht %[2]s",
+		"result of %s is unused":                       "Ergebnis von %s wird nicht verwendet",
+	}
+
+	p := New(lexer.New("let = 1"))
+	p.SetCatalog(catalog)
+	p.ParseProgram()
+	if errors := p.Errors(); len(errors) == 0 || errors[0] != "erwartet IDENT, nicht =" {
+		t.Errorf("error not reworded: %q", errors)
+	}
+
+	p = New(lexer.New("x; break"))
+	p.SetCatalog(catalog)
+	p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 1 || errors[0] != "break outside a loop" {
+		t.Errorf("error without a replacement changed: %q", errors)
+	}
+
+	p = New(lexer.New("x; 1"))
+	p.SetCatalog(catalog)
+	p.ParseProgram()
+	if warnings := p.Warnings(); len(warnings) != 1 || warnings[0].Message != "Ergebnis von x wird nicht verwendet" {
+		t.Errorf("warning not reworded: %v", warnings)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"monkey/evaluator"
+	"monkey/object"
+	"monkey/template"
+	"os"
+)
+
+func runRender(args []string) int {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	dataFile := flags.String("data", "", "JSON file whose top-level keys are bound as variables")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey render [-data file.json] template")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	env, err := loadTemplateData(*dataFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey render: %s\n", err)
+		return 1
+	}
+
+	path := flags.Arg(0)
+	text, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey render: %s\n", err)
+		return 1
+	}
+
+	tmpl, err := template.Parse(path, string(text))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey render: %s\n", err)
+		return 1
+	}
+
+	if err := tmpl.Execute(os.Stdout, evaluator.New(evaluator.WithStdout(os.Stderr)), env); err != nil {
+		fmt.Fprintf(os.Stderr, "monkey render: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func loadTemplateData(path string) (*object.Environment, error) {
+	env := object.NewEnvironment()
+	if path == "" {
+		return env, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for name, value := range values {
+		obj, err := object.FromGo(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, name, err)
+		}
+		env.Set(name, obj)
+	}
+
+	return env, nil
+}
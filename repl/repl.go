@@ -5,13 +5,21 @@ import (
 	"fmt"
 	"io"
 	"monkey/evaluator"
-	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
+	"os"
+	"strings"
 )
 
 const PROMPT = ">> "
 
+// cacheSize bounds how many distinct input lines keep their parsed program.
+const cacheSize = 256
+
+// inspectFormat is how results and puts output are laid out: nested values
+// too long for one line are indented, and very deep ones abbreviated.
+var inspectFormat = object.FormatOptions{Indent: "  ", Width: 72, MaxDepth: 8}
+
 const MONKEY_FACE = `            __,__
    .--.  .-"     "-.  .--.
   / .. \/  .-. .-.  \/ .. \
@@ -26,30 +34,39 @@ const MONKEY_FACE = `            __,__
 `
 
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+	// The reader is shared with the interpreter, so lines a script reads
+	// with prompt are not also taken as input to the REPL.
+	reader := bufio.NewReader(in)
 	env := object.NewEnvironment()
+	cache := parser.NewCache(cacheSize)
+	interpreter := evaluator.New(
+		evaluator.WithStreams(evaluator.Streams{Stdin: reader, Stdout: out}),
+		evaluator.WithFormat(inspectFormat),
+		evaluator.WithSignalHandling(),
+	)
+	defer interpreter.Close()
 
 	for {
 		fmt.Fprint(out, PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		input, err := reader.ReadString('\n')
+		if err != nil && input == "" {
 			return
 		}
+		input = strings.TrimRight(input, "\r\n")
+		if strings.HasPrefix(input, ":") {
+			env = runCommand(out, input, env, cache, interpreter)
+			continue
+		}
 
-		input := scanner.Text()
-		l := lexer.New(input)
-		p := parser.New(l)
-
-		program := p.ParseProgram()
-
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+		program, errors := cache.Parse(input)
+		if len(errors) != 0 {
+			printParserErrors(out, errors)
 			continue
 		}
 
-		evaluated := evaluator.Eval(program, env)
+		evaluated := interpreter.Eval(program, env)
 		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
+			io.WriteString(out, object.Format(evaluated, inspectFormat))
 			io.WriteString(out, "\n")
 		}
 	}
@@ -65,3 +82,88 @@ func printParserErrors(out io.Writer, errors []string) {
 		io.WriteString(out, "\t"+msg+"\n")
 	}
 }
+
+func runCommand(out io.Writer, input string, env *object.Environment, cache *parser.Cache, interpreter *evaluator.Interpreter) *object.Environment {
+	fields := strings.Fields(input)
+
+	switch fields[0] {
+	case ":save":
+		if len(fields) != 2 {
+			io.WriteString(out, "usage: :save <file>\n")
+			return env
+		}
+		skipped, err := saveEnvironment(fields[1], env)
+		if err != nil {
+			fmt.Fprintf(out, "could not save session: %s\n", err)
+			return env
+		}
+		if len(skipped) > 0 {
+			fmt.Fprintf(out, "warning: left out bindings that cannot be saved: %s\n", strings.Join(skipped, ", "))
+		}
+	case ":load":
+		if len(fields) != 2 {
+			io.WriteString(out, "usage: :load <file>\n")
+			return env
+		}
+		restored, err := loadEnvironment(fields[1])
+		if err != nil {
+			fmt.Fprintf(out, "could not load session: %s\n", err)
+			return env
+		}
+		return restored
+	case ":cache":
+		stats := cache.Stats()
+		fmt.Fprintf(out, "hits=%d misses=%d evictions=%d entries=%d\n",
+			stats.Hits, stats.Misses, stats.Evictions, stats.Entries)
+	case ":doc":
+		if len(fields) != 2 {
+			io.WriteString(out, "usage: :doc <name>\n")
+			return env
+		}
+		doc, ok := interpreter.Doc(fields[1])
+		if !ok {
+			fmt.Fprintf(out, "no documentation for %s\n", fields[1])
+			return env
+		}
+		io.WriteString(out, doc.String())
+	default:
+		fmt.Fprintf(out, "unknown command: %s\n", fields[0])
+	}
+
+	return env
+}
+
+// saveEnvironment writes the serializable bindings of env to path and
+// returns the names of those it had to leave out.
+func saveEnvironment(path string, env *object.Environment) ([]string, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := env.Snapshot()
+	if err := snapshot.Encode(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	return snapshot.Skipped, nil
+}
+
+func loadEnvironment(path string) (*object.Environment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	snapshot, err := object.DecodeSnapshot(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot.Restore()
+}
@@ -0,0 +1,110 @@
+// Package resolver assigns environment slots to the variables of function
+// literals, so the evaluator can reach locals by index instead of looking
+// them up by name.
+//
+// Every function literal gets one slot per parameter and per name it binds
+// with let or a for loop anywhere in its body outside nested functions. Identifiers that
+// refer to such a variable are annotated with how many function scopes up
+// it lives and at which slot. Variables that nested functions refer to are
+// marked as captured, which lets the evaluator release the rest when a call
+// returns. Top-level bindings stay unresolved: they live
+// in the map-backed global environment that REPL inputs and hosts share.
+//
+// Undeclared additionally finds identifiers that no scope can ever bind, for
+// hosts that want to reject typos before running a program.
+package resolver
+
+import "monkey/ast"
+
+type scope struct {
+	slots    map[string]int
+	names    []string
+	captured []bool
+}
+
+func (s *scope) declare(name string) {
+	if _, ok := s.slots[name]; ok {
+		return
+	}
+
+	s.slots[name] = len(s.names)
+	s.names = append(s.names, name)
+}
+
+type resolver struct {
+	scopes []*scope
+}
+
+// Resolve annotates the function literals and identifiers in node. It is
+// idempotent, and the parser runs it on every program it produces.
+func Resolve(node ast.Node) {
+	r := &resolver{}
+	r.resolve(node)
+}
+
+func (r *resolver) resolve(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			r.function(n)
+			return false
+		case *ast.Identifier:
+			r.identifier(n)
+		}
+		return true
+	})
+}
+
+func (r *resolver) function(fn *ast.FunctionLiteral) {
+	s := &scope{slots: make(map[string]int), names: []string{}}
+
+	for _, param := range fn.Parameters {
+		s.declare(param.Value)
+	}
+
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			return false
+		case *ast.LetStatement:
+			if n.Name != nil {
+				s.declare(n.Name.Value)
+				for _, name := range n.Rest {
+					s.declare(name.Value)
+				}
+			}
+		case *ast.ForExpression:
+			s.declare(n.Variable.Value)
+		}
+		return true
+	})
+
+	fn.Locals = s.names
+	s.captured = make([]bool, len(s.names))
+
+	r.scopes = append(r.scopes, s)
+	for _, param := range fn.Parameters {
+		r.identifier(param)
+	}
+	r.resolve(fn.Body)
+	r.scopes = r.scopes[:len(r.scopes)-1]
+
+	fn.Captured = s.captured
+}
+
+func (r *resolver) identifier(ident *ast.Identifier) {
+	for depth := 0; depth < len(r.scopes); depth++ {
+		s := r.scopes[len(r.scopes)-1-depth]
+		if index, ok := s.slots[ident.Value]; ok {
+			ident.Resolved = true
+			ident.Depth = depth
+			ident.Index = index
+			if depth > 0 {
+				s.captured[index] = true
+			}
+			return
+		}
+	}
+
+	ident.Resolved = false
+}
@@ -0,0 +1,165 @@
+package resolver_test
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"monkey/resolver"
+	"reflect"
+	"testing"
+)
+
+type binding struct {
+	name     string
+	resolved bool
+	depth    int
+	index    int
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		input    string
+		locals   [][]string
+		bindings []binding
+	}{
+		{
+			"let g = 1; g;",
+			nil,
+			[]binding{{"g", false, 0, 0}, {"g", false, 0, 0}},
+		},
+		{
+			"fn(a, b) { let c = a; if (b) { let d = c; } g }",
+			[][]string{{"a", "b", "c", "d"}},
+			[]binding{
+				{"a", true, 0, 0}, {"b", true, 0, 1},
+				{"c", true, 0, 2}, {"a", true, 0, 0},
+				{"b", true, 0, 1}, {"d", true, 0, 3}, {"c", true, 0, 2},
+				{"g", false, 0, 0},
+			},
+		},
+		{
+			"fn(x) { let inner = fn(y) { x + y + inner }; inner }",
+			[][]string{{"x", "inner"}, {"y"}},
+			[]binding{
+				{"x", true, 0, 0}, {"inner", true, 0, 1},
+				{"y", true, 0, 0}, {"x", true, 1, 0}, {"y", true, 0, 0}, {"inner", true, 1, 1},
+				{"inner", true, 0, 1},
+			},
+		},
+		{
+			"fn() { let q, r = 1; q + r }",
+			[][]string{{"q", "r"}},
+			[]binding{{"q", true, 0, 0}, {"r", true, 0, 1}, {"q", true, 0, 0}, {"r", true, 0, 1}},
+		},
+		{
+			"fn(xs) { for (x in xs) { x } }",
+			[][]string{{"xs", "x"}},
+			[]binding{{"xs", true, 0, 0}, {"x", true, 0, 1}, {"xs", true, 0, 0}, {"x", true, 0, 1}},
+		},
+		{
+			"fn(x, x) { x }",
+			[][]string{{"x"}},
+			[]binding{{"x", true, 0, 0}, {"x", true, 0, 0}, {"x", true, 0, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+
+		var locals [][]string
+		var bindings []binding
+		ast.Inspect(program, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FunctionLiteral:
+				locals = append(locals, n.Locals)
+			case *ast.Identifier:
+				bindings = append(bindings, binding{n.Value, n.Resolved, n.Depth, n.Index})
+			}
+			return true
+		})
+
+		if !reflect.DeepEqual(locals, tt.locals) {
+			t.Errorf("%q: wrong locals. want=%v, got=%v", tt.input, tt.locals, locals)
+		}
+
+		if !reflect.DeepEqual(bindings, tt.bindings) {
+			t.Errorf("%q: wrong bindings.\nwant=%v\ngot= %v", tt.input, tt.bindings, bindings)
+		}
+	}
+}
+
+func TestResolveCaptured(t *testing.T) {
+	tests := []struct {
+		input    string
+		captured [][]bool
+	}{
+		{"fn(a, b) { let c = 1; a + b + c }", [][]bool{{false, false, false}}},
+		{"fn(a, b) { let c = 1; fn() { b } }", [][]bool{{false, true, false}, {}}},
+		{"fn(a) { fn(b) { fn() { a + b } } }", [][]bool{{true}, {true}, {}}},
+		{"fn() { let f = fn() { f() }; f }", [][]bool{{true}, {}}},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+
+		var captured [][]bool
+		ast.Inspect(program, func(n ast.Node) bool {
+			if fn, ok := n.(*ast.FunctionLiteral); ok {
+				captured = append(captured, fn.Captured)
+			}
+			return true
+		})
+
+		if !reflect.DeepEqual(captured, tt.captured) {
+			t.Errorf("%q: wrong captured. want=%v, got=%v", tt.input, tt.captured, captured)
+		}
+	}
+}
+
+func TestResolveIsIdempotent(t *testing.T) {
+	program := parser.New(lexer.New("fn(a) { fn(b) { a + b } }")).ParseProgram()
+	before := program.String()
+
+	resolver.Resolve(program)
+	resolver.Resolve(program)
+
+	fn := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if !reflect.DeepEqual(fn.Locals, []string{"a"}) || program.String() != before {
+		t.Errorf("resolving twice changed the program. locals=%v", fn.Locals)
+	}
+}
+
+func TestUndeclared(t *testing.T) {
+	tests := []struct {
+		input      string
+		undeclared []string
+	}{
+		{"let g = 1; g + len([])", nil},
+		{"let f = fn(a) { if (a) { lenght(a) } else { b + a } }; f(1)", []string{"lenght", "b"}},
+		{"let f = fn() { later + 1 }; let later = 2; f()", nil},
+		{"for (x in [1]) { x }; x; y = 1; y", []string{"y"}},
+		{"let f = fn() { let a, b = g(); fn() { a + b + c } }", []string{"g", "c"}},
+		{"outer: while (true) { break outer }; 1 is INTEGER", nil},
+		{"host + host", nil},
+	}
+
+	known := func(name string) bool { return name == "len" || name == "host" }
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+
+		var names []string
+		for _, ident := range resolver.Undeclared(program, known) {
+			names = append(names, ident.Value)
+		}
+		if !reflect.DeepEqual(names, tt.undeclared) {
+			t.Errorf("%q: wrong undeclared identifiers. want=%q, got=%q", tt.input, tt.undeclared, names)
+		}
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	program := parser.New(lexer.New("let a = 1; let f = fn() { let b = 2 }; if (a) { let c, a = 3, 4 }; for (d in []) {}")).ParseProgram()
+	if globals := resolver.Globals(program); !reflect.DeepEqual(globals, []string{"a", "f", "c", "d"}) {
+		t.Errorf("wrong globals: %q", globals)
+	}
+}
@@ -0,0 +1,65 @@
+package resolver
+
+import "monkey/ast"
+
+// Globals returns the names program binds at the top level, with let or a
+// for loop outside any function literal, each once and in source order.
+func Globals(program *ast.Program) []string {
+	seen := make(map[string]bool)
+	var names []string
+	declare := func(name *ast.Identifier) {
+		if name != nil && !seen[name.Value] {
+			seen[name.Value] = true
+			names = append(names, name.Value)
+		}
+	}
+
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			return false
+		case *ast.LetStatement:
+			declare(n.Name)
+			for _, name := range n.Rest {
+				declare(name)
+			}
+		case *ast.ForExpression:
+			declare(n.Variable)
+		}
+		return true
+	})
+
+	return names
+}
+
+// Undeclared returns the identifiers in program that refer to a variable
+// no scope could ever hold: neither a local of an enclosing function nor a
+// global the program binds, and not accepted by known, which callers use
+// for builtins and for globals supplied by the host or by other programs
+// sharing the environment. Unlike the NameError raised when evaluation
+// reaches such an identifier, this finds them in code that never runs.
+//
+// Each name is reported once, at its first occurrence in source order.
+func Undeclared(program *ast.Program, known func(name string) bool) []*ast.Identifier {
+	Resolve(program)
+
+	globals := make(map[string]bool)
+	for _, name := range Globals(program) {
+		globals[name] = true
+	}
+
+	reported := make(map[string]bool)
+	var undeclared []*ast.Identifier
+	ast.Inspect(program, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Identifier)
+		if !ok || ident.Resolved || globals[ident.Value] || reported[ident.Value] || known(ident.Value) {
+			return true
+		}
+
+		reported[ident.Value] = true
+		undeclared = append(undeclared, ident)
+		return true
+	})
+
+	return undeclared
+}
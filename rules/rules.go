@@ -0,0 +1,120 @@
+// Package rules evaluates sets of named Monkey conditions against data
+// supplied by a host, as a rules engine embedded in a Go program.
+//
+// A rule is an expression such as `age >= 18 && "admin" in roles` that must
+// evaluate to a boolean. The keys of the data become variables, so every
+// rule of a set sees the same values, and bindings made by one rule are not
+// visible to the others.
+package rules
+
+import (
+	"context"
+	"fmt"
+	"monkey/ast"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/optimizer"
+	"monkey/parser"
+	"strings"
+	"time"
+)
+
+// Set is a list of parsed rules, ready to be evaluated any number of times.
+// A Set may be evaluated from several goroutines at once, each with its own
+// Interpreter, but rules must not be added meanwhile.
+type Set struct {
+	rules []rule
+}
+
+type rule struct {
+	name    string
+	program *ast.Program
+}
+
+// Result is the outcome of evaluating one rule.
+type Result struct {
+	Name    string
+	Matched bool
+	Elapsed time.Duration
+	// Err is set if the rule failed or did not evaluate to a boolean, in
+	// which case Matched is false.
+	Err error
+}
+
+// New returns an empty Set.
+func New() *Set {
+	return &Set{}
+}
+
+// Add parses source and adds it to the set as a rule called name. Rules are
+// evaluated in the order they are added, and names must be unique.
+func (s *Set) Add(name, source string) error {
+	for _, r := range s.rules {
+		if r.name == name {
+			return fmt.Errorf("rule %s: already defined", name)
+		}
+	}
+
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return fmt.Errorf("rule %s: %s", name, strings.Join(p.Errors(), "; "))
+	}
+	optimizer.Fold(program)
+
+	s.rules = append(s.rules, rule{name: name, program: program})
+	return nil
+}
+
+// Evaluate evaluates every rule of the set with in against data, which is
+// converted with object.FromGo. It fails only if data cannot be converted;
+// errors of individual rules are reported in their results.
+func (s *Set) Evaluate(ctx context.Context, in *evaluator.Interpreter, data map[string]any) ([]Result, error) {
+	env := object.NewEnvironment()
+	for name, value := range data {
+		obj, err := object.FromGo(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		env.Set(name, obj)
+	}
+
+	results := make([]Result, 0, len(s.rules))
+	for _, r := range s.rules {
+		start := time.Now()
+		value := in.EvalContext(ctx, r.program, object.ExtendEnvironment(env))
+		result := Result{Name: r.name, Elapsed: time.Since(start)}
+
+		switch value := value.(type) {
+		case *object.Error:
+			result.Err = fmt.Errorf("rule %s: %w", r.name, value)
+		case *object.Boolean:
+			result.Matched = value.Value
+		default:
+			result.Err = fmt.Errorf("rule %s: evaluated to %s, not a boolean", r.name, typeOf(value))
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// Matched returns the names of the rules in results that matched.
+func Matched(results []Result) []string {
+	var names []string
+	for _, result := range results {
+		if result.Matched {
+			names = append(names, result.Name)
+		}
+	}
+	return names
+}
+
+func typeOf(obj object.Object) object.ObjectType {
+	if obj == nil {
+		return object.NULL_OBJ
+	}
+	return obj.Type()
+}
@@ -0,0 +1,90 @@
+package rules
+
+import (
+	"context"
+	"monkey/evaluator"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	set := New()
+	for _, r := range []struct{ name, source string }{
+		{"adult", "age >= 18"},
+		{"admin", `"admin" in roles`},
+		{"senior_admin", `let senior = age >= 65; senior && "admin" in roles`},
+		{"broken", "age / 0 > 1"},
+		{"not_boolean", "age + 1"},
+		{"sees_no_other_rule", `let senior = true; senior`},
+	} {
+		if err := set.Add(r.name, r.source); err != nil {
+			t.Fatalf("Add(%q): %v", r.name, err)
+		}
+	}
+
+	data := map[string]any{"age": 70, "roles": []any{"user", "admin"}}
+	results, err := set.Evaluate(context.Background(), evaluator.New(), data)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+
+	expected := []string{"adult", "admin", "senior_admin", "sees_no_other_rule"}
+	if got := Matched(results); !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong matches. want=%v, got=%v", expected, got)
+	}
+
+	errors := map[string]string{
+		"broken":      "rule broken: ZeroDivisionError: division by zero",
+		"not_boolean": "rule not_boolean: evaluated to INTEGER, not a boolean",
+	}
+	for _, result := range results {
+		want, failed := errors[result.Name]
+		switch {
+		case failed && (result.Err == nil || result.Err.Error() != want):
+			t.Errorf("%s: wrong error. want=%q, got=%v", result.Name, want, result.Err)
+		case !failed && result.Err != nil:
+			t.Errorf("%s: unexpected error: %v", result.Name, result.Err)
+		}
+	}
+
+	results, err = set.Evaluate(context.Background(), evaluator.New(), map[string]any{"age": 12, "roles": []any{}})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if got := Matched(results); !reflect.DeepEqual(got, []string{"sees_no_other_rule"}) {
+		t.Errorf("wrong matches for a child. got=%v", got)
+	}
+}
+
+func TestAddErrors(t *testing.T) {
+	set := New()
+	if err := set.Add("a", "true"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	tests := []struct {
+		name, source string
+		err          string
+	}{
+		{"a", "false", "rule a: already defined"},
+		{"b", "1 +", "rule b: no prefix parse function for EOF found"},
+	}
+
+	for _, tt := range tests {
+		err := set.Add(tt.name, tt.source)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Errorf("Add(%q, %q): want error %q, got %v", tt.name, tt.source, tt.err, err)
+		}
+	}
+}
+
+func TestEvaluateUnconvertibleData(t *testing.T) {
+	set := New()
+	set.Add("a", "true")
+
+	_, err := set.Evaluate(context.Background(), evaluator.New(), map[string]any{"ch": make(chan int)})
+	if err == nil || !strings.HasPrefix(err.Error(), "ch: ") {
+		t.Errorf("want a conversion error for ch, got %v", err)
+	}
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"monkey/ast"
+	"monkey/diagnostic"
+	"monkey/evaluator"
+	"monkey/history"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/optimizer"
+	"monkey/parser"
+	"monkey/resolver"
+	"os"
+	"runtime/metrics"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+func runScript(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	warnings := flags.Bool("warnings", false, "report suspicious but legal code on stderr")
+	steps := flags.Int("history", 0, "if the script fails, show the last `n` statements and the variables they changed")
+	record := flags.String("record", "", "write the values of nondeterministic builtins such as now to `file`")
+	replay := flags.String("replay", "", "take the values of nondeterministic builtins from a `file` written by -record")
+	strict := flags.Bool("strict", false, "refuse to run scripts referring to variables that are never declared, even in code that does not run")
+	noPrelude := flags.Bool("no-prelude", false, "leave out the functions the standard prelude defines, such as map and filter")
+	showStats := flags.Bool("stats", false, "after the run, report on stderr the values created by type, environments, call depth, heap use and wall time")
+	allow := flags.String("allow", "", "grant the scripts the comma-separated `capabilities`, such as database,network")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey run [-warnings] [-strict] [-no-prelude] [-stats] [-allow capabilities] [-history n] [-record file | -replay file] script.mky [more.mky ...] [--] [arg ...]")
+		fmt.Fprintln(flags.Output(), "Runs the scripts in order as one program sharing its global variables. If they")
+		fmt.Fprintln(flags.Output(), "define a main function, main is then called with an array of the first script's")
+		fmt.Fprintln(flags.Output(), "path and the args, and an integer from 0 to 255 it returns is the exit code;")
+		fmt.Fprintln(flags.Output(), "other integers are reported and exit with 1. Functions scheduled with every and")
+		fmt.Fprintln(flags.Output(), "after run last, until none remain. Scripts may trap signals such as SIGINT with")
+		fmt.Fprintln(flags.Output(), "on_signal. Builtins reaching outside the interpreter, such as ws_connect, fail")
+		fmt.Fprintln(flags.Output(), "unless their capability is granted with -allow; monkey version lists the")
+		fmt.Fprintln(flags.Output(), "capabilities and the database drivers db_open can use.")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() < 1 || (*record != "" && *replay != "") {
+		flags.Usage()
+		return 2
+	}
+	capabilities, err := parseCapabilities(*allow)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey run: %s\n", err)
+		return 2
+	}
+
+	paths, rest := splitScripts(flags.Args())
+	scripts := make([]script, 0, len(paths))
+	failed := false
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "monkey run: %s\n", err)
+			return 1
+		}
+
+		p := parser.New(lexer.New(string(source)))
+		program := p.ParseProgram()
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, msg)
+			failed = true
+		}
+		if len(p.Errors()) == 0 {
+			optimizer.Fold(program)
+		}
+		if *warnings {
+			for _, d := range p.Warnings() {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, d)
+			}
+		}
+		scripts = append(scripts, script{path: path, program: program})
+	}
+	if failed {
+		return 1
+	}
+
+	// current is the script being run, for reports made while it runs.
+	current := paths[0]
+
+	// A script run from the command line owns the process, so it may trap
+	// signals such as SIGINT to clean up.
+	opts := []evaluator.Option{evaluator.WithSignalHandling()}
+	if *warnings {
+		opts = append(opts, evaluator.WithDiagnostics(func(d diagnostic.Diagnostic) {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", current, d)
+		}))
+	}
+
+	var recorder *history.Recorder
+	if *steps > 0 {
+		recorder = history.New(*steps)
+		opts = append(opts, evaluator.WithTrace(recorder.Trace))
+	}
+
+	var log *evaluator.ReplayLog
+	if *replay != "" {
+		if log, err = readReplayLog(*replay); err != nil {
+			fmt.Fprintf(os.Stderr, "monkey run: %s\n", err)
+			return 1
+		}
+		opts = append(opts, evaluator.WithReplay(log))
+	} else if *record != "" {
+		log = &evaluator.ReplayLog{}
+		opts = append(opts, evaluator.WithRecording(log))
+	}
+
+	if *noPrelude {
+		opts = append(opts, evaluator.WithoutPrelude())
+	}
+	if len(capabilities) > 0 {
+		opts = append(opts, evaluator.WithCapabilities(capabilities...))
+	}
+
+	var stats evaluator.Stats
+	if *showStats {
+		opts = append(opts, evaluator.WithStats(&stats))
+	}
+
+	interpreter := evaluator.New(opts...)
+	if *strict && !declared(scripts, interpreter) {
+		return 1
+	}
+	usage := startUsage()
+	code := execute(os.Stderr, interpreter, scripts, &current, append([]string{paths[0]}, rest...), recorder)
+	interpreter.Close()
+	if *showStats {
+		printStats(os.Stderr, &stats, usage())
+	}
+	if *record != "" {
+		if err := writeReplayLog(*record, log); err != nil {
+			fmt.Fprintf(os.Stderr, "monkey run: %s\n", err)
+			return 1
+		}
+	}
+	return code
+}
+
+// declared reports identifiers in scripts that are neither globals of one
+// of them nor builtins, and returns whether there were none.
+func declared(scripts []script, interpreter *evaluator.Interpreter) bool {
+	globals := make(map[string]bool)
+	for _, s := range scripts {
+		for _, name := range resolver.Globals(s.program) {
+			globals[name] = true
+		}
+	}
+	known := func(name string) bool {
+		return globals[name] || interpreter.HasBuiltin(name)
+	}
+
+	ok := true
+	for _, s := range scripts {
+		for _, ident := range resolver.Undeclared(s.program, known) {
+			fmt.Fprintf(os.Stderr, "%s: undeclared identifier %s\n", s.path, ident.Value)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// script is a parsed source file.
+type script struct {
+	path    string
+	program *ast.Program
+}
+
+// splitScripts splits the operands of run into the leading script paths,
+// those ending in .mky, and the args for main after them. A "--" ends the
+// paths early, so args may end in .mky too.
+func splitScripts(operands []string) (paths, args []string) {
+	for i, operand := range operands {
+		if i > 0 && operand == "--" {
+			return operands[:i], operands[i+1:]
+		}
+		if i > 0 && !strings.HasSuffix(operand, ".mky") {
+			return operands[:i], operands[i:]
+		}
+	}
+	return operands, nil
+}
+
+// parseCapabilities parses the comma-separated capability names given to
+// -allow, which must each be one of evaluator.Capabilities.
+func parseCapabilities(list string) ([]evaluator.Capability, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var caps []evaluator.Capability
+	for _, name := range strings.Split(list, ",") {
+		c := evaluator.Capability(strings.TrimSpace(name))
+		if !slices.Contains(evaluator.Capabilities, c) {
+			return nil, fmt.Errorf("-allow: unknown capability %q, want one of %v", name, evaluator.Capabilities)
+		}
+		caps = append(caps, c)
+	}
+	return caps, nil
+}
+
+// execute runs the scripts in one environment, then main if they define it,
+// and then any timers they scheduled, and returns the exit code. Errors are
+// reported on w, attributed to the script that was running, or for main and
+// timers to the script defining main. current is kept up to date for other
+// reports.
+func execute(w io.Writer, interpreter *evaluator.Interpreter, scripts []script, current *string, argv []string, recorder *history.Recorder) int {
+	env := object.NewEnvironment()
+	for _, s := range scripts {
+		*current = s.path
+		result := interpreter.Eval(s.program, env)
+		if err, ok := result.(*object.Error); ok {
+			return runFailed(w, s.path, err, recorder)
+		}
+	}
+
+	*current = definer(scripts, "main")
+	code := 0
+	if main, ok := env.Get("main"); ok {
+		if _, isFunction := main.(*object.Function); isFunction {
+			elements := make([]object.Object, 0, len(argv))
+			for _, arg := range argv {
+				elements = append(elements, object.NewString(arg))
+			}
+
+			switch result := interpreter.Call(main, &object.Array{Elements: elements}).(type) {
+			case *object.Error:
+				return runFailed(w, *current, result, recorder)
+			case *object.Integer:
+				// Exit statuses are a byte, so larger codes would be cut
+				// down to another code, 256 even to success.
+				if result.Value < 0 || result.Value > 255 {
+					fmt.Fprintf(w, "%s: main returned exit code %d, outside 0 to 255\n", *current, result.Value)
+					code = 1
+				} else {
+					code = int(result.Value)
+				}
+			}
+		}
+	}
+
+	if err, ok := interpreter.RunTimers(context.Background()).(*object.Error); ok {
+		return runFailed(w, *current, err, recorder)
+	}
+	return code
+}
+
+// definer returns the path of the last script binding name at the top
+// level, or of the first script if none does.
+func definer(scripts []script, name string) string {
+	path := scripts[0].path
+	for _, s := range scripts {
+		for _, stmt := range s.program.Statements {
+			if let, ok := stmt.(*ast.LetStatement); ok && let.Name != nil && let.Name.Value == name {
+				path = s.path
+			}
+		}
+	}
+	return path
+}
+
+func readReplayLog(path string) (*evaluator.ReplayLog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	log, err := evaluator.DecodeReplayLog(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return log, nil
+}
+
+func writeReplayLog(path string, log *evaluator.ReplayLog) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := log.Encode(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// usage is what a run cost the process as a whole.
+type usage struct {
+	elapsed   time.Duration
+	heapBytes uint64
+	gcCycles  uint64
+}
+
+// startUsage starts measuring the process; the returned function reports
+// the usage since.
+func startUsage() func() usage {
+	samples := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}, {Name: "/gc/cycles/total:gc-cycles"}}
+	metrics.Read(samples)
+	heap, cycles := samples[0].Value.Uint64(), samples[1].Value.Uint64()
+	start := time.Now()
+
+	return func() usage {
+		elapsed := time.Since(start)
+		metrics.Read(samples)
+		return usage{
+			elapsed:   elapsed,
+			heapBytes: samples[0].Value.Uint64() - heap,
+			gcCycles:  samples[1].Value.Uint64() - cycles,
+		}
+	}
+}
+
+func printStats(w io.Writer, stats *evaluator.Stats, u usage) {
+	types := make([]object.ObjectType, 0, len(stats.Values))
+	total := 0
+	for t, n := range stats.Values {
+		types = append(types, t)
+		total += n
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if stats.Values[types[i]] != stats.Values[types[j]] {
+			return stats.Values[types[i]] > stats.Values[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	fmt.Fprintln(w, "stats:")
+	fmt.Fprintf(w, "  wall time       %s\n", u.elapsed.Round(time.Microsecond))
+	fmt.Fprintf(w, "  heap allocated  %d bytes in %d GC cycles\n", u.heapBytes, u.gcCycles)
+	fmt.Fprintf(w, "  max call depth  %d\n", stats.MaxDepth)
+	fmt.Fprintf(w, "  environments    %d\n", stats.Environments)
+	fmt.Fprintf(w, "  values created  %d\n", total)
+	for _, t := range types {
+		fmt.Fprintf(w, "    %-12s  %d\n", t, stats.Values[t])
+	}
+}
+
+func runFailed(w io.Writer, path string, err *object.Error, recorder *history.Recorder) int {
+	if recorder != nil {
+		printHistory(w, recorder.Steps())
+	}
+	fmt.Fprintf(w, "%s: %s\n", path, err)
+	return 1
+}
+
+func printHistory(w io.Writer, steps []history.Step) {
+	fmt.Fprintln(w, "recent history, oldest first:")
+	for _, step := range steps {
+		fmt.Fprintf(w, "  %s\n", step.Statement)
+		for _, change := range step.Changes {
+			if change.Old == nil {
+				fmt.Fprintf(w, "    %s = %s\n", change.Name, oneLine(change.New))
+			} else {
+				fmt.Fprintf(w, "    %s = %s (was %s)\n", change.Name, oneLine(change.New), oneLine(change.Old))
+			}
+		}
+	}
+}
+
+// oneLine shortens a value's Inspect output to fit on one line.
+func oneLine(obj object.Object) string {
+	s := strings.Join(strings.Fields(obj.Inspect()), " ")
+	if len(s) > 60 {
+		s = s[:57] + "..."
+	}
+	return s
+}
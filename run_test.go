@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/parser"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitScripts(t *testing.T) {
+	tests := []struct {
+		operands []string
+		paths    []string
+		args     []string
+	}{
+		{[]string{"a.mky"}, []string{"a.mky"}, nil},
+		{[]string{"a.mky", "b.mky"}, []string{"a.mky", "b.mky"}, nil},
+		{[]string{"a.mky", "b.mky", "x", "c.mky"}, []string{"a.mky", "b.mky"}, []string{"x", "c.mky"}},
+		{[]string{"a.mky", "--", "c.mky"}, []string{"a.mky"}, []string{"c.mky"}},
+		{[]string{"a.mky", "b.mky", "--"}, []string{"a.mky", "b.mky"}, []string{}},
+		{[]string{"a.mky", "--", "--", "x"}, []string{"a.mky"}, []string{"--", "x"}},
+		// The first operand is always a script, whatever it is called.
+		{[]string{"script", "x"}, []string{"script"}, []string{"x"}},
+		{[]string{"--", "x"}, []string{"--"}, []string{"x"}},
+	}
+
+	for _, tt := range tests {
+		paths, args := splitScripts(tt.operands)
+		if !reflect.DeepEqual(paths, tt.paths) || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("splitScripts(%q) = %q, %q, want %q, %q", tt.operands, paths, args, tt.paths, tt.args)
+		}
+	}
+}
+
+// parseScripts parses sources, alternating paths and their source, into
+// scripts.
+func parseScripts(t *testing.T, sources ...string) []script {
+	t.Helper()
+
+	var scripts []script
+	for i := 0; i < len(sources); i += 2 {
+		p := parser.New(lexer.New(sources[i+1]))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%s: parser errors: %v", sources[i], p.Errors())
+		}
+		scripts = append(scripts, script{path: sources[i], program: program})
+	}
+	return scripts
+}
+
+func TestDefiner(t *testing.T) {
+	scripts := parseScripts(t,
+		"a.mky", `let main = fn(args) { 1 }; let helper = 2;`,
+		"b.mky", `let main = fn(args) { 2 }; let x = fn() { let helper = 3; };`,
+		"c.mky", `main = 4;`,
+	)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		// The last script to bind name wins, as it is the binding in effect.
+		{"main", "b.mky"},
+		// Only top-level bindings count.
+		{"helper", "a.mky"},
+		// Without a binding, the first script is blamed.
+		{"missing", "a.mky"},
+	}
+
+	for _, tt := range tests {
+		if got := definer(scripts, tt.name); got != tt.expected {
+			t.Errorf("definer(%s) = %s, want %s", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name     string
+		sources  []string
+		argv     []string
+		code     int
+		stderr   string
+		stdout   string
+		finished string
+	}{
+		{
+			name:     "globals are shared",
+			sources:  []string{"a.mky", `let x = 2;`, "b.mky", `puts(x * 3);`},
+			stdout:   "6\n",
+			finished: "a.mky",
+		},
+		{
+			name: "main gets the args and returns the exit code",
+			sources: []string{
+				"a.mky", `let greeting = "hi";`,
+				"b.mky", `let main = fn(argv) { puts(greeting, argv); 3 };`,
+			},
+			argv:     []string{"a.mky", "x", "y"},
+			code:     3,
+			stdout:   "hi\n[a.mky, x, y]\n",
+			finished: "b.mky",
+		},
+		{
+			name:     "main returning a non-integer exits with 0",
+			sources:  []string{"a.mky", `let main = fn(argv) { "done" };`},
+			finished: "a.mky",
+		},
+		{
+			name:     "main returning an exit code out of range exits with 1",
+			sources:  []string{"a.mky", `let y = 1;`, "b.mky", `let main = fn(argv) { 256 };`},
+			code:     1,
+			stderr:   "b.mky: main returned exit code 256, outside 0 to 255\n",
+			finished: "b.mky",
+		},
+		{
+			name:     "main returning a negative exit code exits with 1",
+			sources:  []string{"a.mky", `let main = fn(argv) { after(1, fn() { puts("timer") }); -1 };`},
+			code:     1,
+			stdout:   "timer\n",
+			stderr:   "a.mky: main returned exit code -1, outside 0 to 255\n",
+			finished: "a.mky",
+		},
+		{
+			name:     "an error is blamed on the script that was running",
+			sources:  []string{"a.mky", `let x = 1;`, "b.mky", `x + nope;`, "c.mky", `puts("unreached");`},
+			code:     1,
+			stderr:   "b.mky: NameError: identifier not found: nope\n",
+			finished: "b.mky",
+		},
+		{
+			name:     "an error in main is blamed on the script defining it",
+			sources:  []string{"a.mky", `let main = fn(argv) { -true };`, "b.mky", `let y = 1;`},
+			code:     1,
+			stderr:   "a.mky: TypeError: unknown operator: -BOOLEAN\n",
+			finished: "a.mky",
+		},
+		{
+			name:     "timers run after main and are blamed on its script",
+			sources:  []string{"a.mky", `let y = 1;`, "b.mky", `let main = fn(argv) { after(1, fn() { -true }); puts("main") };`},
+			code:     1,
+			stdout:   "main\n",
+			stderr:   "b.mky: TypeError: unknown operator: -BOOLEAN\n",
+			finished: "b.mky",
+		},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		interpreter := evaluator.New(evaluator.WithStreams(evaluator.Streams{Stdin: strings.NewReader(""), Stdout: &stdout}))
+		scripts := parseScripts(t, tt.sources...)
+		current := ""
+
+		code := execute(&stderr, interpreter, scripts, &current, tt.argv, nil)
+		interpreter.Close()
+
+		if code != tt.code {
+			t.Errorf("%s: exit code %d, want %d", tt.name, code, tt.code)
+		}
+		if stderr.String() != tt.stderr {
+			t.Errorf("%s: wrong stderr. want=%q, got=%q", tt.name, tt.stderr, stderr.String())
+		}
+		if stdout.String() != tt.stdout {
+			t.Errorf("%s: wrong stdout. want=%q, got=%q", tt.name, tt.stdout, stdout.String())
+		}
+		if current != tt.finished {
+			t.Errorf("%s: current is %s, want %s", tt.name, current, tt.finished)
+		}
+	}
+}
+
+func TestParseCapabilities(t *testing.T) {
+	tests := []struct {
+		list     string
+		expected []evaluator.Capability
+		err      string
+	}{
+		{"", nil, ""},
+		{"network", []evaluator.Capability{evaluator.CapabilityNetwork}, ""},
+		{"database, network", []evaluator.Capability{evaluator.CapabilityDatabase, evaluator.CapabilityNetwork}, ""},
+		{"network,files", nil, `-allow: unknown capability "files", want one of [database network]`},
+		{"network,", nil, `-allow: unknown capability "", want one of [database network]`},
+	}
+
+	for _, tt := range tests {
+		caps, err := parseCapabilities(tt.list)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("parseCapabilities(%q): error %v, want %q", tt.list, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(caps, tt.expected) {
+			t.Errorf("parseCapabilities(%q) = %v, %v, want %v", tt.list, caps, err, tt.expected)
+		}
+	}
+}
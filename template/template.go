@@ -0,0 +1,386 @@
+// Package template renders text containing Monkey expressions.
+//
+// A template is plain text with three kinds of tags:
+//
+//	{{ expr }}                          inserts the value of expr
+//	{% if expr %} ... {% else %} ... {% endif %}
+//	{% for name in expr %} ... {% endfor %}
+//
+// for iterates over the elements of an array or the keys of a hash. A
+// newline directly after a {% %} tag is dropped so block tags can sit on
+// lines of their own.
+package template
+
+import (
+	"fmt"
+	"io"
+	"monkey/ast"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/optimizer"
+	"monkey/parser"
+	"monkey/token"
+	"strings"
+)
+
+// Template is a parsed template, ready to be executed any number of times.
+type Template struct {
+	name  string
+	nodes []node
+}
+
+type node interface{}
+
+type textNode struct {
+	text string
+}
+
+type exprNode struct {
+	line    int
+	program *ast.Program
+}
+
+type ifNode struct {
+	line        int
+	condition   *ast.Program
+	consequence []node
+	alternative []node
+}
+
+type forNode struct {
+	line     int
+	variable string
+	iterable *ast.Program
+	body     []node
+}
+
+// Parse parses text as a template. name is used in error messages.
+func Parse(name, text string) (*Template, error) {
+	p := &templateParser{name: name, input: text, line: 1}
+
+	nodes, end, err := p.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+	if end != "" {
+		return nil, p.errorf("unexpected {%% %s %%}", end)
+	}
+
+	return &Template{name: name, nodes: nodes}, nil
+}
+
+// Execute renders the template to w, evaluating expressions with in against
+// a child of env so loop variables do not leak into it.
+func (t *Template) Execute(w io.Writer, in *evaluator.Interpreter, env *object.Environment) error {
+	r := &renderer{name: t.name, in: in, w: w}
+	return r.render(t.nodes, object.ExtendEnvironment(env))
+}
+
+type templateParser struct {
+	name  string
+	input string
+	pos   int
+	line  int
+}
+
+func (p *templateParser) errorf(format string, a ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.name, p.line, fmt.Sprintf(format, a...))
+}
+
+func (p *templateParser) advance(n int) string {
+	consumed := p.input[p.pos : p.pos+n]
+	p.line += strings.Count(consumed, "\n")
+	p.pos += n
+	return consumed
+}
+
+// parseNodes parses until the end of input or a block tag that closes or
+// splits the enclosing block, whose keyword it returns.
+func (p *templateParser) parseNodes() ([]node, string, error) {
+	nodes := []node{}
+
+	for p.pos < len(p.input) {
+		rest := p.input[p.pos:]
+		next := indexTag(rest)
+
+		if next < 0 {
+			nodes = append(nodes, &textNode{text: p.advance(len(rest))})
+			break
+		}
+
+		if next > 0 {
+			nodes = append(nodes, &textNode{text: p.advance(next)})
+		}
+
+		if strings.HasPrefix(p.input[p.pos:], "{{") {
+			expr, err := p.parseExpressionTag()
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, expr)
+			continue
+		}
+
+		line := p.line
+		keyword, args, err := p.parseBlockTag()
+		if err != nil {
+			return nil, "", err
+		}
+
+		switch keyword {
+		case "if":
+			n, err := p.parseIf(line, args)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, n)
+		case "for":
+			n, err := p.parseFor(line, args)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, n)
+		case "else", "endif", "endfor":
+			return nodes, keyword, nil
+		default:
+			return nil, "", p.errorf("unknown tag {%% %s %%}", keyword)
+		}
+	}
+
+	return nodes, "", nil
+}
+
+// indexTag returns the index of the first {{ or {% in s, or -1.
+func indexTag(s string) int {
+	for i := 0; i+1 < len(s); i++ {
+		if s[i] == '{' && (s[i+1] == '{' || s[i+1] == '%') {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// closingIndex returns the index of the delimiter closing the tag that s
+// starts with, or -1. Occurrences of delimiter inside the tag's strings or
+// braces, as in {{ {"a": {"b": 1}}["a"] }}, do not close it.
+func closingIndex(s, delimiter string) int {
+	for from := 2; from <= len(s); {
+		i := strings.Index(s[from:], delimiter)
+		if i < 0 {
+			return -1
+		}
+		if end := from + i; complete(s[2:end]) {
+			return end
+		}
+		from += i + 1
+	}
+
+	return -1
+}
+
+// complete reports whether source, lexed as Monkey, closes every brace it
+// opens and every string it starts.
+func complete(source string) bool {
+	l := lexer.New(source)
+	depth := 0
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch {
+		case tok.Type == token.LBRACE:
+			depth++
+		case tok.Type == token.RBRACE:
+			depth--
+		case tok.Type == token.ILLEGAL && strings.HasPrefix(tok.Literal, `"`):
+			return false
+		}
+	}
+
+	return depth <= 0
+}
+
+func (p *templateParser) parseExpressionTag() (node, error) {
+	line := p.line
+	end := closingIndex(p.input[p.pos:], "}}")
+	if end < 0 {
+		return nil, p.errorf("unclosed {{")
+	}
+
+	source := p.advance(end + 2)
+	program, err := p.parseProgram(source[2 : len(source)-2])
+	if err != nil {
+		return nil, err
+	}
+
+	return &exprNode{line: line, program: program}, nil
+}
+
+func (p *templateParser) parseBlockTag() (string, string, error) {
+	end := closingIndex(p.input[p.pos:], "%}")
+	if end < 0 {
+		return "", "", p.errorf("unclosed {%%")
+	}
+
+	tag := p.advance(end + 2)
+	if strings.HasPrefix(p.input[p.pos:], "\n") {
+		p.advance(1)
+	}
+
+	content := strings.TrimSpace(tag[2 : len(tag)-2])
+	keyword, args, _ := strings.Cut(content, " ")
+	return keyword, strings.TrimSpace(args), nil
+}
+
+func (p *templateParser) parseIf(line int, condition string) (node, error) {
+	program, err := p.parseProgram(condition)
+	if err != nil {
+		return nil, err
+	}
+	n := &ifNode{line: line, condition: program}
+
+	var end string
+	n.consequence, end, err = p.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if end == "else" {
+		n.alternative, end, err = p.parseNodes()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if end != "endif" {
+		return nil, p.errorf("{%% if %%} starting on line %d is not closed by {%% endif %%}", line)
+	}
+
+	return n, nil
+}
+
+func (p *templateParser) parseFor(line int, args string) (node, error) {
+	variable, iterable, ok := strings.Cut(args, " in ")
+	variable = strings.TrimSpace(variable)
+	if !ok || variable == "" || strings.ContainsAny(variable, " \t") {
+		return nil, p.errorf("expected {%% for name in expression %%}")
+	}
+
+	program, err := p.parseProgram(iterable)
+	if err != nil {
+		return nil, err
+	}
+	n := &forNode{line: line, variable: variable, iterable: program}
+
+	var end string
+	n.body, end, err = p.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if end != "endfor" {
+		return nil, p.errorf("{%% for %%} starting on line %d is not closed by {%% endfor %%}", line)
+	}
+
+	return n, nil
+}
+
+func (p *templateParser) parseProgram(source string) (*ast.Program, error) {
+	mp := parser.New(lexer.New(source))
+	program := mp.ParseProgram()
+
+	if len(mp.Errors()) != 0 {
+		return nil, p.errorf("%s", strings.Join(mp.Errors(), "; "))
+	}
+	optimizer.Fold(program)
+
+	return program, nil
+}
+
+type renderer struct {
+	name string
+	in   *evaluator.Interpreter
+	w    io.Writer
+}
+
+func (r *renderer) eval(line int, program *ast.Program, env *object.Environment) (object.Object, error) {
+	result := r.in.Eval(program, env)
+	if errObj, ok := result.(*object.Error); ok {
+		return nil, fmt.Errorf("%s:%d: %w", r.name, line, errObj)
+	}
+
+	if result == nil {
+		return evaluator.NULL, nil
+	}
+
+	return result, nil
+}
+
+func (r *renderer) render(nodes []node, env *object.Environment) error {
+	for _, n := range nodes {
+		var err error
+
+		switch n := n.(type) {
+		case *textNode:
+			_, err = io.WriteString(r.w, n.text)
+		case *exprNode:
+			var value object.Object
+			value, err = r.eval(n.line, n.program, env)
+			if err == nil {
+				_, err = io.WriteString(r.w, value.Inspect())
+			}
+		case *ifNode:
+			err = r.renderIf(n, env)
+		case *forNode:
+			err = r.renderFor(n, env)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *renderer) renderIf(n *ifNode, env *object.Environment) error {
+	condition, err := r.eval(n.line, n.condition, env)
+	if err != nil {
+		return err
+	}
+
+	if evaluator.IsTruthy(condition) {
+		return r.render(n.consequence, env)
+	}
+
+	return r.render(n.alternative, env)
+}
+
+func (r *renderer) renderFor(n *forNode, env *object.Environment) error {
+	iterable, err := r.eval(n.line, n.iterable, env)
+	if err != nil {
+		return err
+	}
+
+	var items []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		items = iterable.Elements
+	case *object.Hash:
+		for _, pair := range iterable.Ordered() {
+			items = append(items, pair.Key)
+		}
+	default:
+		return fmt.Errorf("%s:%d: cannot iterate over %s", r.name, n.line, iterable.Type())
+	}
+
+	for _, item := range items {
+		loopEnv := object.ExtendEnvironment(env)
+		loopEnv.Set(n.variable, item)
+
+		if err := r.render(n.body, loopEnv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
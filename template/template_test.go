@@ -0,0 +1,125 @@
+package template
+
+import (
+	"bytes"
+	"monkey/evaluator"
+	"monkey/object"
+	"strings"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain text", "plain text"},
+		{"port = {{ port }}", "port = 8080"},
+		{"{{ port + 1 }} { not a tag }", "8081 { not a tag }"},
+		{"{% if debug %}on{% else %}off{% endif %}", "off"},
+		{"{% if port > 80 %}high{% endif %}", "high"},
+		{"{% for h in hosts %}[{{ h }}]{% endfor %}", "[a][b]"},
+		{
+			"{% for h in hosts %}\n{% for p in [1, 2] %}\n{{ h }}:{{ p }}\n{% endfor %}\n{% endfor %}\n",
+			"a:1\na:2\nb:1\nb:2\n",
+		},
+		{"{% for k in {\"only\": 1} %}{{ k }}{% endfor %}", "only"},
+		{"{{ let greeting = \"hi\"; greeting }}", "hi"},
+		{"{{ {\"a\": {\"b\": 1}}[\"a\"][\"b\"] }}", "1"},
+		{"{{ {\"a\": 2}}}", "{a: 2}"},
+		{"{{ \"}}\" }} and {% if len(\"%}\") == 2 %}yes{% endif %}", "}} and yes"},
+	}
+
+	for _, tt := range tests {
+		tmpl, err := Parse("test", tt.input)
+		if err != nil {
+			t.Errorf("%q: parse error: %s", tt.input, err)
+			continue
+		}
+
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, evaluator.New(), testEnvironment()); err != nil {
+			t.Errorf("%q: execute error: %s", tt.input, err)
+			continue
+		}
+
+		if out.String() != tt.expected {
+			t.Errorf("%q: wrong output. want=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestLoopVariablesDoNotLeak(t *testing.T) {
+	env := testEnvironment()
+	tmpl, err := Parse("test", "{% for h in hosts %}{% endfor %}")
+	if err != nil {
+		t.Fatalf("parse error: %s", err)
+	}
+
+	if err := tmpl.Execute(&bytes.Buffer{}, evaluator.New(), env); err != nil {
+		t.Fatalf("execute error: %s", err)
+	}
+
+	if _, ok := env.Get("h"); ok {
+		t.Errorf("loop variable leaked into the caller's environment")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{{ port", "test:1: unclosed {{"},
+		{"line\n{% if x %}", "test:2: {% if %} starting on line 2 is not closed by {% endif %}"},
+		{"{% endfor %}", "test:1: unexpected {% endfor %}"},
+		{"{% while x %}", "test:1: unknown tag {% while %}"},
+		{"{% for in xs %}{% endfor %}", "test:1: expected {% for name in expression %}"},
+		{"{{ let }}", "test:1: expected next token to be IDENT"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse("test", tt.input)
+		if err == nil {
+			t.Errorf("%q: expected a parse error", tt.input)
+			continue
+		}
+
+		if !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Errorf("%q: wrong error. want prefix %q, got=%q", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestExecuteErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"\n{{ missing }}", "test:2: NameError: identifier not found: missing"},
+		{"{% for x in port %}{% endfor %}", "test:1: cannot iterate over INTEGER"},
+	}
+
+	for _, tt := range tests {
+		tmpl, err := Parse("test", tt.input)
+		if err != nil {
+			t.Fatalf("%q: parse error: %s", tt.input, err)
+		}
+
+		err = tmpl.Execute(&bytes.Buffer{}, evaluator.New(), testEnvironment())
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%q: wrong error. want=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func testEnvironment() *object.Environment {
+	env := object.NewEnvironment()
+	env.Set("port", &object.Integer{Value: 8080})
+	env.Set("debug", evaluator.FALSE)
+	env.Set("hosts", &object.Array{Elements: []object.Object{
+		&object.String{Value: "a"},
+		&object.String{Value: "b"},
+	}})
+	return env
+}
@@ -7,6 +7,7 @@ const (
 	// Identifiers + literals
 	IDENT  = "IDENT"  // add, foobar, x, y, ...
 	INT    = "INT"    // 1343456
+	FLOAT  = "FLOAT"  // 3.14
 	STRING = "STRING" // "makarena"
 
 	// Operators
@@ -16,13 +17,31 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	POWER    = "**"
 
-	LT = "<"
-	GT = ">"
+	// SLASH divides exactly, giving a float even for two integers;
+	// FLOOR_SLASH rounds the quotient toward negative infinity, giving an
+	// integer for two integers.
+	FLOOR_SLASH = "//"
+
+	PLUS_ASSIGN        = "+="
+	MINUS_ASSIGN       = "-="
+	ASTERISK_ASSIGN    = "*="
+	SLASH_ASSIGN       = "/="
+	POWER_ASSIGN       = "**="
+	FLOOR_SLASH_ASSIGN = "//="
+
+	LT    = "<"
+	GT    = ">"
+	LT_EQ = "<="
+	GT_EQ = ">="
 
 	EQ     = "=="
 	NOT_EQ = "!="
 
+	AND = "&&"
+	OR  = "||"
+
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
@@ -44,16 +63,34 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	IN       = "IN"
+	IS       = "IS"
+	UNLESS   = "UNLESS"
+	DO       = "DO"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	IMPORT   = "IMPORT"
 )
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"in":       IN,
+	"is":       IS,
+	"unless":   UNLESS,
+	"do":       DO,
+	"while":    WHILE,
+	"for":      FOR,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"import":   IMPORT,
 }
 
 type TokenType string
@@ -61,6 +98,12 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+
+	// Line and Column locate the token's first byte in the source, counting
+	// from 1; columns count bytes. They are 0 for tokens made up by the
+	// parser or optimizer rather than read.
+	Line   int
+	Column int
 }
 
 func LookUpIdentifierType(identifier string) TokenType {
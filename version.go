@@ -0,0 +1,36 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"monkey/evaluator"
+	"os"
+	"runtime"
+)
+
+func runVersion(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: monkey version")
+		return 2
+	}
+
+	fmt.Printf("monkey %s (engine %s, %s %s/%s)\n", evaluator.Version, evaluator.Engine, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Print("capabilities (grant with monkey run -allow):")
+	for _, c := range evaluator.Capabilities {
+		fmt.Printf(" %s", c)
+	}
+	fmt.Println()
+	// db_open can only reach databases whose driver is linked in, and this
+	// command links in none; hosts embedding the interpreter may.
+	fmt.Print("database drivers:")
+	drivers := sql.Drivers()
+	if len(drivers) == 0 {
+		fmt.Print(" none")
+	}
+	for _, d := range drivers {
+		fmt.Printf(" %s", d)
+	}
+	fmt.Println()
+
+	return 0
+}
@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// echoServer echoes every message back until the client closes.
+func echoServer(t *testing.T) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			messageType, data, err := conn.Read()
+			if err != nil {
+				return
+			}
+			if string(data) == "bye" {
+				return
+			}
+			if err := conn.Write(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestEcho(t *testing.T) {
+	conn, err := Dial(context.Background(), echoServer(t))
+	if err != nil {
+		t.Fatalf("dial: %s", err)
+	}
+	defer conn.Close()
+
+	tests := []struct {
+		messageType int
+		data        string
+	}{
+		{TextMessage, "hello"},
+		{TextMessage, ""},
+		{BinaryMessage, "\x00\x01\x02"},
+		{TextMessage, strings.Repeat("a", 200)},
+		{TextMessage, strings.Repeat("b", 70000)},
+	}
+
+	for _, tt := range tests {
+		if err := conn.Write(tt.messageType, []byte(tt.data)); err != nil {
+			t.Fatalf("write: %s", err)
+		}
+
+		messageType, data, err := conn.Read()
+		if err != nil {
+			t.Fatalf("read: %s", err)
+		}
+		if messageType != tt.messageType || string(data) != tt.data {
+			t.Errorf("wrong echo of %d bytes. got type %d, %d bytes", len(tt.data), messageType, len(data))
+		}
+	}
+}
+
+func TestPeerClose(t *testing.T) {
+	conn, err := Dial(context.Background(), echoServer(t))
+	if err != nil {
+		t.Fatalf("dial: %s", err)
+	}
+
+	conn.Write(TextMessage, []byte("bye"))
+	if _, _, err := conn.Read(); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+
+	if err := conn.Write(TextMessage, []byte("late")); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed writing after close, got %v", err)
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Errorf("closing a closed connection failed: %s", err)
+	}
+}
+
+func TestDialErrors(t *testing.T) {
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"http://example.com", `websocket: unsupported scheme "http"`},
+		{"ws" + strings.TrimPrefix(plain.URL, "http"), "websocket: handshake failed with status 404 Not Found"},
+	}
+
+	for _, tt := range tests {
+		_, err := Dial(context.Background(), tt.url)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: wrong error. want=%q, got=%v", tt.url, tt.expected, err)
+		}
+	}
+}